@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -33,14 +34,23 @@ var cli = []commands.Command{
 	&commands.PutCardCmd,
 	&commands.DeleteCardCmd,
 	&commands.DeleteCardsCmd,
+	&commands.ExpiringCardsCmd,
+	&commands.PurgeExpiredCmd,
 	&commands.GetTimeProfileCmd,
 	&commands.GetTimeProfilesCmd,
 	&commands.SetTimeProfileCmd,
 	&commands.SetTimeProfilesCmd,
+	&commands.SyncTimeProfilesCmd,
+	&commands.CheckTimeProfilesCmd,
 	&commands.ClearTimeProfilesCmd,
 	&commands.ClearTaskListCmd,
 	&commands.RefreshTaskListCmd,
 	&commands.AddTaskCmd,
+	&commands.SetTaskListCmd,
+	&commands.GetTaskListCmd,
+	&commands.DiffTaskListCmd,
+	&commands.ImportHolidaysCmd,
+	&commands.SimulateScheduleCmd,
 	&commands.GetStatusCmd,
 	&commands.ShowCmd,
 	&commands.GrantCmd,
@@ -48,11 +58,17 @@ var cli = []commands.Command{
 	&commands.LoadACLCmd,
 	&commands.GetACLCmd,
 	&commands.CompareACLCmd,
+	&commands.LintACLCmd,
 	&commands.GetEventsCmd,
 	&commands.GetEventCmd,
 	&commands.GetEventIndexCmd,
 	&commands.SetEventIndexCmd,
+	&commands.ExplainCmd,
 	&commands.OpenDoorCmd,
+	&commands.UnlockCmd,
+	&commands.LockdownCmd,
+	&commands.EvacuateCmd,
+	&commands.RestoreDoorsCmd,
 	&commands.SetPCControlCmd,
 	&commands.SetInterlockCmd,
 	&commands.ActivateKeypadsCmd,
@@ -61,6 +77,18 @@ var cli = []commands.Command{
 	&commands.SetFirstCardCmd,
 	&commands.RestoreDefaultParametersCmd,
 	&commands.ListenCmd,
+	&commands.MusterCmd,
+	&commands.HealthCheckCmd,
+	&commands.TUICmd,
+	&commands.ShellCmd,
+	&commands.RunCmd,
+	&commands.ServeCmd,
+	&commands.SimulateCmd,
+}
+
+func init() {
+	commands.ShellCmd.SetCommands(cli)
+	commands.RunCmd.SetCommands(cli)
 }
 
 var options = struct {
@@ -69,6 +97,7 @@ var options = struct {
 	broadcast types.BroadcastAddr
 	listen    types.ListenAddr
 	timeout   time.Duration
+	simulate  string
 	debug     bool
 }{}
 
@@ -83,6 +112,7 @@ func main() {
 	flag.Var(&broadcast, "broadcast", "Sets the IP address and port for UDP broadcast (e.g. 192.168.0.255:60000)")
 	flag.Var(&listen, "listen", "Sets the local IP address and port to which to bind for events (e.g. 192.168.0.100:60001)")
 	flag.DurationVar(&options.timeout, "timeout", 2500*time.Millisecond, "Sets the timeout for a response from a controller (e.g. 3.5s)")
+	flag.StringVar(&options.simulate, "simulate", options.simulate, "Uses simulated controllers with the state stored in the file instead of the real controllers")
 	flag.BoolVar(&options.debug, "debug", options.debug, "Displays internal information for diagnosing errors")
 	flag.Parse()
 
@@ -98,7 +128,7 @@ func main() {
 	}
 
 	// initialise execution context
-	conf := configuration(cmd)
+	conf, file := configuration(cmd)
 
 	if conf.BindAddress != nil {
 		options.bind = *conf.BindAddress
@@ -135,12 +165,30 @@ func main() {
 		os.Exit(1)
 	}
 
-	u := uhppote.NewUHPPOTE(options.bind, options.broadcast, options.listen, options.timeout, controllers, options.debug)
+	var u uhppote.IUHPPOTE = uhppote.NewUHPPOTE(options.bind, options.broadcast, options.listen, options.timeout, controllers, options.debug)
+	if options.simulate != "" {
+		if u, err = commands.NewSimulator(options.simulate, controllers); err != nil {
+			fmt.Fprintf(os.Stderr, "\n   ERROR: %v\n\n", err)
+			os.Exit(1)
+		}
+	}
 
 	// execute command
-	ctx := commands.NewContext(u, conf, options.debug)
-	err = cmd.Execute(ctx)
+	settings, err := commands.LoadSettings(file)
 	if err != nil {
+		fmt.Fprintf(os.Stderr, "\n   WARN:  %v\n", err)
+	}
+
+	if options.simulate != "" {
+		settings = settings.Simulated(options.simulate)
+	}
+
+	ctx := commands.NewContext(u, conf, options.debug).WithSettings(settings)
+	err = cmd.Execute(ctx)
+	if errors.Is(err, commands.ErrDrift) {
+		fmt.Fprintf(os.Stderr, "\n   WARN:  %v\n\n", err)
+		os.Exit(2)
+	} else if err != nil {
 		fmt.Fprintf(os.Stderr, "\n   ERROR: %v\n\n", err)
 		os.Exit(1)
 	}
@@ -169,14 +217,20 @@ func validate(bind types.BindAddr, broadcast types.BroadcastAddr, listen types.L
 // default configuration file is being used. A valid configuration file is mandatory for ACL
 // commands - a note is posted in debug mode if the default configuration file is in use,
 // but this is probably the desired behaviour.
-func configuration(cmd commands.Command) *config.Config {
+//
+// Returns the configuration and the path to the configuration file (or "" if the internal default
+// configuration is in use).
+func configuration(cmd commands.Command) (*config.Config, string) {
 	conf := config.NewConfig()
+	file := ""
 
 	if options.config != "" {
 		if err := conf.Load(options.config); err != nil {
 			fmt.Fprintf(os.Stderr, "\n   ERROR: %v\n\n", err)
 			os.Exit(1)
 		}
+
+		file = options.config
 	} else {
 		info, err := os.Stat(config.DefaultConfig)
 		if err != nil {
@@ -196,8 +250,12 @@ func configuration(cmd commands.Command) *config.Config {
 				} else {
 					fmt.Fprintf(os.Stderr, "\n   WARN:  %v\n", err)
 				}
-			} else if options.debug || cmd.RequiresConfig() {
-				fmt.Fprintf(os.Stderr, "\n ... using default configuration from %v\n", config.DefaultConfig)
+			} else {
+				file = config.DefaultConfig
+
+				if options.debug || cmd.RequiresConfig() {
+					fmt.Fprintf(os.Stderr, "\n ... using default configuration from %v\n", config.DefaultConfig)
+				}
 			}
 		}
 	}
@@ -207,7 +265,7 @@ func configuration(cmd commands.Command) *config.Config {
 		os.Exit(1)
 	}
 
-	return conf
+	return conf, file
 }
 
 func parse() (commands.Command, error) {
@@ -271,6 +329,8 @@ func usage() {
 	fmt.Println("    --bind      Sets the local IP address and port to use")
 	fmt.Println("    --broadcast Sets the IP address and port to use for UDP broadcast")
 	fmt.Println("    --listen    Sets the local IP address and port to use for receiving device events")
+	fmt.Println("    --simulate  Uses simulated controllers with the state stored in the file (e.g. for testing). The local")
+	fmt.Println("                state store is the <file>.state directory")
 	fmt.Println("    --debug     Displays internal information for diagnosing errors")
 	fmt.Println()
 }
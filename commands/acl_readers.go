@@ -0,0 +1,437 @@
+package commands
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// aclReader converts an ACL in some source format to the TSV format expected by
+// acl.ParseTSV.
+type aclReader interface {
+	read(r io.Reader) ([]byte, error)
+}
+
+type tsvReader struct {
+}
+
+type csvReader struct {
+	delimiter rune
+}
+
+type jsonReader struct {
+}
+
+// aclSource describes where and how to read an ACL:
+//   - file is a file path, '-' for stdin or an http:// or https:// URL
+//   - format is one of tsv, csv or json (or "" to autodetect the format)
+//   - delimiter is the CSV field delimiter
+type aclSource struct {
+	file      string
+	format    string
+	delimiter rune
+}
+
+var aclFormats = map[string]string{
+	".tsv":                      "tsv",
+	".acl":                      "tsv",
+	".csv":                      "csv",
+	".json":                     "json",
+	"text/tab-separated-values": "tsv",
+	"text/csv":                  "csv",
+	"application/json":          "json",
+}
+
+// Reads the ACL from a file, stdin or URL and returns it as TSV.
+func (s aclSource) read() ([]byte, error) {
+	var b []byte
+	var contentType string
+	var err error
+
+	switch {
+	case s.file == "-":
+		b, err = io.ReadAll(os.Stdin)
+
+	case s.isURL():
+		b, contentType, err = s.fetch()
+
+	default:
+		b, err = os.ReadFile(s.file)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if reader, err := s.reader(contentType, b); err != nil {
+		return nil, err
+	} else {
+		return reader.read(bytes.NewReader(b))
+	}
+}
+
+// Validates the ACL source, returning an error if the ACL source is a file that does
+// not exist or is not a regular file.
+func (s aclSource) validate() error {
+	if s.file == "-" || s.isURL() {
+		return nil
+	}
+
+	stat, err := os.Stat(s.file)
+	if err != nil && os.IsNotExist(err) {
+		return fmt.Errorf("file '%s' does not exist", s.file)
+	} else if err != nil {
+		return err
+	} else if stat.Mode().IsDir() {
+		return fmt.Errorf("file '%s' is a directory", s.file)
+	} else if !stat.Mode().IsRegular() {
+		return fmt.Errorf("file '%s' is not a real file", s.file)
+	}
+
+	return nil
+}
+
+func (s aclSource) isURL() bool {
+	return strings.HasPrefix(s.file, "http://") || strings.HasPrefix(s.file, "https://")
+}
+
+// Selects the reader for the ACL format. The format is determined by (in order of
+// precedence):
+//   - the --acl-format command line option
+//   - the file (or URL path) extension
+//   - the HTTP Content-Type
+//   - the content itself
+func (s aclSource) reader(contentType string, b []byte) (aclReader, error) {
+	format := s.format
+
+	if format == "" {
+		ext := filepath.Ext(s.file)
+		if s.isURL() {
+			if u, err := url.Parse(s.file); err == nil {
+				ext = filepath.Ext(u.Path)
+				if f := u.Query().Get("format"); f != "" {
+					ext = "." + f
+				}
+			}
+		}
+
+		format = aclFormats[strings.ToLower(ext)]
+	}
+
+	if format == "" && contentType != "" {
+		if mediatype, _, err := mime.ParseMediaType(contentType); err == nil {
+			format = aclFormats[mediatype]
+		}
+	}
+
+	if format == "" {
+		header, _, _ := bytes.Cut(bytes.TrimSpace(b), []byte("\n"))
+
+		switch {
+		case bytes.HasPrefix(header, []byte("[")):
+			format = "json"
+		case bytes.ContainsRune(header, '\t'):
+			format = "tsv"
+		case bytes.ContainsRune(header, s.delimiter):
+			format = "csv"
+		default:
+			format = "tsv"
+		}
+	}
+
+	switch format {
+	case "tsv":
+		return tsvReader{}, nil
+
+	case "csv":
+		return csvReader{delimiter: s.delimiter}, nil
+
+	case "json":
+		return jsonReader{}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported ACL format '%v'", format)
+	}
+}
+
+// Retrieves the ACL from an http:// or https:// URL. The response is cached along with
+// the ETag (if any) and the cached response is used if the server responds with
+// 304 Not Modified.
+func (s aclSource) fetch() ([]byte, string, error) {
+	type cached struct {
+		ETag        string `json:"etag"`
+		ContentType string `json:"content-type"`
+		Body        []byte `json:"body"`
+	}
+
+	var entry cached
+	var cachefile string
+
+	if dir, err := os.UserCacheDir(); err == nil {
+		hash := sha256.Sum256([]byte(s.file))
+		cachefile = filepath.Join(dir, "uhppote-cli", "acl", fmt.Sprintf("%x.json", hash))
+
+		if b, err := os.ReadFile(cachefile); err == nil {
+			json.Unmarshal(b, &entry)
+		}
+	}
+
+	rq, err := http.NewRequest(http.MethodGet, s.file, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if entry.ETag != "" {
+		rq.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	client := http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	response, err := client.Do(rq)
+	if err != nil {
+		return nil, "", err
+	}
+
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode == http.StatusNotModified && entry.Body != nil:
+		return entry.Body, entry.ContentType, nil
+
+	case response.StatusCode != http.StatusOK:
+		return nil, "", fmt.Errorf("error retrieving ACL from %v (%v)", s.file, response.Status)
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	entry = cached{
+		ETag:        response.Header.Get("ETag"),
+		ContentType: response.Header.Get("Content-Type"),
+		Body:        body,
+	}
+
+	if cachefile != "" && entry.ETag != "" {
+		if b, err := json.Marshal(entry); err == nil {
+			if err := os.MkdirAll(filepath.Dir(cachefile), 0770); err == nil {
+				os.WriteFile(cachefile, b, 0660)
+			}
+		}
+	}
+
+	return entry.Body, entry.ContentType, nil
+}
+
+func (r tsvReader) read(f io.Reader) ([]byte, error) {
+	return io.ReadAll(f)
+}
+
+// Converts CSV to TSV. Fields containing tabs, line breaks or double quotes are rejected since
+// they cannot be represented in the TSV format.
+func (r csvReader) read(f io.Reader) ([]byte, error) {
+	reader := csv.NewReader(f)
+	reader.Comma = r.delimiter
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	for i, record := range records {
+		for _, field := range record {
+			if err := validateTSVField(field); err != nil {
+				return nil, fmt.Errorf("record %v: invalid field %q (%v)", i+1, field, err)
+			}
+		}
+
+		fmt.Fprintf(&b, "%v\n", strings.Join(record, "\t"))
+	}
+
+	return b.Bytes(), nil
+}
+
+// Converts a JSON array of objects to TSV. The TSV columns are the union of the object
+// keys in the order in which they first appear e.g.:
+//
+//	[
+//	  { "Card Number": 10058400, "From": "2026-01-01", "To": "2026-12-31", "Great Hall": "Y", "Kitchen": 29 },
+//	  ...
+//	]
+//
+// Boolean values are converted to Y/N and null values to blanks. Keys and values containing tabs,
+// line breaks or double quotes are rejected (as for CSV).
+func (r jsonReader) read(f io.Reader) ([]byte, error) {
+	decoder := json.NewDecoder(f)
+	decoder.UseNumber()
+
+	expect := func(delim json.Delim) error {
+		if token, err := decoder.Token(); err != nil {
+			return err
+		} else if token != delim {
+			return fmt.Errorf("invalid JSON ACL - expected '%v', got '%v'", delim, token)
+		}
+
+		return nil
+	}
+
+	columns := []string{}
+	records := []map[string]string{}
+
+	if err := expect('['); err != nil {
+		return nil, err
+	}
+
+	for decoder.More() {
+		record := map[string]string{}
+
+		if err := expect('{'); err != nil {
+			return nil, err
+		}
+
+		for decoder.More() {
+			var key string
+			var value any
+
+			if token, err := decoder.Token(); err != nil {
+				return nil, err
+			} else {
+				key = fmt.Sprintf("%v", token)
+			}
+
+			if err := validateTSVField(key); err != nil {
+				return nil, fmt.Errorf("record %v: invalid key %q (%v)", len(records)+1, key, err)
+			}
+
+			if err := decoder.Decode(&value); err != nil {
+				return nil, err
+			}
+
+			if !slices.Contains(columns, key) {
+				columns = append(columns, key)
+			}
+
+			switch v := value.(type) {
+			case nil:
+				record[key] = ""
+
+			case bool:
+				record[key] = map[bool]string{true: "Y", false: "N"}[v]
+
+			case json.Number, string:
+				record[key] = fmt.Sprintf("%v", v)
+
+			default:
+				return nil, fmt.Errorf("invalid JSON ACL - unsupported value for '%v' (%v)", key, v)
+			}
+
+			if err := validateTSVField(record[key]); err != nil {
+				return nil, fmt.Errorf("record %v: invalid field %q (%v)", len(records)+1, record[key], err)
+			}
+		}
+
+		if err := expect('}'); err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+
+	if err := expect(']'); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "%v\n", strings.Join(columns, "\t"))
+	for _, record := range records {
+		row := []string{}
+		for _, column := range columns {
+			row = append(row, record[column])
+		}
+
+		fmt.Fprintf(&b, "%v\n", strings.Join(row, "\t"))
+	}
+
+	return b.Bytes(), nil
+}
+
+// Returns an error if a field cannot be represented in the TSV format i.e. it contains a tab
+// (splits the field), a line break (splits the row) or a double quote (breaks the TSV quoting).
+func validateTSVField(field string) error {
+	switch {
+	case strings.ContainsRune(field, '\t'):
+		return fmt.Errorf("contains a tab")
+
+	case strings.ContainsAny(field, "\r\n"):
+		return fmt.Errorf("contains a line break")
+
+	case strings.ContainsRune(field, '"'):
+		return fmt.Errorf("contains a double quote")
+
+	default:
+		return nil
+	}
+}
+
+func parseDelimiter(s string) (rune, error) {
+	switch {
+	case s == `\t` || strings.EqualFold(s, "tab"):
+		return '\t', nil
+
+	case utf8.RuneCountInString(s) == 1 && !regexp.MustCompile(`[\r\n"]`).MatchString(s):
+		r, _ := utf8.DecodeRuneInString(s)
+		return r, nil
+
+	default:
+		return 0, fmt.Errorf("invalid CSV delimiter '%v'", s)
+	}
+}
+
+func parseACLFormat(s string) (string, error) {
+	switch clean(s) {
+	case "":
+		return "", nil
+	case "tsv":
+		return "tsv", nil
+	case "csv":
+		return "csv", nil
+	case "json":
+		return "json", nil
+	default:
+		return "", fmt.Errorf("invalid ACL format '%v' (expected tsv, csv or json)", s)
+	}
+}
+
+// Displays the 'ACL sources' section of the help for the commands that read an ACL.
+func printACLSourcesHelp() {
+	fmt.Println("  ACL sources:")
+	fmt.Println()
+	fmt.Println("    The access control list may also be a CSV or JSON file, '-' to read it from stdin, or an http:// or https://")
+	fmt.Println("    URL (e.g. a Google Sheets TSV export). Unless specified with --acl-format, the format is determined from the file")
+	fmt.Println("    extension, the HTTP Content-Type or the content. A JSON access control list is an array of objects with the TSV")
+	fmt.Println("    column names as keys. URLs are cached and only re-downloaded if the server ETag has changed.")
+	fmt.Println()
+	fmt.Println("    --acl-format  ACL format (tsv, csv or json). Defaults to autodetect")
+	fmt.Println("    --delimiter   CSV field delimiter. Defaults to ','")
+	fmt.Println()
+}
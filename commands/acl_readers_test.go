@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCSVReader(t *testing.T) {
+	csv := "Card Number;From;To;Great Hall;Kitchen\n" +
+		"10058400;2026-01-01;2026-12-31;Y;29\n" +
+		"10058401;2026-01-01;2026-12-31;N;\"Y\"\n"
+
+	expected := "Card Number\tFrom\tTo\tGreat Hall\tKitchen\n" +
+		"10058400\t2026-01-01\t2026-12-31\tY\t29\n" +
+		"10058401\t2026-01-01\t2026-12-31\tN\tY\n"
+
+	tsv, err := csvReader{delimiter: ';'}.read(bytes.NewReader([]byte(csv)))
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	} else if string(tsv) != expected {
+		t.Errorf("Incorrect TSV\n   expected:\n%v\n   got:\n%v", expected, string(tsv))
+	}
+}
+
+func TestJSONReader(t *testing.T) {
+	json := `[
+	  { "Card Number": 10058400, "From": "2026-01-01", "To": "2026-12-31", "Great Hall": "Y", "Kitchen": 29 },
+	  { "Card Number": 10058401, "From": "2026-01-01", "To": "2026-12-31", "Kitchen": true, "Great Hall": false, "PIN": null }
+	]`
+
+	expected := "Card Number\tFrom\tTo\tGreat Hall\tKitchen\tPIN\n" +
+		"10058400\t2026-01-01\t2026-12-31\tY\t29\t\n" +
+		"10058401\t2026-01-01\t2026-12-31\tN\tY\t\n"
+
+	tsv, err := jsonReader{}.read(bytes.NewReader([]byte(json)))
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	} else if string(tsv) != expected {
+		t.Errorf("Incorrect TSV\n   expected:\n%v\n   got:\n%v", expected, string(tsv))
+	}
+}
+
+func TestACLReadersInvalidFields(t *testing.T) {
+	csv := map[string]string{
+		"tab":     "Card Number,Name\n10058400,\"Eduardo\tRamos\"\n",
+		"newline": "Card Number,Name\n10058400,\"Eduardo\nRamos\"\n",
+		"return":  "Card Number,Name\n10058400,\"Eduardo\rRamos\"\n",
+		"quote":   "Card Number,Name\n10058400,\"Eduardo \"\"Ed\"\" Ramos\"\n",
+		"header":  "Card Number,\"Great\nHall\"\n10058400,Y\n",
+	}
+
+	for test, v := range csv {
+		if _, err := (csvReader{delimiter: ','}).read(bytes.NewReader([]byte(v))); err == nil {
+			t.Errorf("CSV %v: expected error", test)
+		}
+	}
+
+	json := map[string]string{
+		"tab":     `[ { "Card Number": 10058400, "Name": "Eduardo\tRamos" } ]`,
+		"newline": `[ { "Card Number": 10058400, "Name": "Eduardo\nRamos" } ]`,
+		"return":  `[ { "Card Number": 10058400, "Name": "Eduardo\rRamos" } ]`,
+		"quote":   `[ { "Card Number": 10058400, "Name": "Eduardo \"Ed\" Ramos" } ]`,
+		"key":     `[ { "Card Number": 10058400, "Great\nHall": "Y" } ]`,
+	}
+
+	for test, v := range json {
+		if _, err := (jsonReader{}).read(bytes.NewReader([]byte(v))); err == nil {
+			t.Errorf("JSON %v: expected error", test)
+		}
+	}
+
+	// ... same error for the same field
+	_, errCSV := (csvReader{delimiter: ','}).read(bytes.NewReader([]byte(csv["tab"])))
+	_, errJSON := (jsonReader{}).read(bytes.NewReader([]byte(json["tab"])))
+
+	if errCSV == nil || errJSON == nil || !strings.HasSuffix(errCSV.Error(), `"Eduardo\tRamos" (contains a tab)`) || !strings.HasSuffix(errJSON.Error(), `"Eduardo\tRamos" (contains a tab)`) {
+		t.Errorf("inconsistent CSV and JSON errors\n   CSV: %v\n   JSON:%v", errCSV, errJSON)
+	}
+}
+
+func TestACLSourceWithETag(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	acl := "Card Number\tFrom\tTo\tGreat Hall\n10058400\t2026-01-01\t2026-12-31\tY\n"
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "text/tab-separated-values")
+		w.Write([]byte(acl))
+	}))
+
+	defer server.Close()
+
+	source := aclSource{file: server.URL + "/acl", delimiter: ','}
+
+	for range 2 {
+		if tsv, err := source.read(); err != nil {
+			t.Fatalf("Unexpected error (%v)", err)
+		} else if string(tsv) != acl {
+			t.Errorf("Incorrect TSV\n   expected:\n%v\n   got:\n%v", acl, string(tsv))
+		}
+	}
+
+	if requests != 2 {
+		t.Errorf("Incorrect number of requests - expected:%v, got:%v", 2, requests)
+	}
+}
@@ -49,6 +49,8 @@ func (c *AddTask) Execute(ctx Context) error {
 		return fmt.Errorf("%v: failed to add task", serialNumber)
 	}
 
+	recordTasks(ctx, serialNumber, []types.Task{*task})
+
 	fmt.Printf("%v: task added\n", serialNumber)
 
 	return nil
@@ -189,6 +191,15 @@ func (c *AddTask) getTaskType(args []string) (types.TaskType, error) {
 	}
 
 	// ... text task type
+	if t, ok := lookupTaskType(arg); ok {
+		return t, nil
+	}
+
+	return 0, fmt.Errorf("invalid task identifier '%v'", arg)
+}
+
+// Finds the task type for a (case-, space- and punctuation-insensitive) task description.
+func lookupTaskType(arg string) (types.TaskType, bool) {
 	re := regexp.MustCompile("[^a-z]+")
 	clean := func(s string) string { return re.ReplaceAllString(strings.ToLower(s), "") }
 	taskID := clean(arg)
@@ -209,11 +220,11 @@ func (c *AddTask) getTaskType(args []string) (types.TaskType, error) {
 		types.EnablePushButton,
 	} {
 		if taskID == clean(fmt.Sprintf("%v", t)) {
-			return t, nil
+			return t, true
 		}
 	}
 
-	return 0, fmt.Errorf("invalid task identifier '%v'", arg)
+	return 0, false
 }
 
 func (c *AddTask) getTaskDoor(args []string) (uint8, error) {
@@ -0,0 +1,164 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// alarmSink delivers alarm events to a consumer.
+type alarmSink interface {
+	send(a alarm) error
+}
+
+// jsonSink writes alarm events as JSON, one alarm per line.
+type jsonSink struct {
+	w io.Writer
+}
+
+// webhookSink POSTs alarm events as JSON to a URL.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// execSink runs a command for each alarm event. The alarm is passed to the command as JSON on
+// stdin and as the ALARM, ALARM_STATE, ALARM_CONTROLLER, ALARM_DOOR and ALARM_DETAILS
+// environment variables. The output of the command is written to stderr so that it is not
+// interleaved with the JSON alarms on stdout.
+type execSink struct {
+	command string
+}
+
+func (s jsonSink) send(a alarm) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(s.w, "%s\n", b)
+
+	return err
+}
+
+func newWebhookSink(url string) webhookSink {
+	return webhookSink{
+		url: url,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+func (s webhookSink) send(a alarm) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+
+	response, err := s.client.Post(s.url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("webhook %v returned %v", s.url, response.Status)
+	}
+
+	return nil
+}
+
+func (s execSink) send(a alarm) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", s.command)
+	} else {
+		cmd = exec.Command("sh", "-c", s.command)
+	}
+
+	cmd.Stdin = bytes.NewReader(b)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("ALARM=%v", a.Alarm),
+		fmt.Sprintf("ALARM_STATE=%v", a.State),
+		fmt.Sprintf("ALARM_CONTROLLER=%v", a.Controller),
+		fmt.Sprintf("ALARM_DOOR=%v", a.Door),
+		fmt.Sprintf("ALARM_DETAILS=%v", a.Details))
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("exec hook '%v' failed (%v)", s.command, err)
+	}
+
+	return nil
+}
+
+// alarmDispatcher delivers alarms to the sinks asynchronously, so that a slow webhook, exec hook
+// or SMTP server does not block the listen loop (or the other sinks). Each sink has its own
+// buffered queue and goroutine, so alarms are delivered to a sink in the order they were raised.
+type alarmDispatcher struct {
+	queues []chan alarm
+	wg     sync.WaitGroup
+}
+
+// Maximum number of alarms queued for a sink before new alarms are discarded.
+const alarmQueueSize = 64
+
+func newAlarmDispatcher(sinks []alarmSink) *alarmDispatcher {
+	d := alarmDispatcher{}
+
+	for _, sink := range sinks {
+		q := make(chan alarm, alarmQueueSize)
+
+		d.queues = append(d.queues, q)
+		d.wg.Add(1)
+
+		go func() {
+			defer d.wg.Done()
+
+			for a := range q {
+				if err := sink.send(a); err != nil {
+					fmt.Fprintf(os.Stderr, "   WARN  error delivering alarm '%v' (%v)\n", a, err)
+				}
+			}
+		}()
+	}
+
+	return &d
+}
+
+// Queues an alarm for delivery to all the sinks, warning if a sink queue is full.
+func (d *alarmDispatcher) dispatch(a alarm) {
+	for _, q := range d.queues {
+		select {
+		case q <- a:
+		default:
+			fmt.Fprintf(os.Stderr, "   WARN  alarm queue full - discarded alarm '%v'\n", a)
+		}
+	}
+}
+
+// Delivers the queued alarms and stops the sink goroutines.
+func (d *alarmDispatcher) close() {
+	for _, q := range d.queues {
+		close(q)
+	}
+
+	d.wg.Wait()
+}
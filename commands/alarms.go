@@ -0,0 +1,312 @@
+package commands
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+// Alarm types.
+const (
+	alarmForcedOpen   = "forced-open"
+	alarmHeldOpen     = "held-open"
+	alarmDeniedSwipes = "denied-swipes"
+	alarmTamper       = "tamper"
+)
+
+// Alarm states.
+const (
+	alarmRaised  = "raised"
+	alarmCleared = "cleared"
+)
+
+// A door opened more than unlockWindow after the last granted swipe, pushbutton or remote open
+// is considered to have been forced open.
+const unlockWindow = 15 * time.Second
+
+// A raised tamper alarm is cleared once the controller has reported no further 'theft prevention'
+// events for tamperQuietPeriod.
+const tamperQuietPeriod = 5 * time.Minute
+
+// alarm is a structured alarm event, as delivered to the alarm sinks.
+type alarm struct {
+	Alarm      string    `json:"alarm"`
+	State      string    `json:"state"`
+	Controller uint32    `json:"controller"`
+	Door       uint8     `json:"door,omitempty"`
+	DoorName   string    `json:"door-name,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+	Details    string    `json:"details,omitempty"`
+	Cards      []uint32  `json:"cards,omitempty"`
+}
+
+func (a alarm) String() string {
+	door := ""
+	if a.DoorName != "" {
+		door = fmt.Sprintf(" door %v (%v)", a.Door, a.DoorName)
+	} else if a.Door != 0 {
+		door = fmt.Sprintf(" door %v", a.Door)
+	}
+
+	return fmt.Sprintf("%v %v %v%v", a.Alarm, a.State, a.Controller, door)
+}
+
+// alarmSettings holds the alarm thresholds and sinks defined by the cli.alarms.xxx settings.
+type alarmSettings struct {
+	heldOpen     time.Duration
+	denied       int
+	deniedWindow time.Duration
+	webhook      string
+	exec         string
+}
+
+func getAlarmSettings(ctx Context) (alarmSettings, error) {
+	settings := alarmSettings{
+		heldOpen:     60 * time.Second,
+		denied:       3,
+		deniedWindow: 60 * time.Second,
+		webhook:      ctx.settings.get("alarms.webhook", ""),
+		exec:         ctx.settings.get("alarms.exec", ""),
+	}
+
+	if v := ctx.settings.get("alarms.held-open", ""); v != "" {
+		if d, err := time.ParseDuration(v); err != nil {
+			return settings, fmt.Errorf("invalid cli.alarms.held-open (%v)", v)
+		} else {
+			settings.heldOpen = d
+		}
+	}
+
+	if v := ctx.settings.get("alarms.denied", ""); v != "" {
+		if N, err := strconv.Atoi(v); err != nil || N < 1 {
+			return settings, fmt.Errorf("invalid cli.alarms.denied (%v)", v)
+		} else {
+			settings.denied = N
+		}
+	}
+
+	if v := ctx.settings.get("alarms.denied-window", ""); v != "" {
+		if d, err := time.ParseDuration(v); err != nil {
+			return settings, fmt.Errorf("invalid cli.alarms.denied-window (%v)", v)
+		} else {
+			settings.deniedWindow = d
+		}
+	}
+
+	return settings, nil
+}
+
+// alarmEngine interprets the sequence of status events received by 'listen' and raises (and
+// clears) alarms for:
+//   - a door opened without a preceding granted swipe, pushbutton or remote open (forced open)
+//   - a door that is open for longer than the held open threshold (held open)
+//   - repeated denied swipes at a door within a window (denied swipes)
+//   - the controller 'theft prevention' alarm (tamper), cleared after a quiet period
+//
+// Time is passed in explicitly (rather than using the controller event timestamps) so that
+// the engine is not affected by controller clock drift.
+type alarmEngine struct {
+	heldOpen     time.Duration
+	denied       int
+	deniedWindow time.Duration
+	names        map[doorID]string
+	doors        map[doorID]*doorAlarms
+	events       map[uint32]uint32
+}
+
+// doorAlarms is the alarm state for a door.
+type doorAlarms struct {
+	known      bool
+	open       bool
+	openedAt   time.Time
+	unlockedAt time.Time
+	forced     bool
+	held       bool
+	denied     []deniedSwipe
+	rejected   bool
+	tamper     bool
+	tamperedAt time.Time
+}
+
+type deniedSwipe struct {
+	card uint32
+	at   time.Time
+}
+
+func newAlarmEngine(heldOpen time.Duration, denied int, deniedWindow time.Duration, names map[doorID]string) *alarmEngine {
+	return &alarmEngine{
+		heldOpen:     heldOpen,
+		denied:       denied,
+		deniedWindow: deniedWindow,
+		names:        names,
+		doors:        map[doorID]*doorAlarms{},
+		events:       map[uint32]uint32{},
+	}
+}
+
+// Updates the alarm state from a status event and returns the alarms raised or cleared.
+func (e *alarmEngine) onStatus(status types.Status, now time.Time) []alarm {
+	alarms := []alarm{}
+	controller := uint32(status.SerialNumber)
+
+	// ... event (ignoring repeated status messages for the same event)
+	if event := status.Event; event.Index != 0 && e.events[controller] != event.Index {
+		e.events[controller] = event.Index
+
+		if event.Door >= 1 && event.Door <= 4 {
+			id := doorID{controller, event.Door}
+			door := e.door(id)
+
+			switch {
+			case event.Type == eventSwipe && event.Granted:
+				door.unlockedAt = now
+
+			case event.Type == eventSwipe && !event.Granted:
+				door.denied = append(door.denied, deniedSwipe{event.CardNumber, now})
+				door.denied = e.prune(door.denied, now)
+
+				if len(door.denied) >= e.denied && !door.rejected {
+					door.rejected = true
+					alarms = append(alarms, e.alarm(alarmDeniedSwipes, alarmRaised, id, now, fmt.Sprintf("%v denied swipes in %v", len(door.denied), e.deniedWindow), deniedCards(door.denied)))
+				}
+
+			case slices.Contains([]uint8{reasonPushButton, reasonSupervisorPassword, reasonFirstCardOpen, reasonRemoteOpen, reasonRemoteOpenUSB, reasonFirstCardOpenPassword}, event.Reason):
+				door.unlockedAt = now
+
+			case event.Reason == reasonForcedOpen && !door.forced:
+				door.forced = true
+				alarms = append(alarms, e.alarm(alarmForcedOpen, alarmRaised, id, now, "reported by controller", nil))
+
+			case event.Reason == reasonTheftPrevention:
+				door.tamperedAt = now
+
+				if !door.tamper {
+					door.tamper = true
+					alarms = append(alarms, e.alarm(alarmTamper, alarmRaised, id, now, eventReason(event.Reason), nil))
+				}
+			}
+		}
+	}
+
+	// ... door buttons and door sensors
+	for _, d := range []uint8{1, 2, 3, 4} {
+		id := doorID{controller, d}
+
+		if status.DoorButton[d] {
+			e.door(id).unlockedAt = now
+		}
+
+		open, ok := status.DoorState[d]
+		if !ok {
+			continue
+		}
+
+		door := e.door(id)
+		switch {
+		case !door.known:
+			door.known = true
+			door.open = open
+			door.openedAt = now
+
+		case open && !door.open:
+			door.open = true
+			door.openedAt = now
+
+			if !door.forced && (door.unlockedAt.IsZero() || now.Sub(door.unlockedAt) > unlockWindow) {
+				door.forced = true
+				alarms = append(alarms, e.alarm(alarmForcedOpen, alarmRaised, id, now, "opened without a granted swipe, pushbutton or remote open", nil))
+			}
+
+		case !open && door.open:
+			door.open = false
+
+			if door.forced {
+				door.forced = false
+				alarms = append(alarms, e.alarm(alarmForcedOpen, alarmCleared, id, now, "door closed", nil))
+			}
+
+			if door.held {
+				door.held = false
+				alarms = append(alarms, e.alarm(alarmHeldOpen, alarmCleared, id, now, fmt.Sprintf("door closed after %v", now.Sub(door.openedAt).Round(time.Second)), nil))
+			}
+		}
+	}
+
+	return alarms
+}
+
+// Checks the held open, denied swipes and tamper alarms and returns the alarms raised or cleared.
+func (e *alarmEngine) tick(now time.Time) []alarm {
+	alarms := []alarm{}
+
+	ids := slices.SortedFunc(maps.Keys(e.doors), func(p, q doorID) int {
+		return cmp.Or(cmp.Compare(p.controller, q.controller), cmp.Compare(p.door, q.door))
+	})
+
+	for _, id := range ids {
+		door := e.doors[id]
+
+		if door.open && !door.held && e.heldOpen > 0 && now.Sub(door.openedAt) >= e.heldOpen {
+			door.held = true
+			alarms = append(alarms, e.alarm(alarmHeldOpen, alarmRaised, id, now, fmt.Sprintf("open for more than %v", e.heldOpen), nil))
+		}
+
+		door.denied = e.prune(door.denied, now)
+		if door.rejected && len(door.denied) < e.denied {
+			door.rejected = false
+			alarms = append(alarms, e.alarm(alarmDeniedSwipes, alarmCleared, id, now, fmt.Sprintf("fewer than %v denied swipes in %v", e.denied, e.deniedWindow), nil))
+		}
+
+		if door.tamper && now.Sub(door.tamperedAt) >= tamperQuietPeriod {
+			door.tamper = false
+			alarms = append(alarms, e.alarm(alarmTamper, alarmCleared, id, now, fmt.Sprintf("no theft prevention events for %v", tamperQuietPeriod), nil))
+		}
+	}
+
+	return alarms
+}
+
+func (e *alarmEngine) door(id doorID) *doorAlarms {
+	if _, ok := e.doors[id]; !ok {
+		e.doors[id] = &doorAlarms{}
+	}
+
+	return e.doors[id]
+}
+
+// Discards the denied swipes that are outside the window.
+func (e *alarmEngine) prune(denied []deniedSwipe, now time.Time) []deniedSwipe {
+	return slices.DeleteFunc(denied, func(d deniedSwipe) bool {
+		return now.Sub(d.at) > e.deniedWindow
+	})
+}
+
+func (e *alarmEngine) alarm(kind, state string, id doorID, now time.Time, details string, cards []uint32) alarm {
+	return alarm{
+		Alarm:      kind,
+		State:      state,
+		Controller: id.controller,
+		Door:       id.door,
+		DoorName:   e.names[id],
+		Timestamp:  now,
+		Details:    details,
+		Cards:      cards,
+	}
+}
+
+// Returns the distinct card numbers of a list of denied swipes.
+func deniedCards(denied []deniedSwipe) []uint32 {
+	list := []uint32{}
+	for _, d := range denied {
+		if !slices.Contains(list, d.card) {
+			list = append(list, d.card)
+		}
+	}
+
+	return list
+}
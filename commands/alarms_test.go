@@ -0,0 +1,235 @@
+package commands
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+func doorStatus(index uint32, door uint8, open bool, event types.StatusEvent) types.Status {
+	event.Index = index
+	event.Door = door
+
+	return types.Status{
+		SerialNumber: 405419896,
+		DoorState:    map[uint8]bool{1: false, 2: false, 3: false, 4: false, door: open},
+		DoorButton:   map[uint8]bool{1: false, 2: false, 3: false, 4: false},
+		Event:        event,
+	}
+}
+
+func summarize(alarms []alarm) []string {
+	list := []string{}
+	for _, a := range alarms {
+		list = append(list, a.String())
+	}
+
+	return list
+}
+
+func TestAlarmsForcedOpen(t *testing.T) {
+	engine := newAlarmEngine(60*time.Second, 3, 60*time.Second, map[doorID]string{{405419896, 1}: "Great Hall"})
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	door := types.StatusEvent{Type: eventDoor, Reason: reasonDoorOpened}
+	granted := types.StatusEvent{Type: eventSwipe, Granted: true, CardNumber: 10058400, Reason: reasonSwipe}
+
+	alarms := []alarm{}
+	alarms = append(alarms, engine.onStatus(doorStatus(1, 1, false, door), now)...)
+	alarms = append(alarms, engine.onStatus(doorStatus(2, 1, true, door), now.Add(1*time.Second))...)
+	alarms = append(alarms, engine.onStatus(doorStatus(3, 1, false, door), now.Add(5*time.Second))...)
+	alarms = append(alarms, engine.onStatus(doorStatus(4, 1, false, granted), now.Add(10*time.Second))...)
+	alarms = append(alarms, engine.onStatus(doorStatus(5, 1, true, door), now.Add(12*time.Second))...)
+	alarms = append(alarms, engine.onStatus(doorStatus(6, 1, false, door), now.Add(15*time.Second))...)
+
+	expected := []string{
+		"forced-open raised 405419896 door 1 (Great Hall)",
+		"forced-open cleared 405419896 door 1 (Great Hall)",
+	}
+
+	if got := summarize(alarms); !reflect.DeepEqual(got, expected) {
+		t.Errorf("incorrect alarms\n   expected:%v\n   got:     %v", expected, got)
+	}
+}
+
+func TestAlarmsHeldOpen(t *testing.T) {
+	engine := newAlarmEngine(30*time.Second, 3, 60*time.Second, map[doorID]string{})
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	door := types.StatusEvent{Type: eventDoor, Reason: reasonDoorOpened}
+	button := types.StatusEvent{Type: eventDoor, Reason: reasonPushButton}
+
+	alarms := []alarm{}
+	alarms = append(alarms, engine.onStatus(doorStatus(1, 2, false, door), now)...)
+	alarms = append(alarms, engine.onStatus(doorStatus(2, 2, false, button), now.Add(1*time.Second))...)
+	alarms = append(alarms, engine.onStatus(doorStatus(3, 2, true, door), now.Add(2*time.Second))...)
+	alarms = append(alarms, engine.tick(now.Add(20*time.Second))...)
+	alarms = append(alarms, engine.tick(now.Add(40*time.Second))...)
+	alarms = append(alarms, engine.tick(now.Add(50*time.Second))...)
+	alarms = append(alarms, engine.onStatus(doorStatus(4, 2, false, door), now.Add(60*time.Second))...)
+
+	expected := []string{
+		"held-open raised 405419896 door 2",
+		"held-open cleared 405419896 door 2",
+	}
+
+	if got := summarize(alarms); !reflect.DeepEqual(got, expected) {
+		t.Errorf("incorrect alarms\n   expected:%v\n   got:     %v", expected, got)
+	}
+}
+
+func TestAlarmsDeniedSwipes(t *testing.T) {
+	engine := newAlarmEngine(60*time.Second, 3, 60*time.Second, map[doorID]string{})
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	denied := func(card uint32) types.StatusEvent {
+		return types.StatusEvent{Type: eventSwipe, Granted: false, CardNumber: card, Reason: reasonNoAccessRights}
+	}
+
+	alarms := []alarm{}
+	alarms = append(alarms, engine.onStatus(doorStatus(1, 3, false, denied(1)), now)...)
+	alarms = append(alarms, engine.onStatus(doorStatus(2, 3, false, denied(2)), now.Add(50*time.Second))...)
+	alarms = append(alarms, engine.onStatus(doorStatus(2, 3, false, denied(2)), now.Add(51*time.Second))...)
+	alarms = append(alarms, engine.onStatus(doorStatus(3, 3, false, denied(3)), now.Add(70*time.Second))...)
+	alarms = append(alarms, engine.onStatus(doorStatus(4, 3, false, denied(3)), now.Add(80*time.Second))...)
+	alarms = append(alarms, engine.tick(now.Add(115*time.Second))...)
+
+	expected := []string{
+		"denied-swipes raised 405419896 door 3",
+		"denied-swipes cleared 405419896 door 3",
+	}
+
+	if got := summarize(alarms); !reflect.DeepEqual(got, expected) {
+		t.Errorf("incorrect alarms\n   expected:%v\n   got:     %v", expected, got)
+	} else if cards := alarms[0].Cards; !reflect.DeepEqual(cards, []uint32{2, 3}) {
+		t.Errorf("incorrect denied cards - expected:%v, got:%v", []uint32{2, 3}, cards)
+	}
+}
+
+func TestAlarmsTamper(t *testing.T) {
+	engine := newAlarmEngine(60*time.Second, 3, 60*time.Second, map[doorID]string{})
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	tamper := types.StatusEvent{Type: eventAlarm, Reason: reasonTheftPrevention}
+
+	alarms := []alarm{}
+	alarms = append(alarms, engine.onStatus(doorStatus(1, 1, false, tamper), now)...)
+	alarms = append(alarms, engine.onStatus(doorStatus(2, 1, false, tamper), now.Add(1*time.Minute))...)
+	alarms = append(alarms, engine.tick(now.Add(4*time.Minute))...)
+	alarms = append(alarms, engine.onStatus(doorStatus(3, 1, false, tamper), now.Add(5*time.Minute))...)
+	alarms = append(alarms, engine.tick(now.Add(9*time.Minute))...)
+	alarms = append(alarms, engine.tick(now.Add(10*time.Minute))...)
+	alarms = append(alarms, engine.tick(now.Add(11*time.Minute))...)
+	alarms = append(alarms, engine.onStatus(doorStatus(4, 1, false, tamper), now.Add(12*time.Minute))...)
+
+	expected := []string{
+		"tamper raised 405419896 door 1",
+		"tamper cleared 405419896 door 1",
+		"tamper raised 405419896 door 1",
+	}
+
+	if got := summarize(alarms); !reflect.DeepEqual(got, expected) {
+		t.Errorf("incorrect alarms\n   expected:%v\n   got:     %v", expected, got)
+	}
+}
+
+func TestAlarmSinks(t *testing.T) {
+	a := alarm{
+		Alarm:      alarmHeldOpen,
+		State:      alarmRaised,
+		Controller: 405419896,
+		Door:       2,
+		Timestamp:  time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+		Details:    "open for more than 30s",
+	}
+
+	expected := `{"alarm":"held-open","state":"raised","controller":405419896,"door":2,"timestamp":"2026-10-19T12:00:00Z","details":"open for more than 30s"}`
+
+	// ... JSON
+	var b bytes.Buffer
+	if err := (jsonSink{&b}).send(a); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if b.String() != expected+"\n" {
+		t.Errorf("incorrect JSON\n   expected:%v\n   got:     %v", expected, b.String())
+	}
+
+	// ... webhook
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- string(body)
+	}))
+
+	defer server.Close()
+
+	if err := newWebhookSink(server.URL).send(a); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if body := <-received; body != expected {
+		t.Errorf("incorrect webhook body\n   expected:%v\n   got:     %v", expected, body)
+	}
+
+	// ... exec
+	if runtime.GOOS != "windows" {
+		file := filepath.Join(t.TempDir(), "alarm.json")
+		if err := (execSink{"cat > " + file + "; echo $ALARM $ALARM_DOOR >> " + file}).send(a); err != nil {
+			t.Fatalf("unexpected error (%v)", err)
+		}
+
+		if bytes, err := os.ReadFile(file); err != nil {
+			t.Fatalf("unexpected error (%v)", err)
+		} else if string(bytes) != expected+"held-open 2\n" {
+			t.Errorf("incorrect exec hook input\n   expected:%v\n   got:     %v", expected+"held-open 2\n", string(bytes))
+		}
+	}
+}
+
+type slowSink struct {
+	delay    time.Duration
+	received chan alarm
+}
+
+func (s slowSink) send(a alarm) error {
+	time.Sleep(s.delay)
+	s.received <- a
+
+	return nil
+}
+
+func TestAlarmDispatcher(t *testing.T) {
+	slow := slowSink{250 * time.Millisecond, make(chan alarm, 2)}
+	fast := slowSink{0, make(chan alarm, 2)}
+	dispatcher := newAlarmDispatcher([]alarmSink{slow, fast})
+
+	a := alarm{Alarm: alarmTamper, State: alarmRaised, Controller: 405419896, Door: 1}
+	b := alarm{Alarm: alarmTamper, State: alarmCleared, Controller: 405419896, Door: 1}
+
+	start := time.Now()
+	dispatcher.dispatch(a)
+	dispatcher.dispatch(b)
+
+	if dt := time.Since(start); dt > 100*time.Millisecond {
+		t.Errorf("dispatch blocked by slow sink (%v)", dt)
+	}
+
+	select {
+	case got := <-fast.received:
+		if got.String() != a.String() {
+			t.Errorf("incorrect alarm - expected:%v, got:%v", a, got)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("alarm delivery to fast sink blocked by slow sink")
+	}
+
+	dispatcher.close()
+
+	if len(slow.received) != 2 {
+		t.Fatalf("queued alarms not delivered on close - expected:%v, got:%v", 2, len(slow.received))
+	} else if got := <-slow.received; got.String() != a.String() {
+		t.Errorf("incorrect alarm order - expected:%v, got:%v", a, got)
+	}
+}
@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/uhppoted/uhppoted-lib/encoding/tsv"
+)
+
+// cardholder is an entry in the local cardholder registry.
+type cardholder struct {
+	CardNumber uint32 `json:"card-number"`
+	Name       string `json:"name"`
+	Department string `json:"department,omitempty"`
+	Notes      string `json:"notes,omitempty"`
+}
+
+// cardholders is the local cardholder registry, keyed by card number. The registry
+// is loaded from the TSV or JSON file defined by the 'cli.cardholders' setting in
+// the uhppoted.conf file.
+type cardholders map[uint32]cardholder
+
+// Loads the cardholder registry defined by the 'cli.cardholders' setting. Returns an empty
+// registry (with a warning) if the registry file cannot be loaded, since the registry is only
+// used to annotate the output.
+func getCardholders(ctx Context) cardholders {
+	file := ctx.settings.path("cardholders")
+	if file == "" {
+		return cardholders{}
+	}
+
+	if registry, err := loadCardholders(file); err != nil {
+		fmt.Fprintf(os.Stderr, "   WARN  error loading cardholder registry (%v)\n", err)
+		return cardholders{}
+	} else {
+		return registry
+	}
+}
+
+// Loads a cardholder registry from a TSV file with 'Card Number', 'Name', 'Department' and
+// 'Notes' columns or a JSON file with an array of cardholder objects.
+func loadCardholders(file string) (cardholders, error) {
+	bytes, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	recordset := []cardholder{}
+
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		if err := json.Unmarshal(bytes, &recordset); err != nil {
+			return nil, err
+		}
+	} else {
+		type tsvCardholder struct {
+			CardNumber uint32 `tsv:"Card Number"`
+			Name       string `tsv:"Name"`
+			Department string `tsv:"Department"`
+			Notes      string `tsv:"Notes"`
+		}
+
+		records := []tsvCardholder{}
+		if err := tsv.Unmarshal(bytes, &records); err != nil {
+			return nil, err
+		}
+
+		for _, record := range records {
+			recordset = append(recordset, cardholder(record))
+		}
+	}
+
+	registry := cardholders{}
+	for _, record := range recordset {
+		if record.CardNumber == 0 {
+			return nil, fmt.Errorf("%v: invalid card number (%v)", file, record.CardNumber)
+		} else if _, ok := registry[record.CardNumber]; ok {
+			return nil, fmt.Errorf("%v: duplicate card number (%v)", file, record.CardNumber)
+		}
+
+		registry[record.CardNumber] = record
+	}
+
+	return registry, nil
+}
+
+// Returns the cardholder name and department for a card number (or "" if the card is
+// not in the registry).
+func (r cardholders) annotate(card uint32) string {
+	if v, ok := r[card]; !ok || v.Name == "" {
+		return ""
+	} else if v.Department != "" {
+		return fmt.Sprintf("%v (%v)", v.Name, v.Department)
+	} else {
+		return v.Name
+	}
+}
+
+// Finds the card number for a cardholder name. Names are case- and space-insensitive and
+// an error is returned if the name is not unique.
+func (r cardholders) lookup(name string) (uint32, error) {
+	cards := []uint32{}
+	for k, v := range r {
+		if clean(v.Name) == clean(name) {
+			cards = append(cards, k)
+		}
+	}
+
+	slices.Sort(cards)
+
+	switch len(cards) {
+	case 0:
+		return 0, fmt.Errorf("no card registered for '%v'", name)
+
+	case 1:
+		return cards[0], nil
+
+	default:
+		return 0, fmt.Errorf("'%v' is not unique (cards %v)", name, cards)
+	}
+}
+
+// Returns the card number from the command line argument, which may be either a card number
+// or a cardholder name in the cardholder registry.
+func getCardNumber(ctx Context, index int, missing, invalid string) (uint32, error) {
+	if len(flag.Args()) < index+1 {
+		return 0, fmt.Errorf("%s", missing)
+	}
+
+	return resolveCardNumber(ctx, flag.Arg(index), invalid)
+}
+
+// Resolves a card number or cardholder name to a card number.
+func resolveCardNumber(ctx Context, arg string, invalid string) (uint32, error) {
+	if regexp.MustCompile("^[0-9]+$").MatchString(arg) {
+		if N, err := strconv.ParseUint(arg, 10, 32); err != nil {
+			return 0, fmt.Errorf(invalid, arg)
+		} else {
+			return uint32(N), nil
+		}
+	}
+
+	return getCardholders(ctx).lookup(arg)
+}
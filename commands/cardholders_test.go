@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+func TestLoadCardholdersJSON(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cardholders.json")
+	content := `[
+  { "card-number": 10058400, "name": "Eduardo Ramos", "department": "Facilities" },
+  { "card-number": 10058401, "name": "Nadia Okafor", "notes": "contractor" }
+]`
+
+	expected := cardholders{
+		10058400: cardholder{CardNumber: 10058400, Name: "Eduardo Ramos", Department: "Facilities"},
+		10058401: cardholder{CardNumber: 10058401, Name: "Nadia Okafor", Notes: "contractor"},
+	}
+
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatalf("error creating cardholders file (%v)", err)
+	}
+
+	registry, err := loadCardholders(file)
+	if err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	if !reflect.DeepEqual(registry, expected) {
+		t.Errorf("incorrect cardholder registry\n   expected:%v\n   got:     %v", expected, registry)
+	}
+}
+
+func TestCardholdersLookup(t *testing.T) {
+	registry := cardholders{
+		10058400: cardholder{CardNumber: 10058400, Name: "Eduardo Ramos"},
+		10058401: cardholder{CardNumber: 10058401, Name: "Nadia Okafor"},
+		10058402: cardholder{CardNumber: 10058402, Name: "Nadia  Okafor"},
+	}
+
+	if card, err := registry.lookup("eduardo ramos"); err != nil {
+		t.Errorf("unexpected error (%v)", err)
+	} else if card != 10058400 {
+		t.Errorf("incorrect card number - expected:%v, got:%v", 10058400, card)
+	}
+
+	if _, err := registry.lookup("Nadia Okafor"); err == nil {
+		t.Errorf("expected 'not unique' error for ambiguous name")
+	}
+
+	if _, err := registry.lookup("Joe Bloggs"); err == nil {
+		t.Errorf("expected error for unregistered name")
+	}
+}
+
+func TestGetCardsPrintWithCardholders(t *testing.T) {
+	getCards := GetCards{
+		cardholders: cardholders{
+			8165538: cardholder{CardNumber: 8165538, Name: "Eduardo Ramos", Department: "Facilities"},
+		},
+	}
+
+	expected := `12345    2023-01-01 2023-12-21 Y N N N  - -
+8165538  2023-01-01 2023-12-31 Y N N 29 - - Eduardo Ramos (Facilities)
+`
+
+	recordset := []types.Card{
+		types.Card{
+			CardNumber: 12345,
+			From:       types.MustParseDate("2023-01-01"),
+			To:         types.MustParseDate("2023-12-21"),
+			Doors:      map[uint8]uint8{1: 1, 2: 0, 3: 0, 4: 0},
+		},
+		types.Card{
+			CardNumber: 8165538,
+			From:       types.MustParseDate("2023-01-01"),
+			To:         types.MustParseDate("2023-12-31"),
+			Doors:      map[uint8]uint8{1: 1, 2: 0, 3: 0, 4: 29},
+		},
+	}
+
+	var b bytes.Buffer
+
+	if err := getCards.print(recordset, &b); err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	} else if b.String() != expected {
+		t.Errorf("Incorrectly formatted cards\n   -- expected:\n%v\n   -- got:\n%v", expected, b.String())
+	}
+}
@@ -0,0 +1,281 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+var CheckTimeProfilesCmd = CheckTimeProfiles{
+	offline: false,
+}
+
+type CheckTimeProfiles struct {
+	offline bool
+}
+
+type profileIssue struct {
+	profile uint8
+	message string
+}
+
+func (c *CheckTimeProfiles) Execute(ctx Context) error {
+	flagset := newFlagSet()
+	offline := flagset.Bool("offline", false, "Skips the checks that require the controllers")
+
+	if err := flagset.Parse(flag.Args()[1:]); err != nil {
+		return err
+	}
+
+	c.offline = *offline
+
+	if len(flagset.Args()) < 1 {
+		return fmt.Errorf("missing controller serial number or TSV file")
+	}
+
+	arg := flagset.Arg(0)
+	if stat, err := os.Stat(arg); err == nil && stat.Mode().IsRegular() {
+		return c.checkFile(ctx, arg)
+	}
+
+	serialNumber, err := parseSerialNumber(ctx, arg)
+	if err != nil {
+		return err
+	}
+
+	return c.checkController(ctx, serialNumber)
+}
+
+// Checks the time profiles on a controller against the cards on the controller.
+func (c *CheckTimeProfiles) checkController(ctx Context, serialNumber uint32) error {
+	profiles, err := getTimeProfiles(ctx, serialNumber)
+	if err != nil {
+		return err
+	}
+
+	var references map[uint8]bool
+	if !c.offline {
+		if cards, err := getCards(ctx, serialNumber); err != nil {
+			return fmt.Errorf("%v: error retrieving cards (%v)", serialNumber, err)
+		} else {
+			references = referencedTimeProfiles(cards)
+		}
+	}
+
+	date := types.ToDate(time.Now().Year(), time.Now().Month(), time.Now().Day())
+	for _, device := range ctx.devices {
+		if device.DeviceID == serialNumber {
+			date = today(device)
+		}
+	}
+
+	return c.report(fmt.Sprintf("controller %v", serialNumber), c.check(profiles, references, date))
+}
+
+// Checks the time profiles in a TSV file (in the same format as for set-time-profiles) against
+// the cards on all the configured controllers.
+func (c *CheckTimeProfiles) checkFile(ctx Context, file string) error {
+	list, err := (&SetTimeProfiles{}).parse(file)
+	if err != nil {
+		return err
+	} else if len(list) == 0 {
+		return fmt.Errorf("file '%s' does not contain any valid time profiles", file)
+	}
+
+	issues := []profileIssue{}
+	profiles := map[uint8]types.TimeProfile{}
+	for _, p := range list {
+		if q, ok := profiles[p.ID]; ok && !sameTimeProfile(p, q) {
+			issues = append(issues, profileIssue{p.ID, "has more than one definition"})
+		}
+
+		profiles[p.ID] = p
+	}
+
+	var references map[uint8]bool
+	if !c.offline && len(ctx.devices) > 0 {
+		cards := []types.Card{}
+		for _, device := range ctx.devices {
+			if list, err := getCards(ctx, device.DeviceID); err != nil {
+				return fmt.Errorf("%v: error retrieving cards (%v)", device.DeviceID, err)
+			} else {
+				cards = append(cards, list...)
+			}
+		}
+
+		references = referencedTimeProfiles(cards)
+	}
+
+	now := time.Now()
+	date := types.ToDate(now.Year(), now.Month(), now.Day())
+
+	issues = append(issues, c.check(profiles, references, date)...)
+
+	return c.report(fmt.Sprintf("'%v'", file), issues)
+}
+
+func (c *CheckTimeProfiles) report(source string, issues []profileIssue) error {
+	if len(issues) == 0 {
+		fmt.Printf("   ... %v  ok\n", source)
+		return nil
+	}
+
+	slices.SortStableFunc(issues, func(p, q profileIssue) int {
+		return int(p.profile) - int(q.profile)
+	})
+
+	for _, issue := range issues {
+		fmt.Printf("   profile %-4v %v\n", issue.profile, issue.message)
+	}
+
+	return fmt.Errorf("%v issues in time profiles for %v", len(issues), source)
+}
+
+// Checks a set of time profiles for:
+//   - links to undefined time profiles and circular references
+//   - invalid and expired date ranges
+//   - segments with an 'End' before the 'Start' and overlapping segments
+//   - profiles that are not referenced by any card (directly or via a linked profile)
+//
+// The unreferenced profiles check is skipped if references is nil.
+func (c *CheckTimeProfiles) check(profiles map[uint8]types.TimeProfile, references map[uint8]bool, today types.Date) []profileIssue {
+	issues := []profileIssue{}
+	warn := func(id uint8, format string, args ...any) {
+		issues = append(issues, profileIssue{
+			profile: id,
+			message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	// ... referenced either directly or as a linked profile of a referenced profile
+	referenced := map[uint8]bool{}
+	for id := range references {
+		chain, _ := linkedChain(id, profiles)
+		for _, p := range chain {
+			referenced[p] = true
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(profiles)) {
+		profile := profiles[id]
+
+		// ... linked profiles
+		if _, err := linkedChain(id, profiles); err != nil {
+			warn(id, "%v", err)
+		}
+
+		// ... from/to
+		if profile.From.IsZero() {
+			warn(id, "invalid 'From' date (%v)", profile.From)
+		}
+
+		if profile.To.IsZero() {
+			warn(id, "invalid 'To' date (%v)", profile.To)
+		}
+
+		if !profile.From.IsZero() && !profile.To.IsZero() && profile.To.Before(profile.From) {
+			warn(id, "'To' date (%v) is before 'From' date (%v)", profile.To, profile.From)
+		} else if !profile.To.IsZero() && profile.To.Before(today) {
+			warn(id, "expired on %v", profile.To)
+		}
+
+		// ... segments
+		active := []uint8{}
+		for _, i := range []uint8{1, 2, 3} {
+			segment := profile.Segments[i]
+
+			if segment.End.Before(segment.Start) {
+				warn(id, "segment %v 'End' (%v) is before 'Start' (%v)", i, segment.End, segment.Start)
+			} else if segment.Start.Before(segment.End) {
+				active = append(active, i)
+			}
+		}
+
+		for j, p := range active {
+			for _, q := range active[j+1:] {
+				s := profile.Segments[p]
+				t := profile.Segments[q]
+
+				if s.Start.Before(t.End) && t.Start.Before(s.End) {
+					warn(id, "segment %v (%v-%v) overlaps segment %v (%v-%v)", q, t.Start, t.End, p, s.Start, s.End)
+				}
+			}
+		}
+
+		// ... references
+		if references != nil && !referenced[id] {
+			warn(id, "is not referenced by any card")
+		}
+	}
+
+	return issues
+}
+
+// Returns the time profiles assigned to doors in a list of cards.
+func referencedTimeProfiles(cards []types.Card) map[uint8]bool {
+	references := map[uint8]bool{}
+	for _, card := range cards {
+		for _, permission := range card.Doors {
+			if permission >= 2 && permission <= 254 {
+				references[permission] = true
+			}
+		}
+	}
+
+	return references
+}
+
+func (c *CheckTimeProfiles) CLI() string {
+	return "check-time-profiles"
+}
+
+func (c *CheckTimeProfiles) Description() string {
+	return "Checks the time profiles on a controller or in a TSV file for errors"
+}
+
+func (c *CheckTimeProfiles) Usage() string {
+	return "[--offline] <serial number | file>"
+}
+
+func (c *CheckTimeProfiles) Help() {
+	fmt.Println("Usage: uhppote-cli [options] check-time-profiles [--offline] <serial number | file>")
+	fmt.Println()
+	fmt.Println(" Checks the time profiles on a controller (or in a TSV file in the same format as for set-time-profiles) for")
+	fmt.Println(" errors that would result in a card not having the expected access:")
+	fmt.Println()
+	fmt.Println("   - links to undefined time profiles and circular references")
+	fmt.Println("   - 'To' dates before 'From' dates and time profiles that have already expired")
+	fmt.Println("   - segments with an 'End' before the 'Start' and overlapping segments")
+	fmt.Println("   - time profiles that are not referenced by any card, either directly or via a linked time profile")
+	fmt.Println()
+	fmt.Println(" Time profiles in a TSV file are checked against the cards on all the controllers in the configuration")
+	fmt.Println(" file.")
+	fmt.Println()
+	fmt.Println("  serial number  controller serial number (or name)")
+	fmt.Println("  file           TSV file with time profiles")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --config  File path for the 'conf' file containing the controller configuration")
+	fmt.Printf("              (defaults to %s)\n", config.DefaultConfig)
+	fmt.Println("    --debug   Displays internal information for diagnosing errors")
+	fmt.Println()
+	fmt.Println("    --offline Skips the check for time profiles that are not referenced by any card")
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println()
+	fmt.Println("    uhppote-cli check-time-profiles 9876543210")
+	fmt.Println("    uhppote-cli check-time-profiles --offline time-profiles.tsv")
+	fmt.Println()
+}
+
+// Returns false - configuration is useful but optional.
+func (c *CheckTimeProfiles) RequiresConfig() bool {
+	return false
+}
@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+func TestCheckTimeProfiles(t *testing.T) {
+	overlapping := testProfile(5, 0, "08:00", "12:00")
+	overlapping.Segments[2] = types.Segment{Start: types.MustParseHHmm("11:30"), End: types.MustParseHHmm("17:00")}
+
+	expired := testProfile(6, 0, "08:00", "17:00")
+	expired.To = types.MustParseDate("2026-06-30")
+
+	profiles := map[uint8]types.TimeProfile{
+		2:  testProfile(2, 3, "08:00", "12:00"),
+		3:  testProfile(3, 0, "13:00", "17:00"),
+		4:  testProfile(4, 0, "17:00", "08:00"),
+		5:  overlapping,
+		6:  expired,
+		7:  testProfile(7, 8, "08:00", "17:00"),
+		8:  testProfile(8, 7, "08:00", "17:00"),
+		9:  testProfile(9, 99, "08:00", "17:00"),
+		10: testProfile(10, 0, "08:00", "17:00"),
+	}
+
+	references := map[uint8]bool{2: true, 4: true, 5: true, 6: true, 7: true, 9: true}
+
+	expected := []profileIssue{
+		{4, "segment 1 'End' (08:00) is before 'Start' (17:00)"},
+		{5, "segment 2 (11:30-17:00) overlaps segment 1 (08:00-12:00)"},
+		{6, "expired on 2026-06-30"},
+		{7, "circular reference [7 8 7]"},
+		{8, "circular reference [8 7 8]"},
+		{9, "linked time profile 99 is not defined"},
+		{10, "is not referenced by any card"},
+	}
+
+	issues := (&CheckTimeProfiles{}).check(profiles, references, types.MustParseDate("2026-10-19"))
+
+	if !reflect.DeepEqual(issues, expected) {
+		t.Errorf("incorrect issues\n   expected:%v\n   got:     %v", expected, issues)
+	}
+}
+
+func TestCheckTimeProfilesOffline(t *testing.T) {
+	profiles := map[uint8]types.TimeProfile{
+		10: testProfile(10, 0, "08:00", "17:00"),
+	}
+
+	if issues := (&CheckTimeProfiles{}).check(profiles, nil, types.MustParseDate("2026-10-19")); len(issues) != 0 {
+		t.Errorf("expected no issues, got %v", issues)
+	}
+}
+
+func TestReferencedTimeProfiles(t *testing.T) {
+	cards := []types.Card{
+		{CardNumber: 10058400, Doors: map[uint8]uint8{1: 1, 2: 29, 3: 0, 4: 0}},
+		{CardNumber: 10058401, Doors: map[uint8]uint8{1: 0, 2: 0, 3: 30, 4: 29}},
+	}
+
+	expected := map[uint8]bool{29: true, 30: true}
+
+	if references := referencedTimeProfiles(cards); !reflect.DeepEqual(references, expected) {
+		t.Errorf("incorrect references - expected:%v, got:%v", expected, references)
+	}
+}
@@ -3,6 +3,7 @@ package commands
 import (
 	"fmt"
 
+	"github.com/uhppoted/uhppote-core/types"
 	"github.com/uhppoted/uhppoted-lib/config"
 )
 
@@ -22,6 +23,10 @@ func (c *ClearTaskList) Execute(ctx Context) error {
 		return err
 	}
 
+	if cleared {
+		recordTaskList(ctx, serialNumber, []types.Task{}, true)
+	}
+
 	fmt.Printf("%v %v\n", serialNumber, cleared)
 
 	return nil
@@ -1,8 +1,10 @@
 package commands
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"regexp"
 	"slices"
 	"strconv"
@@ -14,12 +16,22 @@ import (
 	"github.com/uhppoted/uhppoted-lib/config"
 )
 
+// flagErrorHandling is the error handling for the command option flag sets. The shell sets it to
+// flag.ContinueOnError so that an invalid option (or -h) is returned as an error rather than
+// exiting the shell.
+var flagErrorHandling = flag.ExitOnError
+
+// ErrDrift is returned (wrapped) by commands that compare the controllers against an
+// authoritative source if the controllers differ from the authoritative source.
+var ErrDrift = errors.New("controllers differ from the authoritative source")
+
 // Context contains the environment and configuration information required for all commands
 type Context struct {
-	uhppote uhppote.IUHPPOTE
-	devices []uhppote.Device
-	config  *config.Config
-	debug   bool
+	uhppote  uhppote.IUHPPOTE
+	devices  []uhppote.Device
+	config   *config.Config
+	settings Settings
+	debug    bool
 }
 
 // NewContext returns a valid Context initialized with the supplied UHPPOTE and
@@ -73,6 +85,17 @@ type Command interface {
 	RequiresConfig() bool
 }
 
+// Returns a flag set for the command options with the current flag error handling. The flag
+// set is silent with flag.ContinueOnError - the caller reports the error.
+func newFlagSet() *flag.FlagSet {
+	flagset := flag.NewFlagSet("", flagErrorHandling)
+	if flagErrorHandling == flag.ContinueOnError {
+		flagset.SetOutput(io.Discard)
+	}
+
+	return flagset
+}
+
 func clean(s string) string {
 	return regexp.MustCompile(`[\s\t]+`).ReplaceAllString(strings.ToLower(s), "")
 }
@@ -86,8 +109,11 @@ func getSerialNumberI(ctx Context, index int) (uint32, error) {
 		return 0, fmt.Errorf("missing controller serial number")
 	}
 
-	arg := flag.Arg(index)
+	return parseSerialNumber(ctx, flag.Arg(index))
+}
 
+// Resolves a controller name or serial number to a controller serial number.
+func parseSerialNumber(ctx Context, arg string) (uint32, error) {
 	// lookup controller by name
 	if ctx.config != nil {
 		for k, v := range ctx.config.Devices {
@@ -2,10 +2,13 @@ package commands
 
 import (
 	"fmt"
+	"maps"
 	"net"
 	"net/netip"
 	"os"
 	"reflect"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
@@ -220,3 +223,92 @@ func (s *stub) DeviceList() map[uint32]uhppote.Device {
 func (s *stub) ListenAddrList() []netip.AddrPort {
 	return nil
 }
+
+// doorControlStub is the shared door control stub for the unlock, lockdown/evacuate and tui
+// tests. Door control states are keyed by controller and door and commands to an 'offline'
+// controller fail with a timeout.
+type doorControlStub struct {
+	stub
+	doors   map[doorID]types.DoorControlState
+	opened  []doorID
+	offline []uint32
+	sync.Mutex
+}
+
+func (s *doorControlStub) GetDoorControlState(controller uint32, door byte) (*types.DoorControlState, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if slices.Contains(s.offline, controller) {
+		return nil, fmt.Errorf("timeout")
+	}
+
+	state := s.doors[doorID{controller, door}]
+
+	return &state, nil
+}
+
+func (s *doorControlStub) SetDoorControlState(controller uint32, door uint8, mode types.ControlState, delay uint8) (*types.DoorControlState, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if slices.Contains(s.offline, controller) {
+		return nil, fmt.Errorf("timeout")
+	}
+
+	state := types.DoorControlState{
+		SerialNumber: types.SerialNumber(controller),
+		Door:         door,
+		ControlState: mode,
+		Delay:        delay,
+	}
+
+	s.doors[doorID{controller, door}] = state
+
+	return &state, nil
+}
+
+func (s *doorControlStub) OpenDoor(controller uint32, door uint8) (*types.Result, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if slices.Contains(s.offline, controller) {
+		return nil, fmt.Errorf("timeout")
+	}
+
+	s.opened = append(s.opened, doorID{controller, door})
+
+	return &types.Result{SerialNumber: types.SerialNumber(controller), Succeeded: true}, nil
+}
+
+// Returns the current door control state for a door.
+func (s *doorControlStub) state(controller uint32, door uint8) types.DoorControlState {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.doors[doorID{controller, door}]
+}
+
+// Sets the current door control state for a door.
+func (s *doorControlStub) set(state types.DoorControlState) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.doors[doorID{uint32(state.SerialNumber), state.Door}] = state
+}
+
+// Sets the controllers that fail with a timeout.
+func (s *doorControlStub) setOffline(controllers ...uint32) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.offline = controllers
+}
+
+// Returns a copy of the door control states.
+func (s *doorControlStub) snapshot() map[doorID]types.DoorControlState {
+	s.Lock()
+	defer s.Unlock()
+
+	return maps.Clone(s.doors)
+}
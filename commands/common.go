@@ -8,6 +8,9 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppote-core/uhppote"
 )
 
 // WEEKDAYS
@@ -182,3 +185,111 @@ func format(table [][]string) []string {
 
 	return rows
 }
+
+// Retrieves all the cards stored on a controller. Returns the cards retrieved so far along
+// with the error if retrieving a card fails.
+func getCards(ctx Context, deviceID uint32) ([]types.Card, error) {
+	N, err := ctx.uhppote.GetCards(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	recordset := []types.Card{}
+	index := uint32(1)
+	for count := uint32(0); count < N; {
+		record, err := ctx.uhppote.GetCardByIndex(deviceID, index)
+		if err != nil {
+			return recordset, err
+		}
+
+		if record != nil {
+			recordset = append(recordset, *record)
+			count++
+		}
+
+		index++
+	}
+
+	return recordset, nil
+}
+
+// Parses a period specified as a number of days or weeks e.g. 30d or 4w. A plain number
+// is interpreted as days.
+func parsePeriod(s string) (int, error) {
+	match := regexp.MustCompile(`^\s*([0-9]+)\s*([dDwW]?)\s*$`).FindStringSubmatch(s)
+	if match == nil {
+		return 0, fmt.Errorf("invalid period '%v' (expected e.g. 30d or 4w)", s)
+	}
+
+	N, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid period '%v' (expected e.g. 30d or 4w)", s)
+	}
+
+	if strings.EqualFold(match[2], "w") {
+		return 7 * N, nil
+	}
+
+	return N, nil
+}
+
+// Returns the current date for a controller, in the controller timezone.
+func today(device uhppote.Device) types.Date {
+	now := time.Now()
+	if device.TimeZone != nil {
+		now = now.In(device.TimeZone)
+	}
+
+	return types.ToDate(now.Year(), now.Month(), now.Day())
+}
+
+// configuredDoor identifies a door defined in the configuration file by controller and
+// door number.
+type configuredDoor struct {
+	controller uint32
+	door       uint8
+	name       string
+}
+
+// Returns the doors defined in the configuration file, keyed by the 'cleaned' door name.
+func getConfiguredDoors(ctx Context) map[string]configuredDoor {
+	doors := map[string]configuredDoor{}
+	for _, d := range ctx.devices {
+		for i, name := range d.Doors {
+			if clean(name) != "" {
+				doors[clean(name)] = configuredDoor{
+					controller: d.DeviceID,
+					door:       uint8(i + 1),
+					name:       name,
+				}
+			}
+		}
+	}
+
+	return doors
+}
+
+// Resolves a door name from the configuration file or a <controller>:<door> pair.
+func resolveDoor(ctx Context, arg string) (configuredDoor, error) {
+	if door, ok := getConfiguredDoors(ctx)[clean(arg)]; ok {
+		return door, nil
+	}
+
+	if controller, door, ok := strings.Cut(arg, ":"); ok {
+		if serialNumber, err := parseSerialNumber(ctx, controller); err != nil {
+			return configuredDoor{}, err
+		} else if v, err := strconv.ParseUint(door, 10, 8); err != nil || v < 1 || v > 4 {
+			return configuredDoor{}, fmt.Errorf("invalid door (%v)", door)
+		} else {
+			for _, d := range getConfiguredDoors(ctx) {
+				if d.controller == serialNumber && d.door == uint8(v) {
+					return d, nil
+				}
+			}
+
+			return configuredDoor{controller: serialNumber, door: uint8(v)}, nil
+		}
+	}
+
+	return configuredDoor{}, fmt.Errorf("unknown door '%v' (expected a configured door name or <controller>:<door>)", arg)
+}
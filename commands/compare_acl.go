@@ -2,15 +2,21 @@ package commands
 
 import (
 	"bytes"
+	"cmp"
+	"encoding/json"
 	"flag"
 	"fmt"
-	"github.com/uhppoted/uhppote-core/types"
-	"github.com/uhppoted/uhppoted-lib/acl"
-	"github.com/uhppoted/uhppoted-lib/config"
+	htmltemplate "html/template"
 	"io"
 	"os"
+	"slices"
+	"strings"
 	"text/template"
 	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppoted-lib/acl"
+	"github.com/uhppoted/uhppoted-lib/config"
 )
 
 // CompareACLCmd is an initialized CompareACL command for the main() command list
@@ -18,6 +24,7 @@ var CompareACLCmd = CompareACL{
 	file:    "",
 	rptfile: "",
 	withPIN: false,
+	format:  "text",
 	template: `
 -----------------------------------
 ACL DIFF REPORT {{ .DateTime }}
@@ -30,14 +37,105 @@ ACL DIFF REPORT {{ .DateTime }}
     Unexpected: {{range $value.Deleted}}{{.}}
                 {{end}}{{end}}{{end}}
 -----------------------------------
+`,
+	markdown: `# ACL DIFF REPORT {{ .DateTime }}
+{{range .Devices}}
+## {{ .DeviceID }}{{if .OK}} OK{{end}}
+
+| Same | Incorrect | Missing | Unexpected |
+|------|-----------|---------|------------|
+| {{ .Unchanged }} | {{ len .Incorrect }} | {{ len .Missing }} | {{ len .Unexpected }} |
+{{if .Incorrect}}
+### Incorrect
+
+| Card | Field | Expected | Actual |
+|------|-------|----------|--------|
+{{range $card := .Incorrect}}{{range .Fields}}| {{ $card.CardNumber }} | {{ .Field }} | {{ .Expected }} | {{ .Actual }} |
+{{end}}{{end}}{{end}}{{if .Missing}}
+### Missing
+
+| Card |
+|------|
+{{range .Missing}}| {{ . }} |
+{{end}}{{end}}{{if .Unexpected}}
+### Unexpected
+
+| Card |
+|------|
+{{range .Unexpected}}| {{ . }} |
+{{end}}{{end}}{{end}}`,
+	html: `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>ACL DIFF REPORT {{ .DateTime }}</title>
+</head>
+<body>
+<h1>ACL DIFF REPORT {{ .DateTime }}</h1>
+{{range .Devices}}
+<h2>{{ .DeviceID }}{{if .OK}} OK{{end}}</h2>
+<table>
+<tr><th>Same</th><th>Incorrect</th><th>Missing</th><th>Unexpected</th></tr>
+<tr><td>{{ .Unchanged }}</td><td>{{ len .Incorrect }}</td><td>{{ len .Missing }}</td><td>{{ len .Unexpected }}</td></tr>
+</table>
+{{if .Incorrect}}
+<h3>Incorrect</h3>
+<table>
+<tr><th>Card</th><th>Field</th><th>Expected</th><th>Actual</th></tr>
+{{range $card := .Incorrect}}{{range .Fields}}<tr><td>{{ $card.CardNumber }}</td><td>{{ .Field }}</td><td>{{ .Expected }}</td><td>{{ .Actual }}</td></tr>
+{{end}}{{end}}</table>
+{{end}}{{if .Missing}}
+<h3>Missing</h3>
+<ul>
+{{range .Missing}}<li>{{ . }}</li>
+{{end}}</ul>
+{{end}}{{if .Unexpected}}
+<h3>Unexpected</h3>
+<ul>
+{{range .Unexpected}}<li>{{ . }}</li>
+{{end}}</ul>
+{{end}}{{end}}
+</body>
+</html>
 `,
 }
 
 type CompareACL struct {
 	file     string
+	source   aclSource
 	rptfile  string
 	withPIN  bool
+	format   string
 	template string
+	markdown string
+	html     string
+}
+
+type aclReport struct {
+	DateTime types.DateTime    `json:"timestamp"`
+	Devices  []aclDeviceReport `json:"controllers"`
+}
+
+type aclDeviceReport struct {
+	DeviceID   uint32        `json:"controller"`
+	OK         bool          `json:"ok"`
+	Unchanged  int           `json:"unchanged"`
+	Incorrect  []aclCardDiff `json:"incorrect"`
+	Missing    []types.Card  `json:"missing"`
+	Unexpected []types.Card  `json:"unexpected"`
+}
+
+type aclCardDiff struct {
+	CardNumber uint32         `json:"card-number"`
+	Expected   types.Card     `json:"expected"`
+	Actual     types.Card     `json:"actual"`
+	Fields     []aclFieldDiff `json:"fields"`
+}
+
+type aclFieldDiff struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
 }
 
 func (c *CompareACL) Execute(ctx Context) error {
@@ -53,12 +151,12 @@ func (c *CompareACL) Execute(ctx Context) error {
 		return fmt.Errorf("please specify the TSV file from which to load the authoritative access control list ")
 	}
 
-	tsv, err := os.ReadFile(c.file)
+	tsv, err := c.source.read()
 	if err != nil {
 		return err
 	}
 
-	list, warnings, err := acl.ParseTSV(bytes.NewReader(tsv), ctx.devices, false)
+	list, warnings, err := acl.ParseTSV(bytes.NewReader(getProfileNames(ctx).resolveACL(tsv)), ctx.devices, false)
 	if err != nil {
 		return err
 	}
@@ -124,19 +222,174 @@ func (c *CompareACL) Execute(ctx Context) error {
 	}
 
 	var w bytes.Buffer
-	if err := c.report(diff, &w); err != nil {
-		return err
+	switch c.format {
+	case "json", "markdown", "html":
+		if err := c.export(c.build(current, list, diff), &w); err != nil {
+			return err
+		}
+
+	default:
+		if err := c.report(diff, &w); err != nil {
+			return err
+		}
 	}
 
 	if c.rptfile != "" {
-		return os.WriteFile(c.rptfile, w.Bytes(), 0660)
+		if err := os.WriteFile(c.rptfile, w.Bytes(), 0660); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("%v\n", w.String())
+	}
+
+	drift := []string{}
+	for k, v := range diff {
+		if len(v.Updated) > 0 || len(v.Added) > 0 || len(v.Deleted) > 0 {
+			drift = append(drift, fmt.Sprintf("%v", k))
+		}
 	}
 
-	fmt.Printf("%v\n", w.String())
+	if len(drift) > 0 {
+		slices.Sort(drift)
+
+		return fmt.Errorf("%w (%v)", ErrDrift, strings.Join(drift, ","))
+	}
 
 	return nil
 }
 
+// Builds the detailed report used for the JSON, markdown and HTML formats, with the
+// current and authoritative card records and the field level differences for
+// 'incorrect' cards.
+func (c *CompareACL) build(current acl.ACL, list acl.ACL, diff map[uint32]acl.Diff) aclReport {
+	rpt := aclReport{
+		DateTime: types.DateTime(time.Now()),
+		Devices:  []aclDeviceReport{},
+	}
+
+	keys := []uint32{}
+	for k := range diff {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	byCardNumber := func(p, q types.Card) int {
+		return cmp.Compare(p.CardNumber, q.CardNumber)
+	}
+
+	for _, k := range keys {
+		v := diff[k]
+		device := aclDeviceReport{
+			DeviceID:   k,
+			OK:         len(v.Updated) == 0 && len(v.Added) == 0 && len(v.Deleted) == 0,
+			Unchanged:  len(v.Unchanged),
+			Incorrect:  []aclCardDiff{},
+			Missing:    slices.SortedFunc(slices.Values(v.Added), byCardNumber),
+			Unexpected: slices.SortedFunc(slices.Values(v.Deleted), byCardNumber),
+		}
+
+		for _, card := range slices.SortedFunc(slices.Values(v.Updated), byCardNumber) {
+			expected := card
+			actual := card
+
+			if cards, ok := list[k]; ok {
+				if record, ok := cards[card.CardNumber]; ok {
+					expected = record
+				}
+			}
+
+			if cards, ok := current[k]; ok {
+				if record, ok := cards[card.CardNumber]; ok {
+					actual = record
+				}
+			}
+
+			device.Incorrect = append(device.Incorrect, aclCardDiff{
+				CardNumber: card.CardNumber,
+				Expected:   expected,
+				Actual:     actual,
+				Fields:     c.compare(expected, actual),
+			})
+		}
+
+		rpt.Devices = append(rpt.Devices, device)
+	}
+
+	return rpt
+}
+
+// Returns the list of fields that differ between the authoritative and current card
+// records. The PIN is only compared if --with-pin is specified.
+func (c *CompareACL) compare(expected, actual types.Card) []aclFieldDiff {
+	fields := []aclFieldDiff{}
+
+	f := func(field string, p, q any) {
+		if u, v := fmt.Sprintf("%v", p), fmt.Sprintf("%v", q); u != v {
+			fields = append(fields, aclFieldDiff{
+				Field:    field,
+				Expected: u,
+				Actual:   v,
+			})
+		}
+	}
+
+	door := func(p uint8) string {
+		switch {
+		case p == 1:
+			return "Y"
+
+		case p >= 2 && p <= 254:
+			return fmt.Sprintf("%v", p)
+
+		default:
+			return "N"
+		}
+	}
+
+	f("From", expected.From, actual.From)
+	f("To", expected.To, actual.To)
+
+	for _, d := range []uint8{1, 2, 3, 4} {
+		f(fmt.Sprintf("Door %v", d), door(expected.Doors[d]), door(actual.Doors[d]))
+	}
+
+	if c.withPIN {
+		f("PIN", expected.PIN, actual.PIN)
+	}
+
+	return fields
+}
+
+func (c *CompareACL) export(rpt aclReport, w io.Writer) error {
+	switch c.format {
+	case "json":
+		if bytes, err := json.MarshalIndent(rpt, "", "  "); err != nil {
+			return err
+		} else {
+			_, err = fmt.Fprintf(w, "%s\n", bytes)
+			return err
+		}
+
+	case "markdown":
+		if t, err := template.New("report").Parse(c.markdown); err != nil {
+			return err
+		} else {
+			return t.Execute(w, rpt)
+		}
+
+	case "html":
+		if t, err := htmltemplate.New("report").Parse(c.html); err != nil {
+			return err
+		} else {
+			return t.Execute(w, rpt)
+		}
+
+	default:
+		return fmt.Errorf("unsupported report format '%v'", c.format)
+	}
+}
+
 func (c *CompareACL) report(diff map[uint32]acl.Diff, w io.Writer) error {
 	t, err := template.New("report").Parse(c.template)
 	if err != nil {
@@ -156,29 +409,39 @@ func (c *CompareACL) report(diff map[uint32]acl.Diff, w io.Writer) error {
 }
 
 func (c *CompareACL) parseArgs() error {
-	flagset := flag.NewFlagSet("", flag.ExitOnError)
+	flagset := newFlagSet()
 	withPIN := flagset.Bool("with-pin", false, "Include card keypad PIN code in retrieved ACL information")
+	format := flagset.String("format", "text", "Report format (text, json, markdown or html)")
+	aclFormat := flagset.String("acl-format", "", "ACL format (tsv, csv or json)")
+	delimiter := flagset.String("delimiter", ",", "CSV field delimiter")
 	file := ""
 	rptfile := ""
 	args := flag.Args()[1:]
 
-	flagset.Parse(args)
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
 
 	// ... file
 	if len(flagset.Args()) > 0 {
 		file = flagset.Arg(0)
-		stat, err := os.Stat(file)
-		if err != nil && os.IsNotExist(err) {
-			return fmt.Errorf("file '%s' does not exist", file)
-		} else if err != nil {
+		if err := (aclSource{file: file}).validate(); err != nil {
 			return err
-		} else if err == nil && stat.Mode().IsDir() {
-			return fmt.Errorf("file '%s' is a directory", file)
-		} else if err == nil && !stat.Mode().IsRegular() {
-			return fmt.Errorf("file '%s' is not a real file", file)
 		}
 	}
 
+	if v, err := parseACLFormat(*aclFormat); err != nil {
+		return err
+	} else {
+		c.source.format = v
+	}
+
+	if v, err := parseDelimiter(*delimiter); err != nil {
+		return err
+	} else {
+		c.source.delimiter = v
+	}
+
 	// ... report file
 	if len(flagset.Args()) > 1 {
 		rptfile = flagset.Arg(1)
@@ -192,7 +455,22 @@ func (c *CompareACL) parseArgs() error {
 		}
 	}
 
+	// ... report format
+	switch clean(*format) {
+	case "", "text", "txt":
+		c.format = "text"
+	case "json":
+		c.format = "json"
+	case "markdown", "md":
+		c.format = "markdown"
+	case "html":
+		c.format = "html"
+	default:
+		return fmt.Errorf("invalid report format '%v' (expected text, json, markdown or html)", *format)
+	}
+
 	c.file = file
+	c.source.file = file
 	c.rptfile = rptfile
 	c.withPIN = *withPIN
 
@@ -208,15 +486,18 @@ func (c *CompareACL) Description() string {
 }
 
 func (c *CompareACL) Usage() string {
-	return "<TSV file> [<report file>]"
+	return "[--format <format>] <TSV file> [<report file>]"
 }
 
 func (c *CompareACL) Help() {
-	fmt.Println("Usage: uhppote-cli [options] compare-acl <TSV file> <report file>")
+	fmt.Println("Usage: uhppote-cli [options] compare-acl [--with-pin] [--format <format>] [--acl-format <format>] <TSV file> <report file>")
 	fmt.Println()
 	fmt.Println(" Compares the card lists in the configurated controllers to the authoritative access control list in the TSV file")
 	fmt.Println(" Duplicate card numbers are ignored (with a warning message)")
 	fmt.Println()
+	fmt.Println(" Exits with status 2 if any of the controller card lists differ from the authoritative access control list,")
+	fmt.Println(" 1 if the comparison failed and 0 if all the controllers match the authoritative access control list.")
+	fmt.Println()
 	fmt.Println("  <TSV file>    (required) TSV file with access control list")
 	fmt.Println()
 	fmt.Println("                The TSV file should conform to the following format:")
@@ -229,12 +510,19 @@ func (c *CompareACL) Help() {
 	fmt.Println()
 	fmt.Println("  <report file> (optional) file to which to write the 'compare' report. Defaults to stdout if not provided")
 	fmt.Println()
+	printACLSourcesHelp()
 	fmt.Println("  Options:")
 	fmt.Println()
 	fmt.Println("    --config  File path for the 'conf' file containing the controller configuration")
 	fmt.Printf("              (defaults to %s)\n", config.DefaultConfig)
 	fmt.Println("    --debug   Displays internal information for diagnosing errors")
 	fmt.Println()
+	fmt.Println("    --format   Report format. Valid formats are:")
+	fmt.Println("               - text     (default) plain text summary report")
+	fmt.Println("               - json     JSON report with the card records and field differences for each controller")
+	fmt.Println("               - markdown markdown report with the card records and field differences for each controller")
+	fmt.Println("               - html     HTML report with the card records and field differences for each controller")
+	fmt.Println()
 	fmt.Println("    --with-pin Includes the card keypad PIN code when comparing ACLs.")
 	fmt.Println()
 	fmt.Println("               The TSV file with PIN should conform to the following format:")
@@ -246,6 +534,8 @@ func (c *CompareACL) Help() {
 	fmt.Println()
 	fmt.Println("    uhppote-cli compare-acl \"uhppote-2023-03-07.tsv\"")
 	fmt.Println("    uhppote-cli --debug --config .config compare-acl --with-pin \"uhppote-2023-03-07.tsv\"")
+	fmt.Println("    uhppote-cli compare-acl --format json \"uhppote-2023-03-07.tsv\" \"uhppote-2023-03-07.json\"")
+	fmt.Println("    cat \"uhppote-2023-03-07.json\" | uhppote-cli compare-acl --acl-format json -")
 	fmt.Println()
 }
 
@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+func TestCompareACLFieldDifferences(t *testing.T) {
+	compareACL := CompareACL{withPIN: true}
+
+	expected := types.Card{
+		CardNumber: 10058400,
+		From:       types.MustParseDate("2026-01-01"),
+		To:         types.MustParseDate("2026-12-31"),
+		Doors:      map[uint8]uint8{1: 1, 2: 0, 3: 0, 4: 29},
+		PIN:        7531,
+	}
+
+	actual := types.Card{
+		CardNumber: 10058400,
+		From:       types.MustParseDate("2026-01-01"),
+		To:         types.MustParseDate("2026-06-30"),
+		Doors:      map[uint8]uint8{1: 1, 2: 1, 3: 0, 4: 0},
+		PIN:        7531,
+	}
+
+	fields := []aclFieldDiff{
+		{Field: "To", Expected: "2026-12-31", Actual: "2026-06-30"},
+		{Field: "Door 2", Expected: "N", Actual: "Y"},
+		{Field: "Door 4", Expected: "29", Actual: "N"},
+	}
+
+	if diff := compareACL.compare(expected, actual); !reflect.DeepEqual(diff, fields) {
+		t.Errorf("Incorrect field differences\n   expected:%v\n   got:     %v", fields, diff)
+	}
+}
@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+var DiffTaskListCmd = DiffTaskList{}
+
+type DiffTaskList struct {
+}
+
+func (c *DiffTaskList) Execute(ctx Context) error {
+	serialNumber, err := getSerialNumber(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(flag.Args()) < 3 {
+		return fmt.Errorf("missing TSV file with tasks")
+	}
+
+	file := flag.Arg(2)
+	if stat, err := os.Stat(file); err != nil && os.IsNotExist(err) {
+		return fmt.Errorf("file '%s' does not exist", file)
+	} else if err != nil {
+		return err
+	} else if !stat.Mode().IsRegular() {
+		return fmt.Errorf("file '%s' is not a real file", file)
+	}
+
+	tasks, err := (&SetTaskList{}).parse(file)
+	if err != nil {
+		return err
+	}
+
+	list, err := getTaskList(ctx, serialNumber)
+	if err != nil {
+		return err
+	} else if list == nil {
+		return fmt.Errorf("no recorded task list for %v", serialNumber)
+	}
+
+	if !list.Complete {
+		fmt.Fprintf(os.Stderr, "   WARN  %v task list was not set with set-task-list and may include unrecorded tasks\n", serialNumber)
+	}
+
+	added, removed := c.diff(list.Tasks, tasks)
+	if len(added) == 0 && len(removed) == 0 {
+		fmt.Printf("   ... %v  task list matches '%v'\n", serialNumber, file)
+		return nil
+	}
+
+	for _, t := range added {
+		fmt.Printf("   + %v\n", t)
+	}
+
+	for _, t := range removed {
+		fmt.Printf("   - %v\n", t)
+	}
+
+	return fmt.Errorf("%w (%v: %v added, %v removed)", ErrDrift, serialNumber, len(added), len(removed))
+}
+
+// Compares the recorded task list with a list of tasks, returning the tasks that are only in
+// the list (added) and the tasks that are only in the recorded task list (removed). The order
+// of the tasks is ignored.
+func (c *DiffTaskList) diff(recorded, tasks []types.Task) ([]string, []string) {
+	remaining := []string{}
+	for _, t := range recorded {
+		remaining = append(remaining, formatTask(t))
+	}
+
+	added := []string{}
+	for _, t := range tasks {
+		s := formatTask(t)
+		if ix := slices.Index(remaining, s); ix >= 0 {
+			remaining = slices.Delete(remaining, ix, ix+1)
+		} else {
+			added = append(added, s)
+		}
+	}
+
+	return added, remaining
+}
+
+func (c *DiffTaskList) CLI() string {
+	return "diff-task-list"
+}
+
+func (c *DiffTaskList) Description() string {
+	return "Compares the last known task list for a controller with a TSV file"
+}
+
+func (c *DiffTaskList) Usage() string {
+	return "<serial number> <file>"
+}
+
+func (c *DiffTaskList) Help() {
+	fmt.Println("Usage: uhppote-cli [options] diff-task-list <serial number> <file>")
+	fmt.Println()
+	fmt.Println(" Compares the last known task list for a controller (see get-task-list) with the tasks in a TSV (or .schedule)")
+	fmt.Println(" file in the format used by set-task-list. Tasks that are only in the file are listed with a '+' and tasks that")
+	fmt.Println(" are only in the recorded task list with a '-'. The order of the tasks is ignored.")
+	fmt.Println()
+	fmt.Println(" Exits with status 2 if the task list differs from the file.")
+	fmt.Println()
+	fmt.Println("  serial number  (required) controller serial number")
+	fmt.Println("  file           (required) TSV or .schedule file with list of tasks")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --config  File path for the 'conf' file containing the controller configuration")
+	fmt.Printf("              (defaults to %s)\n", config.DefaultConfig)
+	fmt.Println("    --debug   Displays internal information for diagnosing errors")
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println()
+	fmt.Println("    uhppote-cli diff-task-list 9876543210 tasks.tsv")
+	fmt.Println()
+}
+
+// Returns false - configuration is useful but optional.
+func (c *DiffTaskList) RequiresConfig() bool {
+	return false
+}
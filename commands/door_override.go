@@ -0,0 +1,279 @@
+package commands
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+var LockdownCmd = DoorOverride{
+	command:     "lockdown",
+	description: "Sets every door to 'normally closed'",
+	mode:        types.NormallyClosed,
+}
+
+var EvacuateCmd = DoorOverride{
+	command:     "evacuate",
+	description: "Sets every door to 'normally open'",
+	mode:        types.NormallyOpen,
+}
+
+// DoorOverride implements the 'lockdown' and 'evacuate' commands, which set every door on
+// every controller to 'normally closed' or 'normally open'. The current door control states are
+// recorded in a snapshot in the local state store before the override is applied, for
+// 'restore-doors'.
+type DoorOverride struct {
+	command     string
+	description string
+	mode        types.ControlState
+}
+
+// doorResult is the outcome of setting (or restoring) the control mode of a single door.
+type doorResult struct {
+	controller uint32
+	door       uint8
+	name       string
+	mode       types.ControlState
+	warning    string
+	err        error
+}
+
+func (c *DoorOverride) Execute(ctx Context) error {
+	flagset := newFlagSet()
+
+	if err := flagset.Parse(flag.Args()[1:]); err != nil {
+		return err
+	}
+
+	controllers, err := overrideControllers(ctx, flagset.Args())
+	if err != nil {
+		return err
+	}
+
+	names := map[doorID]string{}
+	for _, d := range getConfiguredDoors(ctx) {
+		names[doorID{d.controller, d.door}] = d.name
+	}
+
+	// ... controllers are updated concurrently so that an unreachable controller does not delay
+	//     the override for the other controllers
+	now := time.Now()
+	results := make([][]doorResult, len(controllers))
+	wg := sync.WaitGroup{}
+
+	for i, controller := range controllers {
+		wg.Go(func() {
+			results[i] = c.override(ctx, controller, names, now)
+		})
+	}
+
+	wg.Wait()
+
+	return reportDoors(c.command, slices.Concat(results...))
+}
+
+// Snapshots the door control states of a controller and then sets every door to the override
+// mode. A door whose control state cannot be retrieved is still overridden, with a warning that
+// it will not be restored by 'restore-doors'.
+func (c *DoorOverride) override(ctx Context, controller uint32, names map[doorID]string, now time.Time) []doorResult {
+	results := []doorResult{}
+	doors := []uint8{1, 2, 3, 4}
+
+	snapshot, err := getDoorSnapshot(ctx, controller)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "   WARN  error retrieving door snapshot for %v (%v)\n", controller, err)
+	}
+
+	if snapshot == nil {
+		snapshot = &doorSnapshot{
+			Controller: controller,
+			Doors:      map[uint8]snapshotDoor{},
+		}
+	}
+
+	snapshot.Override = c.command
+	snapshot.Timestamp = now
+
+	// ... snapshot
+	warnings := map[uint8]string{}
+	for _, door := range doors {
+		if _, ok := snapshot.Doors[door]; ok {
+			continue
+		}
+
+		// ... a temporarily unlocked door is restored to the mode it had before the unlock
+		if revert, err := getPendingRevert(ctx, controller, door); err != nil {
+			fmt.Fprintf(os.Stderr, "   WARN  error retrieving pending revert for %v door %v (%v)\n", controller, door, err)
+		} else if revert != nil {
+			snapshot.Doors[door] = snapshotDoor{Name: names[doorID{controller, door}], Mode: revert.Mode, Delay: revert.Delay}
+			continue
+		}
+
+		if state, err := ctx.uhppote.GetDoorControlState(controller, door); err != nil {
+			warnings[door] = fmt.Sprintf("no snapshot (%v)", err)
+		} else if state == nil {
+			warnings[door] = "no snapshot"
+		} else {
+			snapshot.Doors[door] = snapshotDoor{Name: names[doorID{controller, door}], Mode: state.ControlState, Delay: state.Delay}
+		}
+	}
+
+	if err := saveDoorSnapshot(ctx, *snapshot); err != nil {
+		fmt.Fprintf(os.Stderr, "   WARN  error recording door snapshot for %v (%v)\n", controller, err)
+	}
+
+	// ... override
+	for _, door := range doors {
+		result := doorResult{
+			controller: controller,
+			door:       door,
+			name:       names[doorID{controller, door}],
+			mode:       c.mode,
+			warning:    warnings[door],
+		}
+
+		// ... cancels any pending 'unlock' revert, which would otherwise undo the override
+		if err := deletePendingRevert(ctx, controller, door); err != nil {
+			fmt.Fprintf(os.Stderr, "   WARN  error removing pending revert for %v door %v (%v)\n", controller, door, err)
+		}
+
+		delay := uint8(5)
+		if d, ok := snapshot.Doors[door]; ok {
+			delay = d.Delay
+		}
+
+		if state, err := ctx.uhppote.SetDoorControlState(controller, door, c.mode, delay); err != nil {
+			result.err = err
+		} else if state == nil || state.ControlState != c.mode {
+			result.err = fmt.Errorf("door control mode not set")
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func (c *DoorOverride) CLI() string {
+	return c.command
+}
+
+func (c *DoorOverride) Description() string {
+	return c.description
+}
+
+func (c *DoorOverride) Usage() string {
+	return "[serial number...]"
+}
+
+func (c *DoorOverride) Help() {
+	fmt.Printf("Usage: uhppote-cli [options] %v [serial number...]\n", c.command)
+	fmt.Println()
+	fmt.Printf(" Sets every door on every controller to '%v'. The controllers are updated concurrently and the current door\n", c.mode)
+	fmt.Println(" control modes are recorded in the local state store before the doors are updated, so that the doors can be")
+	fmt.Println(" restored with 'restore-doors'. Doors that could not be updated are reported on stderr and the command exits")
+	fmt.Println(" with an error.")
+	fmt.Println()
+	fmt.Println(" Any pending 'unlock' reverts are cancelled and the door is restored to the mode it had before the unlock.")
+	fmt.Println()
+	fmt.Println("  serial number  controller serial number (or name). Defaults to all the controllers in the configuration file")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --config  File path for the 'conf' file containing the controller configuration")
+	fmt.Printf("              (defaults to %s)\n", config.DefaultConfig)
+	fmt.Println("    --debug   Displays internal information for diagnosing errors")
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println()
+	fmt.Printf("    uhppote-cli %v\n", c.command)
+	fmt.Printf("    uhppote-cli %v 405419896 303986753\n", c.command)
+	fmt.Println()
+}
+
+// Returns false - configuration is useful but optional.
+func (c *DoorOverride) RequiresConfig() bool {
+	return false
+}
+
+// Returns the controllers in the command line arguments, defaulting to all the controllers in
+// the configuration file.
+func overrideControllers(ctx Context, args []string) ([]uint32, error) {
+	controllers := []uint32{}
+	for _, arg := range args {
+		if controller, err := parseSerialNumber(ctx, arg); err != nil {
+			return nil, err
+		} else {
+			controllers = append(controllers, controller)
+		}
+	}
+
+	if len(controllers) == 0 {
+		for _, device := range ctx.devices {
+			controllers = append(controllers, device.DeviceID)
+		}
+	}
+
+	if len(controllers) == 0 {
+		return nil, fmt.Errorf("no controllers (specify the controllers or define them in the configuration file)")
+	}
+
+	return controllers, nil
+}
+
+// Prints the door results and returns an error if any of the doors failed. The failures are
+// repeated on stderr so that they are not lost in the output.
+func reportDoors(command string, results []doorResult) error {
+	slices.SortFunc(results, func(p, q doorResult) int {
+		return cmp.Or(cmp.Compare(p.controller, q.controller), cmp.Compare(p.door, q.door))
+	})
+
+	table := [][]string{}
+	failed := []doorResult{}
+
+	for _, r := range results {
+		status := "ok"
+		if r.err != nil {
+			status = fmt.Sprintf("FAILED (%v)", r.err)
+			failed = append(failed, r)
+		} else if r.warning != "" {
+			status = fmt.Sprintf("ok    %v", r.warning)
+		}
+
+		name := r.name
+		if name == "" {
+			name = "-"
+		}
+
+		table = append(table, []string{
+			fmt.Sprintf("%v", r.controller),
+			fmt.Sprintf("%v", r.door),
+			name,
+			fmt.Sprintf("%v", r.mode),
+			status,
+		})
+	}
+
+	for _, line := range format(table) {
+		fmt.Printf("   %v\n", strings.TrimSpace(line))
+	}
+
+	if len(failed) > 0 {
+		fmt.Fprintln(os.Stderr)
+		for _, r := range failed {
+			fmt.Fprintf(os.Stderr, "   ERROR  %v door %v: %v\n", r.controller, r.door, r.err)
+		}
+
+		return fmt.Errorf("%v failed for %v of %v doors", command, len(failed), len(results))
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+func overrideDoors() map[doorID]types.DoorControlState {
+	doors := map[doorID]types.DoorControlState{}
+	for _, controller := range []uint32{405419896, 303986753} {
+		for _, door := range []uint8{1, 2, 3, 4} {
+			doors[doorID{controller, door}] = types.DoorControlState{
+				SerialNumber: types.SerialNumber(controller),
+				Door:         door,
+				ControlState: types.Controlled,
+				Delay:        door + 2,
+			}
+		}
+	}
+
+	doors[doorID{405419896, 3}] = types.DoorControlState{SerialNumber: 405419896, Door: 3, ControlState: types.NormallyClosed, Delay: 9}
+
+	return doors
+}
+
+func TestLockdownAndRestore(t *testing.T) {
+	s := doorControlStub{
+		doors: overrideDoors(),
+	}
+
+	ctx := Context{
+		uhppote: &s,
+		settings: Settings{
+			values: map[string]string{"state": t.TempDir()},
+		},
+	}
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	controllers := []uint32{405419896, 303986753}
+
+	// ... door 2 temporarily unlocked
+	s.set(types.DoorControlState{SerialNumber: 405419896, Door: 2, ControlState: types.NormallyOpen, Delay: 4})
+	savePendingRevert(ctx, pendingRevert{Controller: 405419896, Door: 2, Mode: types.Controlled, Delay: 4, Until: now.Add(time.Hour)})
+
+	for _, controller := range controllers {
+		for _, r := range LockdownCmd.override(ctx, controller, map[doorID]string{}, now) {
+			if r.err != nil || r.warning != "" {
+				t.Errorf("unexpected error/warning for %v door %v (%v, %v)", r.controller, r.door, r.err, r.warning)
+			}
+		}
+	}
+
+	for id, state := range s.snapshot() {
+		if state.ControlState != types.NormallyClosed {
+			t.Errorf("%v door %v not locked down (%v)", id.controller, id.door, state.ControlState)
+		}
+	}
+
+	if p, _ := getPendingRevert(ctx, 405419896, 2); p != nil {
+		t.Errorf("pending revert not cancelled")
+	}
+
+	// ... evacuate after lockdown must not overwrite the original snapshot
+	for _, controller := range controllers {
+		EvacuateCmd.override(ctx, controller, map[doorID]string{}, now.Add(time.Minute))
+	}
+
+	for id, state := range s.snapshot() {
+		if state.ControlState != types.NormallyOpen {
+			t.Errorf("%v door %v not evacuated (%v)", id.controller, id.door, state.ControlState)
+		}
+	}
+
+	if snapshot, err := getDoorSnapshot(ctx, 405419896); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if snapshot == nil || snapshot.Override != "evacuate" || len(snapshot.Doors) != 4 {
+		t.Fatalf("incorrect snapshot %+v", snapshot)
+	}
+
+	snapshots, err := getDoorSnapshots(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	c := RestoreDoors{}
+	for _, snapshot := range snapshots {
+		for _, r := range c.restore(ctx, snapshot) {
+			if r.err != nil {
+				t.Errorf("unexpected error restoring %v door %v (%v)", r.controller, r.door, r.err)
+			}
+		}
+	}
+
+	expected := overrideDoors()
+	for id, state := range s.snapshot() {
+		if state != expected[id] {
+			t.Errorf("%v door %v not restored - expected:%v, got:%v", id.controller, id.door, expected[id].ControlState, state.ControlState)
+		}
+	}
+
+	if snapshots, err := getDoorSnapshots(ctx); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if len(snapshots) != 0 {
+		t.Errorf("expected no snapshots after restore, got %v", snapshots)
+	}
+}
+
+func TestLockdownFailed(t *testing.T) {
+	s := doorControlStub{
+		doors:   overrideDoors(),
+		offline: []uint32{303986753},
+	}
+
+	ctx := Context{
+		uhppote: &s,
+		settings: Settings{
+			values: map[string]string{"state": t.TempDir()},
+		},
+	}
+
+	results := []doorResult{}
+	for _, controller := range []uint32{405419896, 303986753} {
+		results = append(results, LockdownCmd.override(ctx, controller, map[doorID]string{}, time.Now())...)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.controller == 303986753 && (r.err == nil || r.warning == "") {
+			t.Errorf("expected error and warning for offline controller door %v", r.door)
+		} else if r.controller == 405419896 && r.err != nil {
+			t.Errorf("unexpected error for %v door %v (%v)", r.controller, r.door, r.err)
+		}
+
+		if r.err != nil {
+			failed++
+		}
+	}
+
+	if failed != 4 {
+		t.Errorf("incorrect number of failed doors - expected:%v, got:%v", 4, failed)
+	}
+
+	if err := reportDoors("lockdown", results); err == nil {
+		t.Errorf("expected error")
+	}
+
+	for _, door := range []uint8{1, 2, 3, 4} {
+		if state := s.state(405419896, door); state.ControlState != types.NormallyClosed {
+			t.Errorf("405419896 door %v not locked down", door)
+		}
+	}
+
+	// ... a failed restore keeps the door in the snapshot
+	snapshot, _ := getDoorSnapshot(ctx, 405419896)
+	s.setOffline(405419896)
+
+	c := RestoreDoors{}
+	c.restore(ctx, *snapshot)
+
+	if snapshot, err := getDoorSnapshot(ctx, 405419896); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if snapshot == nil || len(snapshot.Doors) != 4 {
+		t.Errorf("failed restore removed doors from the snapshot %+v", snapshot)
+	}
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+// pendingRevert records the door control mode and delay to be restored when a temporarily
+// unlocked door is locked again. The record is written to the local state store before the
+// door is unlocked so that the door can still be restored if the unlocking process is killed.
+type pendingRevert struct {
+	Controller uint32             `json:"controller"`
+	Door       uint8              `json:"door"`
+	Name       string             `json:"name,omitempty"`
+	Mode       types.ControlState `json:"mode"`
+	Delay      uint8              `json:"delay"`
+	Until      time.Time          `json:"until"`
+}
+
+func (p pendingRevert) String() string {
+	if p.Name != "" {
+		return fmt.Sprintf("%v door %v (%v)", p.Controller, p.Door, p.Name)
+	}
+
+	return fmt.Sprintf("%v door %v", p.Controller, p.Door)
+}
+
+func pendingRevertFile(controller uint32, door uint8) string {
+	return filepath.Join("unlock", fmt.Sprintf("%v-%v.json", controller, door))
+}
+
+// Retrieves the pending revert record for a door. Returns nil if the door does not have a
+// pending revert.
+func getPendingRevert(ctx Context, controller uint32, door uint8) (*pendingRevert, error) {
+	var revert pendingRevert
+
+	if ok, err := loadState(ctx, pendingRevertFile(controller, door), &revert); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, nil
+	}
+
+	return &revert, nil
+}
+
+// Retrieves all the pending revert records, ordered by controller and door.
+func getPendingReverts(ctx Context) ([]pendingRevert, error) {
+	files, err := listState(ctx, "unlock")
+	if err != nil {
+		return nil, err
+	}
+
+	reverts := []pendingRevert{}
+	for _, file := range files {
+		var revert pendingRevert
+		if ok, err := loadState(ctx, file, &revert); err != nil {
+			return nil, err
+		} else if ok {
+			reverts = append(reverts, revert)
+		}
+	}
+
+	slices.SortFunc(reverts, func(p, q pendingRevert) int {
+		return cmp.Or(cmp.Compare(p.Controller, q.Controller), cmp.Compare(p.Door, q.Door))
+	})
+
+	return reverts, nil
+}
+
+func savePendingRevert(ctx Context, revert pendingRevert) error {
+	return saveState(ctx, pendingRevertFile(revert.Controller, revert.Door), revert)
+}
+
+func deletePendingRevert(ctx Context, controller uint32, door uint8) error {
+	return deleteState(ctx, pendingRevertFile(controller, door))
+}
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+// doorSnapshot records the door control modes and delays of a controller before a lockdown or
+// evacuation override, for 'restore-doors'. A snapshot is only ever extended (never overwritten)
+// by a subsequent override so that it always holds the door control states from before the
+// first override.
+type doorSnapshot struct {
+	Controller uint32                 `json:"controller"`
+	Override   string                 `json:"override"`
+	Timestamp  time.Time              `json:"timestamp"`
+	Doors      map[uint8]snapshotDoor `json:"doors"`
+}
+
+type snapshotDoor struct {
+	Name  string             `json:"name,omitempty"`
+	Mode  types.ControlState `json:"mode"`
+	Delay uint8              `json:"delay"`
+}
+
+func doorSnapshotFile(controller uint32) string {
+	return filepath.Join("override", fmt.Sprintf("%v.json", controller))
+}
+
+// Retrieves the door snapshot for a controller. Returns nil if the controller does not have a
+// snapshot.
+func getDoorSnapshot(ctx Context, controller uint32) (*doorSnapshot, error) {
+	var snapshot doorSnapshot
+
+	if ok, err := loadState(ctx, doorSnapshotFile(controller), &snapshot); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, nil
+	}
+
+	if snapshot.Doors == nil {
+		snapshot.Doors = map[uint8]snapshotDoor{}
+	}
+
+	return &snapshot, nil
+}
+
+// Retrieves all the door snapshots, ordered by controller.
+func getDoorSnapshots(ctx Context) ([]doorSnapshot, error) {
+	files, err := listState(ctx, "override")
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []doorSnapshot{}
+	for _, file := range files {
+		var snapshot doorSnapshot
+		if ok, err := loadState(ctx, file, &snapshot); err != nil {
+			return nil, err
+		} else if ok {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+
+	slices.SortFunc(snapshots, func(p, q doorSnapshot) int {
+		return cmp.Compare(p.Controller, q.Controller)
+	})
+
+	return snapshots, nil
+}
+
+func saveDoorSnapshot(ctx Context, snapshot doorSnapshot) error {
+	return saveState(ctx, doorSnapshotFile(snapshot.Controller), snapshot)
+}
+
+func deleteDoorSnapshot(ctx Context, controller uint32) error {
+	return deleteState(ctx, doorSnapshotFile(controller))
+}
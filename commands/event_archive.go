@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+// The event archive is a file of JSON encoded events, one event per line, appended to by
+// 'listen --archive'.
+
+// Converts the event in a status message to a controller event.
+func statusEvent(status types.Status) types.Event {
+	return types.Event{
+		SerialNumber: status.SerialNumber,
+		Index:        status.Event.Index,
+		Type:         status.Event.Type,
+		Granted:      status.Event.Granted,
+		Door:         status.Event.Door,
+		Direction:    status.Event.Direction,
+		CardNumber:   status.Event.CardNumber,
+		Timestamp:    status.Event.Timestamp,
+		Reason:       status.Event.Reason,
+	}
+}
+
+// Loads the events in an event archive. Blank lines are ignored.
+func loadArchive(file string) ([]types.Event, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	events := []types.Event{}
+	scanner := bufio.NewScanner(f)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var event types.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("%v: line %v: invalid event (%v)", file, line, err)
+		}
+
+		events = append(events, event)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Appends an event to an event archive.
+func archiveEvent(file string, event types.Event) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"fmt"
+)
+
+// Event types recorded by the controllers.
+const (
+	eventNone        = 0x00
+	eventSwipe       = 0x01
+	eventDoor        = 0x02
+	eventAlarm       = 0x03
+	eventOverwritten = 0xff
+)
+
+// Reader directions recorded by the controllers for a card swipe.
+const (
+	directionIn  = 1
+	directionOut = 2
+)
+
+// Event reason codes recorded by the controllers.
+const (
+	reasonSwipe                 = 1
+	reasonDenied                = 5
+	reasonNoAccessRights        = 6
+	reasonIncorrectPassword     = 7
+	reasonAntiPassback          = 8
+	reasonMoreCards             = 9
+	reasonFirstCardOpen         = 10
+	reasonNormallyClosed        = 11
+	reasonInterlock             = 12
+	reasonNotInAllowedTime      = 13
+	reasonInvalidTimezone       = 15
+	reasonAccessDenied          = 18
+	reasonPushButton            = 20
+	reasonDoorOpened            = 23
+	reasonDoorClosed            = 24
+	reasonSupervisorPassword    = 25
+	reasonPowerOn               = 28
+	reasonReset                 = 29
+	reasonPushButtonLocked      = 31
+	reasonPushButtonOffline     = 32
+	reasonPushButtonInterlock   = 33
+	reasonPushButtonThreat      = 34
+	reasonDoorOpenTooLong       = 37
+	reasonForcedOpen            = 38
+	reasonFire                  = 39
+	reasonForcedClosed          = 40
+	reasonTheftPrevention       = 41
+	reasonZone24x7              = 42
+	reasonEmergency             = 43
+	reasonRemoteOpen            = 44
+	reasonRemoteOpenUSB         = 45
+	reasonFirstCardOpenPassword = 46
+)
+
+var eventTypes = map[uint8]string{
+	eventNone:        "none",
+	eventSwipe:       "card swipe",
+	eventDoor:        "door",
+	eventAlarm:       "alarm",
+	eventOverwritten: "overwritten",
+}
+
+var eventReasons = map[uint8]string{
+	reasonSwipe:                 "swipe",
+	reasonDenied:                "swipe denied (system)",
+	reasonNoAccessRights:        "no access rights",
+	reasonIncorrectPassword:     "incorrect password",
+	reasonAntiPassback:          "anti-passback",
+	reasonMoreCards:             "more cards",
+	reasonFirstCardOpen:         "first card open",
+	reasonNormallyClosed:        "door is normally closed",
+	reasonInterlock:             "interlock",
+	reasonNotInAllowedTime:      "not in allowed time period",
+	reasonInvalidTimezone:       "invalid timezone",
+	reasonAccessDenied:          "access denied",
+	reasonPushButton:            "pushbutton ok",
+	reasonDoorOpened:            "door opened",
+	reasonDoorClosed:            "door closed",
+	reasonSupervisorPassword:    "door opened (supervisor password)",
+	reasonPowerOn:               "controller power on",
+	reasonReset:                 "controller reset",
+	reasonPushButtonLocked:      "pushbutton invalid (door locked)",
+	reasonPushButtonOffline:     "pushbutton invalid (offline)",
+	reasonPushButtonInterlock:   "pushbutton invalid (interlock)",
+	reasonPushButtonThreat:      "pushbutton invalid (threat)",
+	reasonDoorOpenTooLong:       "door open too long",
+	reasonForcedOpen:            "forced open",
+	reasonFire:                  "fire",
+	reasonForcedClosed:          "forced closed",
+	reasonTheftPrevention:       "theft prevention",
+	reasonZone24x7:              "24x7 zone",
+	reasonEmergency:             "emergency",
+	reasonRemoteOpen:            "remote open door",
+	reasonRemoteOpenUSB:         "remote open door (USB reader)",
+	reasonFirstCardOpenPassword: "first card open (password)",
+}
+
+// Longer explanations of the event reasons that are not self-explanatory.
+var eventReasonDetails = map[uint8]string{
+	reasonDenied:              "the controller denied access for a reason other than the card permissions",
+	reasonNoAccessRights:      "the card is not in the controller card list, is outside its valid dates or does not have access to the door",
+	reasonIncorrectPassword:   "the PIN entered on the reader keypad does not match the card PIN",
+	reasonAntiPassback:        "the card was last swiped at a reader on the same side of the anti-passback group",
+	reasonMoreCards:           "the door requires more than one card to be swiped",
+	reasonFirstCardOpen:       "the card has first card privilege and switched the door to the first card 'active' mode",
+	reasonNormallyClosed:      "the door control mode is 'normally closed' (see get-door-control and the task list)",
+	reasonInterlock:           "an interlocked door was open at the time of the swipe (see set-interlock)",
+	reasonNotInAllowedTime:    "the card time profile (and linked time profiles) do not include the time of the swipe",
+	reasonInvalidTimezone:     "the card time profile is not defined on the controller",
+	reasonAccessDenied:        "the door was in 'first card' mode and no card with first card privilege had been swiped",
+	reasonPushButtonLocked:    "the pushbutton was pressed while the door control mode was 'normally closed'",
+	reasonPushButtonInterlock: "the pushbutton was pressed while an interlocked door was open",
+	reasonDoorOpenTooLong:     "the door was held open for longer than the door open delay",
+	reasonForcedOpen:          "the door was opened without a valid swipe, pushbutton or remote open",
+	reasonRemoteOpen:          "the door was opened by an open-door request from the host",
+}
+
+// Returns the description of an event type.
+func eventType(code uint8) string {
+	if v, ok := eventTypes[code]; ok {
+		return v
+	}
+
+	return fmt.Sprintf("unknown (%v)", code)
+}
+
+// Returns the description of an event reason code.
+func eventReason(code uint8) string {
+	if v, ok := eventReasons[code]; ok {
+		return v
+	}
+
+	return fmt.Sprintf("unknown (%v)", code)
+}
@@ -0,0 +1,550 @@
+package commands
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+// eventHub fans out the events received by 'listen' to the connected SSE and WebSocket
+// clients and keeps a replay buffer of the most recent events for newly connected clients.
+type eventHub struct {
+	ctx     Context
+	token   string
+	origin  string
+	size    int
+	last    uint64
+	replay  []streamEvent
+	clients map[*streamClient]struct{}
+	sync.Mutex
+}
+
+// streamEvent is the JSON representation of an event sent to a client. The ID is assigned
+// by the hub and is used as the SSE event ID.
+type streamEvent struct {
+	ID uint64 `json:"id"`
+	statusRecord
+}
+
+// streamClient is a connected client. Events are dropped (and the client disconnected) if
+// the client falls too far behind rather than blocking the listener.
+type streamClient struct {
+	filter streamFilter
+	events chan streamEvent
+}
+
+// streamFilter restricts the events sent to a client to the listed controllers, doors
+// and cards. An empty list matches everything.
+type streamFilter struct {
+	controllers []uint32
+	doors       []doorID
+	cards       []uint32
+}
+
+const (
+	streamQueue     = 64
+	streamKeepalive = 30 * time.Second
+	websocketGUID   = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+func newEventHub(ctx Context, replay int, token, origin string) *eventHub {
+	return &eventHub{
+		ctx:     ctx,
+		token:   token,
+		origin:  origin,
+		size:    max(replay, 0),
+		replay:  []streamEvent{},
+		clients: map[*streamClient]struct{}{},
+	}
+}
+
+// Returns the HTTP handler for the event stream endpoints:
+//
+//	GET /events     Server-Sent Events
+//	GET /events/ws  WebSocket
+func (h *eventHub) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /events", h.sse)
+	mux.HandleFunc("GET /events/ws", h.websocket)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.origin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", h.origin)
+		}
+
+		// ... the 'token' query parameter (for a browser EventSource) is only accepted over HTTPS
+		//     because the URL is logged by proxies and browsers
+		if h.token != "" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok && r.TLS != nil {
+				token = r.URL.Query().Get("token")
+			}
+
+			if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(h.token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="uhppote-cli"`)
+				writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid bearer token"))
+				return
+			}
+		}
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// Adds an event to the replay buffer and queues it for the clients with a matching filter.
+func (h *eventHub) publish(status types.Status) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.last++
+
+	event := streamEvent{
+		ID:           h.last,
+		statusRecord: newStatusRecord(status),
+	}
+
+	if h.size > 0 {
+		h.replay = append(h.replay, event)
+		if len(h.replay) > h.size {
+			h.replay = slices.Delete(h.replay, 0, len(h.replay)-h.size)
+		}
+	}
+
+	for client := range h.clients {
+		if !client.filter.match(event) {
+			continue
+		}
+
+		select {
+		case client.events <- event:
+		default:
+			close(client.events)
+			delete(h.clients, client)
+		}
+	}
+}
+
+// Returns true if a WebSocket handshake is from an allowed origin i.e. the --allow-origin origin
+// ('*' allows any origin) or the same host as the request. Handshakes without an Origin header are
+// from non-browser clients and are allowed.
+func (h *eventHub) allowOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || (h.origin != "" && (h.origin == "*" || strings.EqualFold(origin, h.origin))) {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+
+	return err == nil && u.Host != "" && strings.EqualFold(u.Host, r.Host)
+}
+
+// Registers a client and returns the buffered events after 'since' that match the client
+// filter.
+func (h *eventHub) subscribe(filter streamFilter, since uint64) (*streamClient, []streamEvent) {
+	h.Lock()
+	defer h.Unlock()
+
+	client := streamClient{
+		filter: filter,
+		events: make(chan streamEvent, streamQueue),
+	}
+
+	replay := []streamEvent{}
+	for _, event := range h.replay {
+		if event.ID > since && filter.match(event) {
+			replay = append(replay, event)
+		}
+	}
+
+	h.clients[&client] = struct{}{}
+
+	return &client, replay
+}
+
+func (h *eventHub) unsubscribe(client *streamClient) {
+	h.Lock()
+	defer h.Unlock()
+
+	if _, ok := h.clients[client]; ok {
+		close(client.events)
+		delete(h.clients, client)
+	}
+}
+
+// Streams events as Server-Sent Events. A reconnecting EventSource sends the ID of the last
+// event received in the Last-Event-ID header and is only sent the missed events.
+func (h *eventHub) sse(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+
+	filter, err := parseStreamFilter(h.ctx, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	since, _ := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64)
+	client, replay := h.subscribe(filter, since)
+
+	defer h.unsubscribe(client)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event streamEvent) error {
+		if bytes, err := json.Marshal(event); err != nil {
+			return err
+		} else if _, err := fmt.Fprintf(w, "id: %v\nevent: status\ndata: %s\n\n", event.ID, bytes); err != nil {
+			return err
+		}
+
+		flusher.Flush()
+
+		return nil
+	}
+
+	for _, event := range replay {
+		if err := send(event); err != nil {
+			return
+		}
+	}
+
+	flusher.Flush()
+
+	keepalive := time.NewTicker(streamKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case event, ok := <-client.events:
+			if !ok {
+				return
+			} else if err := send(event); err != nil {
+				return
+			}
+
+		case <-keepalive.C:
+			if _, err := fmt.Fprintf(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+// Streams events as WebSocket text messages (one JSON encoded event per message). Messages
+// from the client are ignored other than 'ping' and 'close'.
+func (h *eventHub) websocket(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+
+	if !h.allowOrigin(r) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("WebSocket origin %v not allowed", r.Header.Get("Origin")))
+		return
+	} else if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") || key == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("not a WebSocket handshake"))
+		return
+	} else if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		writeError(w, http.StatusUpgradeRequired, fmt.Errorf("unsupported WebSocket version"))
+		return
+	}
+
+	filter, err := parseStreamFilter(h.ctx, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("WebSocket not supported"))
+		return
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+
+	defer conn.Close()
+
+	conn.SetDeadline(time.Time{})
+
+	hash := sha1.Sum([]byte(key + websocketGUID))
+	accept := base64.StdEncoding.EncodeToString(hash[:])
+
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\n")
+	fmt.Fprintf(rw, "Upgrade: websocket\r\n")
+	fmt.Fprintf(rw, "Connection: Upgrade\r\n")
+	fmt.Fprintf(rw, "Sec-WebSocket-Accept: %v\r\n\r\n", accept)
+
+	if err := rw.Flush(); err != nil {
+		return
+	}
+
+	ws := wsConn{conn: conn}
+	client, replay := h.subscribe(filter, 0)
+
+	defer h.unsubscribe(client)
+
+	send := func(event streamEvent) error {
+		if bytes, err := json.Marshal(event); err != nil {
+			return err
+		} else {
+			return ws.write(wsText, bytes)
+		}
+	}
+
+	for _, event := range replay {
+		if err := send(event); err != nil {
+			return
+		}
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+
+		for {
+			opcode, payload, err := readFrame(rw.Reader)
+			if err != nil {
+				return
+			}
+
+			switch opcode {
+			case wsClose:
+				ws.write(wsClose, payload)
+				return
+
+			case wsPing:
+				ws.write(wsPong, payload)
+			}
+		}
+	}()
+
+	keepalive := time.NewTicker(streamKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+
+		case event, ok := <-client.events:
+			if !ok {
+				ws.write(wsClose, closePayload(1008, "client too slow"))
+				return
+			} else if err := send(event); err != nil {
+				return
+			}
+
+		case <-keepalive.C:
+			if err := ws.write(wsPing, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Parses the controller, door and card query parameters. Each parameter may be repeated
+// or be a comma separated list. Controllers and doors may be given by name.
+func parseStreamFilter(ctx Context, r *http.Request) (streamFilter, error) {
+	filter := streamFilter{}
+	query := r.URL.Query()
+
+	values := func(key string) []string {
+		list := []string{}
+		for _, v := range query[key] {
+			for s := range strings.SplitSeq(v, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					list = append(list, s)
+				}
+			}
+		}
+
+		return list
+	}
+
+	for _, v := range values("controller") {
+		if controller, err := parseSerialNumber(ctx, v); err != nil {
+			return filter, err
+		} else {
+			filter.controllers = append(filter.controllers, controller)
+		}
+	}
+
+	for _, v := range values("door") {
+		if door, err := resolveDoor(ctx, v); err != nil {
+			return filter, err
+		} else {
+			filter.doors = append(filter.doors, doorID{door.controller, door.door})
+		}
+	}
+
+	for _, v := range values("card") {
+		if card, err := strconv.ParseUint(v, 10, 32); err != nil || card == 0 {
+			return filter, fmt.Errorf("invalid card number (%v)", v)
+		} else {
+			filter.cards = append(filter.cards, uint32(card))
+		}
+	}
+
+	return filter, nil
+}
+
+// Returns true if the event matches the filter. A status update without an event matches
+// a door filter for the door controller but never matches a card filter.
+func (f streamFilter) match(event streamEvent) bool {
+	if len(f.controllers) > 0 && !slices.Contains(f.controllers, event.Controller) {
+		return false
+	}
+
+	if len(f.doors) > 0 {
+		if !slices.ContainsFunc(f.doors, func(d doorID) bool {
+			return d.controller == event.Controller && (event.Event == nil || d.door == event.Event.Door)
+		}) {
+			return false
+		}
+	}
+
+	if len(f.cards) > 0 {
+		if event.Event == nil || !slices.Contains(f.cards, event.Event.Card) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func headerContains(header http.Header, key, token string) bool {
+	for _, v := range header.Values(key) {
+		for s := range strings.SplitSeq(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(s), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// wsConn serializes the frames written to a WebSocket connection.
+type wsConn struct {
+	conn net.Conn
+	sync.Mutex
+}
+
+const (
+	wsText  = 0x01
+	wsClose = 0x08
+	wsPing  = 0x09
+	wsPong  = 0x0a
+)
+
+// Writes a single unmasked (server to client) frame.
+func (ws *wsConn) write(opcode byte, payload []byte) error {
+	ws.Lock()
+	defer ws.Unlock()
+
+	frame := []byte{0x80 | opcode}
+
+	switch N := len(payload); {
+	case N < 126:
+		frame = append(frame, byte(N))
+	case N <= 0xffff:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(N))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(N))
+	}
+
+	frame = append(frame, payload...)
+
+	ws.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := ws.conn.Write(frame)
+
+	return err
+}
+
+// Reads a single WebSocket frame, unmasking the payload if required. Control frames are
+// limited to 125 bytes and data frames to 64kB, which is ample for the events stream.
+func readFrame(r *bufio.Reader) (byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+
+	opcode := header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+	N := uint64(header[1] & 0x7f)
+
+	switch N {
+	case 126:
+		b := make([]byte, 2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return 0, nil, err
+		}
+		N = uint64(binary.BigEndian.Uint16(b))
+
+	case 127:
+		b := make([]byte, 8)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return 0, nil, err
+		}
+		N = binary.BigEndian.Uint64(b)
+	}
+
+	if (opcode >= wsClose && N > 125) || N > 65536 {
+		return 0, nil, fmt.Errorf("WebSocket frame too large (%v bytes)", N)
+	}
+
+	mask := make([]byte, 4)
+	if masked {
+		if _, err := io.ReadFull(r, mask); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	payload := make([]byte, N)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return opcode, payload, nil
+}
+
+func closePayload(code uint16, reason string) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, code), reason...)
+}
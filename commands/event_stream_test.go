@@ -0,0 +1,355 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+func streamStatus(controller uint32, index uint32, door uint8, card uint32) types.Status {
+	return types.Status{
+		SerialNumber:   types.SerialNumber(controller),
+		DoorState:      map[uint8]bool{},
+		DoorButton:     map[uint8]bool{},
+		SystemDateTime: types.DateTime(time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)),
+		Event: types.StatusEvent{
+			Index:      index,
+			Type:       eventSwipe,
+			Granted:    true,
+			Door:       door,
+			CardNumber: card,
+			Reason:     reasonSwipe,
+		},
+	}
+}
+
+// Reads the 'data' of the next N SSE events.
+func readSSE(t *testing.T, r *bufio.Reader, N int) []streamEvent {
+	events := []streamEvent{}
+
+	for len(events) < N {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("error reading event stream (%v)", err)
+		}
+
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			var event streamEvent
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				t.Fatalf("invalid event %q (%v)", data, err)
+			}
+
+			events = append(events, event)
+		}
+	}
+
+	return events
+}
+
+func indices(events []streamEvent) []uint32 {
+	list := []uint32{}
+	for _, e := range events {
+		list = append(list, e.Event.Index)
+	}
+
+	return list
+}
+
+func TestStreamFilter(t *testing.T) {
+	ctx := shellContext(t)
+
+	tests := []struct {
+		query    string
+		expected []uint32
+	}{
+		{"", []uint32{1, 2, 3, 4, 0}},
+		{"controller=Alpha", []uint32{1, 2, 0}},
+		{"controller=303986753", []uint32{3, 4}},
+		{"door=Great%20Hall", []uint32{1, 0}},
+		{"door=Great%20Hall,303986753:2", []uint32{1, 4, 0}},
+		{"card=10058400", []uint32{1, 3}},
+		{"card=10058400&card=10058401", []uint32{1, 2, 3}},
+		{"controller=Alpha&card=10058400", []uint32{1}},
+	}
+
+	events := []streamEvent{
+		{1, newStatusRecord(streamStatus(405419896, 1, 1, 10058400))},
+		{2, newStatusRecord(streamStatus(405419896, 2, 2, 10058401))},
+		{3, newStatusRecord(streamStatus(303986753, 3, 1, 10058400))},
+		{4, newStatusRecord(streamStatus(303986753, 4, 2, 10058402))},
+		{5, newStatusRecord(streamStatus(405419896, 0, 0, 0))},
+	}
+
+	for _, test := range tests {
+		filter, err := parseStreamFilter(ctx, httptest.NewRequest("GET", "/events?"+test.query, nil))
+		if err != nil {
+			t.Fatalf("%v: unexpected error (%v)", test.query, err)
+		}
+
+		matched := []streamEvent{}
+		for _, e := range events {
+			if filter.match(e) {
+				matched = append(matched, e)
+			}
+		}
+
+		list := []uint32{}
+		for _, e := range matched {
+			if e.Event != nil {
+				list = append(list, e.Event.Index)
+			} else {
+				list = append(list, 0)
+			}
+		}
+
+		if !reflect.DeepEqual(list, test.expected) {
+			t.Errorf("%v: incorrect events - expected:%v, got:%v", test.query, test.expected, list)
+		}
+	}
+
+	for _, query := range []string{"controller=Gamma", "door=Attic", "card=x"} {
+		if _, err := parseStreamFilter(ctx, httptest.NewRequest("GET", "/events?"+query, nil)); err == nil {
+			t.Errorf("%v: expected error", query)
+		}
+	}
+}
+
+func TestStreamReplay(t *testing.T) {
+	hub := newEventHub(shellContext(t), 3, "", "")
+
+	for i := uint32(1); i <= 5; i++ {
+		hub.publish(streamStatus(405419896, i, 1, 10058400))
+	}
+
+	_, replay := hub.subscribe(streamFilter{}, 0)
+	if expected := []uint32{3, 4, 5}; !reflect.DeepEqual(indices(replay), expected) {
+		t.Errorf("incorrect replay - expected:%v, got:%v", expected, indices(replay))
+	}
+
+	_, replay = hub.subscribe(streamFilter{}, 4)
+	if expected := []uint32{5}; !reflect.DeepEqual(indices(replay), expected) {
+		t.Errorf("incorrect replay after event 4 - expected:%v, got:%v", expected, indices(replay))
+	}
+}
+
+func TestStreamSlowClient(t *testing.T) {
+	hub := newEventHub(shellContext(t), 0, "", "")
+	client, _ := hub.subscribe(streamFilter{}, 0)
+
+	for i := uint32(1); i <= streamQueue+1; i++ {
+		hub.publish(streamStatus(405419896, i, 1, 10058400))
+	}
+
+	N := 0
+	for range client.events {
+		N++
+	}
+
+	if N != streamQueue || len(hub.clients) != 0 {
+		t.Errorf("slow client not disconnected (%v events, %v clients)", N, len(hub.clients))
+	}
+}
+
+func TestStreamSSE(t *testing.T) {
+	hub := newEventHub(shellContext(t), 10, "qwerty", "")
+	hub.publish(streamStatus(405419896, 1, 1, 10058400))
+	hub.publish(streamStatus(405419896, 2, 2, 10058401))
+
+	server := httptest.NewTLSServer(hub.handler())
+	defer server.Close()
+
+	client := server.Client()
+
+	if response, err := client.Get(server.URL + "/events"); err != nil {
+		t.Fatalf("%v", err)
+	} else if response.Body.Close(); response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("incorrect status - expected:%v, got:%v", http.StatusUnauthorized, response.StatusCode)
+	}
+
+	response, err := client.Get(server.URL + "/events?token=qwerty&door=Great%20Hall")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	defer response.Body.Close()
+
+	if ct := response.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("incorrect content type %q", ct)
+	}
+
+	r := bufio.NewReader(response.Body)
+
+	// ... replayed events
+	if events := readSSE(t, r, 1); events[0].ID != 1 || events[0].Event.Index != 1 {
+		t.Errorf("incorrect replayed event %+v", events[0])
+	}
+
+	// ... live events
+	hub.publish(streamStatus(405419896, 3, 2, 10058400))
+	hub.publish(streamStatus(405419896, 4, 1, 10058401))
+
+	if events := readSSE(t, r, 1); events[0].ID != 4 || events[0].Event.Index != 4 || events[0].Event.Card != 10058401 {
+		t.Errorf("incorrect live event %+v", events[0])
+	}
+}
+
+func TestStreamQueryTokenOverHTTP(t *testing.T) {
+	hub := newEventHub(shellContext(t), 10, "qwerty", "")
+
+	server := httptest.NewServer(hub.handler())
+	defer server.Close()
+
+	if response, err := http.Get(server.URL + "/events?token=qwerty"); err != nil {
+		t.Fatalf("%v", err)
+	} else if response.Body.Close(); response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("query token accepted over plain HTTP - expected:%v, got:%v", http.StatusUnauthorized, response.StatusCode)
+	}
+
+	rq, _ := http.NewRequest(http.MethodGet, server.URL+"/events", nil)
+	rq.Header.Set("Authorization", "Bearer qwerty")
+
+	if response, err := http.DefaultClient.Do(rq); err != nil {
+		t.Fatalf("%v", err)
+	} else if response.Body.Close(); response.StatusCode != http.StatusOK {
+		t.Errorf("bearer token rejected - expected:%v, got:%v", http.StatusOK, response.StatusCode)
+	}
+}
+
+func TestStreamAddress(t *testing.T) {
+	tests := map[string]string{
+		":8081":             "127.0.0.1:8081",
+		"127.0.0.1:8081":    "127.0.0.1:8081",
+		"0.0.0.0:8081":      "0.0.0.0:8081",
+		"192.168.1.100:443": "192.168.1.100:443",
+		"[::]:8081":         "[::]:8081",
+	}
+
+	for address, expected := range tests {
+		if bind := streamAddress(address); bind != expected {
+			t.Errorf("%v: incorrect bind address - expected:%v, got:%v", address, expected, bind)
+		}
+	}
+}
+
+func TestStreamWebSocketOrigin(t *testing.T) {
+	tests := []struct {
+		allow   string
+		origin  string
+		allowed bool
+	}{
+		{"", "", true},
+		{"", "http://uhppote.local:8081", true},
+		{"", "https://evil.example.com", false},
+		{"https://example.com", "https://example.com", true},
+		{"https://example.com", "https://evil.example.com", false},
+		{"*", "https://evil.example.com", true},
+		{"", "null", false},
+	}
+
+	for _, test := range tests {
+		hub := newEventHub(shellContext(t), 10, "", test.allow)
+		r := httptest.NewRequest("GET", "http://uhppote.local:8081/events/ws", nil)
+		if test.origin != "" {
+			r.Header.Set("Origin", test.origin)
+		}
+
+		if allowed := hub.allowOrigin(r); allowed != test.allowed {
+			t.Errorf("%q/%q: incorrect origin check - expected:%v, got:%v", test.allow, test.origin, test.allowed, allowed)
+		}
+	}
+
+	// ... cross-site handshake
+	hub := newEventHub(shellContext(t), 10, "", "https://example.com")
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "http://uhppote.local:8081/events/ws", nil)
+
+	r.Header.Set("Origin", "https://evil.example.com")
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	r.Header.Set("Sec-WebSocket-Version", "13")
+
+	if hub.handler().ServeHTTP(w, r); w.Code != http.StatusForbidden {
+		t.Errorf("incorrect status for cross-site handshake - expected:%v, got:%v", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestStreamWebSocket(t *testing.T) {
+	hub := newEventHub(shellContext(t), 10, "", "https://example.com")
+	hub.publish(streamStatus(405419896, 1, 1, 10058400))
+	hub.publish(streamStatus(303986753, 2, 1, 10058400))
+
+	server := httptest.NewServer(hub.handler())
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	fmt.Fprintf(conn, "GET /events/ws?controller=Beta HTTP/1.1\r\n")
+	fmt.Fprintf(conn, "Host: %v\r\n", strings.TrimPrefix(server.URL, "http://"))
+	fmt.Fprintf(conn, "Upgrade: websocket\r\n")
+	fmt.Fprintf(conn, "Connection: Upgrade\r\n")
+	fmt.Fprintf(conn, "Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n")
+	fmt.Fprintf(conn, "Sec-WebSocket-Version: 13\r\n\r\n")
+
+	r := bufio.NewReader(conn)
+	response, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("incorrect status - expected:%v, got:%v", http.StatusSwitchingProtocols, response.StatusCode)
+	} else if accept := response.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("incorrect Sec-WebSocket-Accept %q", accept)
+	}
+
+	next := func() streamEvent {
+		opcode, payload, err := readFrame(r)
+		if err != nil {
+			t.Fatalf("error reading frame (%v)", err)
+		} else if opcode != wsText {
+			t.Fatalf("incorrect opcode - expected:%v, got:%v", wsText, opcode)
+		}
+
+		var event streamEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			t.Fatalf("invalid event %q (%v)", payload, err)
+		}
+
+		return event
+	}
+
+	if event := next(); event.Controller != 303986753 || event.Event.Index != 2 {
+		t.Errorf("incorrect replayed event %+v", event)
+	}
+
+	hub.publish(streamStatus(405419896, 3, 1, 10058400))
+	hub.publish(streamStatus(303986753, 4, 2, 10058401))
+
+	if event := next(); event.Controller != 303986753 || event.Event.Index != 4 {
+		t.Errorf("incorrect live event %+v", event)
+	}
+
+	// ... masked client close frame
+	conn.Write([]byte{0x88, 0x82, 0x01, 0x02, 0x03, 0x04, 0x03 ^ 0x01, 0xe8 ^ 0x02})
+
+	if opcode, payload, err := readFrame(r); err != nil || opcode != wsClose || string(payload) != "\x03\xe8" {
+		t.Errorf("invalid close frame (%v %v %v)", opcode, payload, err)
+	}
+}
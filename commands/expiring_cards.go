@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+var ExpiringCardsCmd = ExpiringCards{
+	within: 30,
+}
+
+type ExpiringCards struct {
+	within int
+}
+
+type expiringCard struct {
+	controller uint32
+	card       types.Card
+}
+
+func (c *ExpiringCards) Execute(ctx Context) error {
+	if ctx.config == nil {
+		return errors.New("expiring-cards requires a valid configuration file")
+	}
+
+	if err := c.parseArgs(); err != nil {
+		return err
+	}
+
+	cardholders := getCardholders(ctx)
+	list := []expiringCard{}
+	errs := []error{}
+
+	for _, device := range ctx.devices {
+		cards, err := getCards(ctx, device.DeviceID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %v", device.DeviceID, err))
+			continue
+		}
+
+		list = append(list, c.expiring(cards, device.DeviceID, today(device))...)
+	}
+
+	slices.SortStableFunc(list, func(p, q expiringCard) int {
+		switch {
+		case p.card.To.Before(q.card.To):
+			return -1
+		case p.card.To.After(q.card.To):
+			return +1
+		case p.card.CardNumber != q.card.CardNumber:
+			return int(int64(p.card.CardNumber) - int64(q.card.CardNumber))
+		default:
+			return int(int64(p.controller) - int64(q.controller))
+		}
+	})
+
+	if len(list) == 0 {
+		fmt.Printf("   ... no cards expiring within %v days\n", c.within)
+	} else {
+		printCards(list, cardholders)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Prints a table of cards (controller, card number, from, to and cardholder) for expiring-cards
+// and purge-expired.
+func printCards(list []expiringCard, cardholders cardholders) {
+	table := [][]string{}
+	for _, v := range list {
+		table = append(table, []string{
+			fmt.Sprintf("%v", v.controller),
+			fmt.Sprintf("%v", v.card.CardNumber),
+			fmt.Sprintf("%v", v.card.From),
+			fmt.Sprintf("%v", v.card.To),
+			cardholders.annotate(v.card.CardNumber),
+		})
+	}
+
+	fmt.Println()
+	for _, row := range format(table) {
+		fmt.Printf("   %v\n", row)
+	}
+	fmt.Println()
+}
+
+// Returns the cards with a 'To' date in the interval [today, today + within days].
+func (c *ExpiringCards) expiring(cards []types.Card, controller uint32, today types.Date) []expiringCard {
+	list := []expiringCard{}
+	end := types.Date(time.Time(today).AddDate(0, 0, c.within))
+
+	for _, card := range cards {
+		if !card.To.IsZero() && !card.To.Before(today) && !card.To.After(end) {
+			list = append(list, expiringCard{
+				controller: controller,
+				card:       card,
+			})
+		}
+	}
+
+	return list
+}
+
+func (c *ExpiringCards) parseArgs() error {
+	flagset := newFlagSet()
+	within := flagset.String("within", "30d", "Period from today within which cards expire")
+	args := flag.Args()[1:]
+
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+
+	if v, err := parsePeriod(*within); err != nil {
+		return err
+	} else {
+		c.within = v
+	}
+
+	return nil
+}
+
+func (c *ExpiringCards) CLI() string {
+	return "expiring-cards"
+}
+
+func (c *ExpiringCards) Description() string {
+	return "Lists the cards that expire within a period across all configured controllers"
+}
+
+func (c *ExpiringCards) Usage() string {
+	return "[--within <period>]"
+}
+
+func (c *ExpiringCards) Help() {
+	fmt.Println("Usage: uhppote-cli [options] expiring-cards [--within <period>]")
+	fmt.Println()
+	fmt.Println(" Lists the cards on the controllers defined in the configuration file that have a 'To' date between today and the")
+	fmt.Println(" end of the period, ordered by expiry date. Cards are annotated with the cardholder name if the configuration")
+	fmt.Println(" defines a cardholder registry ('cli.cardholders')")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --config  File path for the 'conf' file containing the controller configuration")
+	fmt.Printf("              (defaults to %s)\n", config.DefaultConfig)
+	fmt.Println("    --debug   Displays internal information for diagnosing errors")
+	fmt.Println()
+	fmt.Println("    --within  Period (in days or weeks e.g. 30d or 4w) within which cards expire. Defaults to 30d")
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println()
+	fmt.Println("    uhppote-cli expiring-cards")
+	fmt.Println("    uhppote-cli expiring-cards --within 2w")
+	fmt.Println()
+	fmt.Println("    > 405419896  10058400  2026-01-01  2026-10-31  Eduardo Ramos (Facilities)")
+	fmt.Println("      303986753  10058401  2026-01-01  2026-11-15")
+	fmt.Println()
+}
+
+// Returns true - configuration is not optional for this command to return valid information.
+func (c *ExpiringCards) RequiresConfig() bool {
+	return true
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+var expiryTestCards = []types.Card{
+	types.Card{CardNumber: 10058400, From: types.MustParseDate("2026-01-01"), To: types.MustParseDate("2026-10-18")},
+	types.Card{CardNumber: 10058401, From: types.MustParseDate("2026-01-01"), To: types.MustParseDate("2026-10-19")},
+	types.Card{CardNumber: 10058402, From: types.MustParseDate("2026-01-01"), To: types.MustParseDate("2026-11-18")},
+	types.Card{CardNumber: 10058403, From: types.MustParseDate("2026-01-01"), To: types.MustParseDate("2026-11-19")},
+	types.Card{CardNumber: 10058404, From: types.MustParseDate("2026-01-01")},
+}
+
+func TestExpiringCards(t *testing.T) {
+	cmd := ExpiringCards{
+		within: 30,
+	}
+
+	expected := []expiringCard{
+		expiringCard{controller: 405419896, card: expiryTestCards[1]},
+		expiringCard{controller: 405419896, card: expiryTestCards[2]},
+	}
+
+	list := cmd.expiring(expiryTestCards, 405419896, types.MustParseDate("2026-10-19"))
+
+	if !reflect.DeepEqual(list, expected) {
+		t.Errorf("incorrect expiring cards\n   expected:%v\n   got:     %v", expected, list)
+	}
+}
+
+func TestPurgeExpired(t *testing.T) {
+	cmd := PurgeExpired{}
+
+	expected := []expiringCard{
+		expiringCard{controller: 405419896, card: expiryTestCards[0]},
+	}
+
+	list := cmd.expired(expiryTestCards, 405419896, types.MustParseDate("2026-10-19"))
+
+	if !reflect.DeepEqual(list, expected) {
+		t.Errorf("incorrect expired cards\n   expected:%v\n   got:     %v", expected, list)
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	tests := map[string]int{
+		"30d": 30,
+		"30":  30,
+		"4w":  28,
+		"1W":  7,
+	}
+
+	for s, expected := range tests {
+		if days, err := parsePeriod(s); err != nil {
+			t.Errorf("%v: unexpected error (%v)", s, err)
+		} else if days != expected {
+			t.Errorf("%v: incorrect period - expected:%v, got:%v", s, expected, days)
+		}
+	}
+
+	if _, err := parsePeriod("30m"); err == nil {
+		t.Errorf("expected error for invalid period '30m'")
+	}
+}
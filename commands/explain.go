@@ -0,0 +1,619 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+var ExplainCmd = Explain{}
+
+type Explain struct {
+	cardholders cardholders
+	profiles    profileNames
+}
+
+// access holds the controller configuration that determines whether a card swipe at a door
+// is granted or denied.
+type access struct {
+	card         *types.Card
+	door         uint8
+	at           time.Time
+	profiles     map[uint8]types.TimeProfile
+	mode         types.ControlState
+	source       string
+	firstcard    *types.FirstCard
+	antipassback *types.AntiPassback
+	interlock    *types.Interlock
+	open         map[uint8]bool
+}
+
+// explanation is a single step in the evaluation of a card swipe. The result is one of 'ok',
+// 'no' (did not match but evaluation continues), 'DENIED' or 'note'.
+type explanation struct {
+	check  string
+	result string
+	detail string
+	reason uint8
+}
+
+func (c *Explain) Execute(ctx Context) error {
+	args := flag.Args()[1:]
+
+	c.cardholders = getCardholders(ctx)
+	c.profiles = getProfileNames(ctx)
+
+	if len(args) > 0 && clean(args[0]) == "event" {
+		return c.explainEvent(ctx, args[1:])
+	}
+
+	if len(args) < 1 {
+		return fmt.Errorf("missing card number")
+	} else if len(args) < 2 {
+		return fmt.Errorf("missing door")
+	}
+
+	cardNumber, err := resolveCardNumber(ctx, args[0], "invalid card number (%v)")
+	if err != nil {
+		return err
+	}
+
+	door, err := resolveDoor(ctx, args[1])
+	if err != nil {
+		return err
+	}
+
+	location := c.location(ctx, door.controller)
+	at := time.Now().In(location).Truncate(time.Minute)
+	live := true
+
+	if len(args) > 2 {
+		if clean(args[2]) != "at" || len(args) < 4 {
+			return fmt.Errorf("invalid date/time (expected 'at <datetime>')")
+		} else if at, err = c.parseDateTime(strings.Join(args[3:], " "), location); err != nil {
+			return err
+		} else {
+			live = false
+		}
+	}
+
+	a, err := c.gather(ctx, door, cardNumber, at, live)
+	if err != nil {
+		return err
+	}
+
+	c.print(door, cardNumber, a, explain(a, c.profiles), os.Stdout)
+
+	return nil
+}
+
+// Explains the reason code for a stored event and, for a card swipe, evaluates the swipe
+// against the current controller configuration.
+func (c *Explain) explainEvent(ctx Context, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("missing controller serial number")
+	} else if len(args) < 2 {
+		return fmt.Errorf("missing event index")
+	}
+
+	serialNumber, err := parseSerialNumber(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	index, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid event index (%v)", args[1])
+	}
+
+	event, err := ctx.uhppote.GetEvent(serialNumber, uint32(index))
+	if err != nil {
+		return err
+	} else if event == nil || event.Index != uint32(index) {
+		return fmt.Errorf("%v:  no event at index: %v", serialNumber, index)
+	}
+
+	granted := "denied"
+	if event.Granted {
+		granted = "granted"
+	}
+
+	fmt.Printf("   %v  event %v  %v\n", serialNumber, event.Index, event.Timestamp)
+	fmt.Println()
+
+	table := [][]string{
+		{"type", fmt.Sprintf("%v", eventType(event.Type))},
+		{"door", fmt.Sprintf("%v", event.Door)},
+		{"access", granted},
+		{"reason", fmt.Sprintf("%v: %v", event.Reason, eventReason(event.Reason))},
+	}
+
+	if event.Type == eventSwipe {
+		table = slices.Insert(table, 1, []string{"card", fmt.Sprintf("%v %v", event.CardNumber, c.cardholders.annotate(event.CardNumber))})
+	}
+
+	if detail, ok := eventReasonDetails[event.Reason]; ok {
+		table = append(table, []string{"", detail})
+	}
+
+	for _, line := range format(table) {
+		fmt.Printf("   %v\n", strings.TrimSpace(line))
+	}
+
+	if event.Type != eventSwipe || event.CardNumber == 0 || event.Door < 1 || event.Door > 4 {
+		return nil
+	}
+
+	door := configuredDoor{
+		controller: serialNumber,
+		door:       event.Door,
+	}
+
+	for _, d := range getConfiguredDoors(ctx) {
+		if d.controller == serialNumber && d.door == event.Door {
+			door = d
+		}
+	}
+
+	at := time.Time(event.Timestamp).In(c.location(ctx, serialNumber))
+
+	a, err := c.gather(ctx, door, event.CardNumber, at, false)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println("   ... evaluated against the current controller configuration")
+	fmt.Println()
+
+	c.print(door, event.CardNumber, a, explain(a, c.profiles), os.Stdout)
+
+	return nil
+}
+
+// Retrieves the card record, time profiles, door control mode, first card, anti-passback and
+// interlock configuration for a card swipe. The door control mode and door states are the
+// current values if live is true, otherwise the door control mode is evaluated from the
+// recorded task list (if any).
+func (c *Explain) gather(ctx Context, door configuredDoor, cardNumber uint32, at time.Time, live bool) (access, error) {
+	controller := door.controller
+
+	a := access{
+		door:     door.door,
+		at:       at,
+		profiles: map[uint8]types.TimeProfile{},
+	}
+
+	// ... card
+	if card, err := ctx.uhppote.GetCardByID(controller, cardNumber); err != nil {
+		return a, err
+	} else if card == nil {
+		return a, nil
+	} else {
+		a.card = card
+	}
+
+	// ... time profiles
+	for id := a.card.Doors[door.door]; id >= 2 && id <= 254; {
+		if _, ok := a.profiles[id]; ok {
+			break
+		} else if profile, err := ctx.uhppote.GetTimeProfile(controller, id); err != nil {
+			return a, err
+		} else if profile == nil {
+			break
+		} else {
+			a.profiles[id] = *profile
+			id = profile.LinkedProfileID
+		}
+	}
+
+	// ... door control mode
+	if state, err := ctx.uhppote.GetDoorControlState(controller, door.door); err != nil {
+		return a, err
+	} else if state != nil {
+		a.mode = state.ControlState
+		a.source = "current"
+	}
+
+	firstcards, err := getFirstCards(ctx, controller)
+	if err != nil {
+		return a, err
+	} else if fc, ok := firstcards[door.door]; ok {
+		a.firstcard = &fc
+	}
+
+	if !live {
+		if list, err := getTaskList(ctx, controller); err != nil {
+			return a, err
+		} else if list != nil {
+			midnight := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+			monday := midnight.AddDate(0, 0, -((int(at.Weekday()) + 6) % 7))
+			current := map[uint8]types.ControlState{door.door: a.mode}
+
+			for _, timeline := range simulateWeek(monday, list.Tasks, firstcards, current) {
+				if timeline.door == door.door {
+					a.mode = timeline.modeAt(at)
+					a.source = "scheduled"
+				}
+			}
+		}
+	}
+
+	// ... anti-passback
+	if antipassback, err := ctx.uhppote.GetAntiPassback(controller); err != nil {
+		fmt.Fprintf(os.Stderr, "   WARN  error retrieving anti-passback for %v (%v)\n", controller, err)
+	} else {
+		a.antipassback = &antipassback
+	}
+
+	// ... interlock
+	if interlock, err := getInterlock(ctx, controller); err != nil {
+		return a, err
+	} else {
+		a.interlock = interlock
+	}
+
+	if live && a.interlock != nil && len(interlocked(*a.interlock, door.door)) > 0 {
+		if status, err := ctx.uhppote.GetStatus(controller); err != nil {
+			return a, err
+		} else if status != nil {
+			a.open = status.DoorState
+		}
+	}
+
+	return a, nil
+}
+
+// Evaluates a card swipe in the same order as the controller i.e. card, door permission,
+// time profile, door control mode, interlock and anti-passback.
+func explain(a access, profiles profileNames) []explanation {
+	steps := []explanation{}
+	step := func(check, result string, reason uint8, format string, args ...any) {
+		steps = append(steps, explanation{
+			check:  check,
+			result: result,
+			detail: fmt.Sprintf(format, args...),
+			reason: reason,
+		})
+	}
+
+	date := types.ToDate(a.at.Year(), a.at.Month(), a.at.Day())
+	hhmm := types.HHmmFromTime(a.at)
+	weekday := a.at.Weekday()
+
+	// ... card
+	card := a.card
+	switch {
+	case card == nil:
+		step("card", "DENIED", reasonNoAccessRights, "not in the controller card list")
+		return steps
+
+	case card.From.IsZero() || card.To.IsZero():
+		step("card", "DENIED", reasonNoAccessRights, "invalid start or end date (%v to %v)", card.From, card.To)
+		return steps
+
+	case date.Before(card.From):
+		step("card", "DENIED", reasonNoAccessRights, "not valid until %v", card.From)
+		return steps
+
+	case date.After(card.To):
+		step("card", "DENIED", reasonNoAccessRights, "expired on %v", card.To)
+		return steps
+
+	default:
+		step("card", "ok", 0, "valid from %v to %v", card.From, card.To)
+	}
+
+	// ... door permission
+	permission := card.Doors[a.door]
+	switch {
+	case permission == 0:
+		step("permission", "DENIED", reasonNoAccessRights, "no access to door %v", a.door)
+		return steps
+
+	case permission == 1:
+		step("permission", "ok", 0, "unrestricted access to door %v", a.door)
+
+	case permission >= 2 && permission <= 254:
+		step("permission", "ok", 0, "time profile %v", profiles.describe(permission))
+
+		chain, _ := linkedChain(permission, a.profiles)
+		matched := false
+		seen := map[uint8]bool{}
+		for _, id := range chain {
+			if matched || seen[id] {
+				break
+			}
+
+			seen[id] = true
+			label := "time profile"
+			if id != permission {
+				label = "linked profile"
+			}
+
+			profile, ok := a.profiles[id]
+			if !ok {
+				step(label, "no", 0, "%v is not defined on the controller", id)
+				break
+			}
+
+			spec := formatTimeProfile(profile)
+			switch {
+			case profile.From.IsZero() || profile.To.IsZero() || date.Before(profile.From) || date.After(profile.To):
+				step(label, "no", 0, "%v  not valid on %v", spec, date)
+
+			case !profile.Weekdays[weekday]:
+				step(label, "no", 0, "%v  not enabled on %v", spec, weekday)
+
+			case !inSegment(profile, hhmm):
+				step(label, "no", 0, "%v  %v is not in any segment", spec, hhmm)
+
+			default:
+				step(label, "ok", 0, "%v  includes %v %v", spec, weekday, hhmm)
+				matched = true
+			}
+		}
+
+		if !matched {
+			reason := uint8(reasonNotInAllowedTime)
+			if _, ok := a.profiles[permission]; !ok {
+				reason = reasonInvalidTimezone
+			}
+
+			step("time profile", "DENIED", reason, "%v %v is not included in time profile %v", date, hhmm, profiles.describe(permission))
+			return steps
+		}
+
+	default:
+		step("permission", "DENIED", reasonNoAccessRights, "invalid permission (%v) for door %v", permission, a.door)
+		return steps
+	}
+
+	// ... door control mode
+	switch a.mode {
+	case types.ModeNormallyOpen:
+		step("door control", "ok", 0, "normally open (%v) - the door is unlocked", a.source)
+
+	case types.ModeNormallyClosed:
+		step("door control", "DENIED", reasonNormallyClosed, "normally closed (%v) - the door is locked for all cards", a.source)
+		return steps
+
+	case types.ModeControlled:
+		step("door control", "ok", 0, "controlled (%v)", a.source)
+
+	case types.ModeFirstCardOnly:
+		if hasFirstCard(card, a.door) {
+			step("door control", "ok", 0, "first card (%v) - card has first card privilege for door %v", a.source, a.door)
+		} else {
+			step("door control", "DENIED", reasonAccessDenied, "first card (%v) - locked until a card with first card privilege is swiped", a.source)
+			return steps
+		}
+
+	default:
+		step("door control", "note", 0, "unknown door control mode")
+	}
+
+	if fc := a.firstcard; fc != nil && fc.Weekdays[weekday] && !hhmm.Before(fc.StartTime) && !hhmm.After(fc.EndTime) {
+		privilege := "does not have"
+		if hasFirstCard(card, a.door) {
+			privilege = "has"
+		}
+
+		step("first card", "note", 0, "first card window %v-%v (card %v first card privilege)", fc.StartTime, fc.EndTime, privilege)
+	}
+
+	// ... interlock
+	switch {
+	case a.interlock == nil:
+		step("interlock", "note", 0, "interlock not recorded (set with set-interlock)")
+
+	case len(interlocked(*a.interlock, a.door)) == 0:
+		step("interlock", "ok", 0, "door %v is not interlocked", a.door)
+
+	default:
+		doors := interlocked(*a.interlock, a.door)
+		open := []uint8{}
+		for _, d := range doors {
+			if a.open[d] {
+				open = append(open, d)
+			}
+		}
+
+		switch {
+		case len(open) > 0:
+			step("interlock", "DENIED", reasonInterlock, "interlocked door %v is open", joinDoors(open))
+			return steps
+
+		case a.open != nil:
+			step("interlock", "ok", 0, "interlocked doors %v are closed", joinDoors(doors))
+
+		default:
+			step("interlock", "note", 0, "interlocked with door %v - denied if an interlocked door is open", joinDoors(doors))
+		}
+	}
+
+	// ... anti-passback
+	if a.antipassback != nil {
+		if *a.antipassback == types.Disabled {
+			step("anti-passback", "ok", 0, "disabled")
+		} else {
+			step("anti-passback", "note", 0, "%v - denied if the card was last swiped on the same side", *a.antipassback)
+		}
+	}
+
+	// ... PIN
+	if card.PIN != 0 {
+		step("PIN", "note", 0, "card has a PIN (required for readers with an activated keypad)")
+	}
+
+	return steps
+}
+
+// Returns the first denied step, if any.
+func verdict(steps []explanation) (explanation, bool) {
+	for _, s := range steps {
+		if s.result == "DENIED" {
+			return s, false
+		}
+	}
+
+	return explanation{}, true
+}
+
+// Returns true if the time is included in one of the time profile segments.
+func inSegment(profile types.TimeProfile, hhmm types.HHmm) bool {
+	for _, i := range []uint8{1, 2, 3} {
+		if segment, ok := profile.Segments[i]; ok && segment.Start.Before(segment.End) {
+			if !hhmm.Before(segment.Start) && !hhmm.After(segment.End) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Returns true if the card has first card privilege for the door.
+func hasFirstCard(card *types.Card, door uint8) bool {
+	privilege := false
+	card.FirstCard.ForEach(func(d uint8, enabled bool) {
+		if d == door && enabled {
+			privilege = true
+		}
+	})
+
+	return privilege
+}
+
+func joinDoors(doors []uint8) string {
+	list := []string{}
+	for _, d := range doors {
+		list = append(list, fmt.Sprintf("%v", d))
+	}
+
+	return strings.Join(list, ",")
+}
+
+func (c *Explain) print(door configuredDoor, cardNumber uint32, a access, steps []explanation, w io.Writer) {
+	card := fmt.Sprintf("%v", cardNumber)
+	if name := c.cardholders.annotate(cardNumber); name != "" {
+		card = fmt.Sprintf("%v (%v)", cardNumber, name)
+	}
+
+	name := fmt.Sprintf("%v", door.door)
+	if door.name != "" {
+		name = fmt.Sprintf("%v (%v)", door.door, door.name)
+	}
+
+	fmt.Fprintf(w, "   card %v  door %v  controller %v  at %v\n", card, name, door.controller, a.at.Format("2006-01-02 15:04"))
+	fmt.Fprintln(w)
+
+	table := [][]string{}
+	for _, s := range steps {
+		table = append(table, []string{s.check, s.result, s.detail})
+	}
+
+	for _, line := range format(table) {
+		fmt.Fprintf(w, "   %v\n", strings.TrimSpace(line))
+	}
+
+	fmt.Fprintln(w)
+	if denied, granted := verdict(steps); granted {
+		fmt.Fprintf(w, "   GRANTED\n")
+	} else {
+		fmt.Fprintf(w, "   DENIED  (event reason %v: %v)\n", denied.reason, eventReason(denied.reason))
+	}
+}
+
+// Returns the timezone for a controller.
+func (c *Explain) location(ctx Context, controller uint32) *time.Location {
+	for _, device := range ctx.devices {
+		if device.DeviceID == controller && device.TimeZone != nil {
+			return device.TimeZone
+		}
+	}
+
+	return time.Local
+}
+
+// Parses a date/time as YYYY-mm-dd HH:mm[:ss] or HH:mm (today).
+func (c *Explain) parseDateTime(s string, location *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, s, location); err == nil {
+			return t, nil
+		}
+	}
+
+	if t, err := time.ParseInLocation("15:04", s, location); err == nil {
+		now := time.Now().In(location)
+		return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, location), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date/time (%v)", s)
+}
+
+func (c *Explain) CLI() string {
+	return "explain"
+}
+
+func (c *Explain) Description() string {
+	return "Explains why a card swipe at a door is granted or denied"
+}
+
+func (c *Explain) Usage() string {
+	return "<card number|name> <door> [at <datetime>] | event <serial number> <index>"
+}
+
+func (c *Explain) Help() {
+	fmt.Println("Usage: uhppote-cli [options] explain <card number|name> <door> [at <datetime>]")
+	fmt.Println("       uhppote-cli [options] explain event <serial number> <index>")
+	fmt.Println()
+	fmt.Println(" Retrieves the card record and walks through the checks made by the controller for a card swipe at a door,")
+	fmt.Println(" displaying a step-by-step verdict:")
+	fmt.Println()
+	fmt.Println("   - card start and end dates")
+	fmt.Println("   - door permission and the time profile (and linked time profiles) for the door")
+	fmt.Println("   - door control mode and first card window")
+	fmt.Println("   - door interlock (as recorded by set-interlock)")
+	fmt.Println("   - anti-passback")
+	fmt.Println()
+	fmt.Println(" The door control mode is the current door control mode unless a date/time is specified, in which case it")
+	fmt.Println(" is evaluated from the task list recorded in the local state store (if any).")
+	fmt.Println()
+	fmt.Println(" 'explain event' retrieves the event at the index and explains the recorded reason code. For a card swipe,")
+	fmt.Println(" the swipe is also evaluated against the current controller configuration.")
+	fmt.Println()
+	fmt.Println("  card number    card number or cardholder name from the cardholder registry")
+	fmt.Println("  door           door name from the configuration file or <serial number>:<door> (e.g. 405419896:1)")
+	fmt.Println("  datetime       date and time of the swipe (YYYY-mm-dd HH:mm or HH:mm for today). Defaults to now")
+	fmt.Println("  serial number  controller serial number (or name)")
+	fmt.Println("  index          event index")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --config  File path for the 'conf' file containing the controller configuration")
+	fmt.Printf("              (defaults to %s)\n", config.DefaultConfig)
+	fmt.Println("    --debug   Displays internal information for diagnosing errors")
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println()
+	fmt.Println("    uhppote-cli explain 10058400 'Great Hall'")
+	fmt.Println("    uhppote-cli explain Alice 405419896:1 at 2026-10-24 09:30")
+	fmt.Println("    uhppote-cli explain event 405419896 17")
+	fmt.Println()
+}
+
+// Returns false - configuration is useful but optional.
+func (c *Explain) RequiresConfig() bool {
+	return false
+}
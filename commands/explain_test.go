@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+func TestExplain(t *testing.T) {
+	card := types.Card{
+		CardNumber: 10058400,
+		From:       types.MustParseDate("2026-01-01"),
+		To:         types.MustParseDate("2026-12-31"),
+		Doors:      map[uint8]uint8{1: 1, 2: 29, 3: 0, 4: 31},
+	}
+
+	profiles := map[uint8]types.TimeProfile{
+		29: {
+			ID:       29,
+			From:     types.MustParseDate("2026-01-01"),
+			To:       types.MustParseDate("2026-12-31"),
+			Weekdays: types.Weekdays{time.Monday: true, time.Tuesday: true, time.Wednesday: true, time.Thursday: true, time.Friday: true},
+			Segments: types.Segments{
+				1: types.Segment{Start: types.MustParseHHmm("08:00"), End: types.MustParseHHmm("12:00")},
+				2: types.Segment{Start: types.MustParseHHmm("13:00"), End: types.MustParseHHmm("17:00")},
+			},
+			LinkedProfileID: 30,
+		},
+		30: {
+			ID:       30,
+			From:     types.MustParseDate("2026-01-01"),
+			To:       types.MustParseDate("2026-12-31"),
+			Weekdays: types.Weekdays{time.Saturday: true},
+			Segments: types.Segments{
+				1: types.Segment{Start: types.MustParseHHmm("09:00"), End: types.MustParseHHmm("11:00")},
+			},
+		},
+	}
+
+	interlock := types.Interlock12
+	at := func(s string) time.Time {
+		t, _ := time.ParseInLocation("2006-01-02 15:04", s, time.Local)
+		return t
+	}
+
+	tests := []struct {
+		name    string
+		access  access
+		granted bool
+		reason  uint8
+	}{
+		{"no card", access{door: 1, at: at("2026-10-19 09:30"), mode: types.ModeControlled}, false, reasonNoAccessRights},
+		{"unrestricted", access{card: &card, door: 1, at: at("2026-10-19 09:30"), mode: types.ModeControlled}, true, 0},
+		{"expired", access{card: &card, door: 1, at: at("2027-01-01 09:30"), mode: types.ModeControlled}, false, reasonNoAccessRights},
+		{"no permission", access{card: &card, door: 3, at: at("2026-10-19 09:30"), mode: types.ModeControlled}, false, reasonNoAccessRights},
+		{"time profile", access{card: &card, door: 2, at: at("2026-10-19 09:30"), profiles: profiles, mode: types.ModeControlled}, true, 0},
+		{"outside segments", access{card: &card, door: 2, at: at("2026-10-19 12:30"), profiles: profiles, mode: types.ModeControlled}, false, reasonNotInAllowedTime},
+		{"linked profile", access{card: &card, door: 2, at: at("2026-10-24 10:00"), profiles: profiles, mode: types.ModeControlled}, true, 0},
+		{"linked profile weekday", access{card: &card, door: 2, at: at("2026-10-25 10:00"), profiles: profiles, mode: types.ModeControlled}, false, reasonNotInAllowedTime},
+		{"undefined profile", access{card: &card, door: 4, at: at("2026-10-19 09:30"), profiles: profiles, mode: types.ModeControlled}, false, reasonInvalidTimezone},
+		{"normally closed", access{card: &card, door: 1, at: at("2026-10-19 09:30"), mode: types.ModeNormallyClosed}, false, reasonNormallyClosed},
+		{"first card", access{card: &card, door: 1, at: at("2026-10-19 09:30"), mode: types.ModeFirstCardOnly}, false, reasonAccessDenied},
+		{"interlock open", access{card: &card, door: 1, at: at("2026-10-19 09:30"), mode: types.ModeControlled, interlock: &interlock, open: map[uint8]bool{2: true}}, false, reasonInterlock},
+		{"interlock closed", access{card: &card, door: 1, at: at("2026-10-19 09:30"), mode: types.ModeControlled, interlock: &interlock, open: map[uint8]bool{2: false}}, true, 0},
+	}
+
+	for _, test := range tests {
+		steps := explain(test.access, profileNames{})
+		denied, granted := verdict(steps)
+
+		if granted != test.granted {
+			t.Errorf("%v: incorrect verdict - expected:%v, got:%v (%v)", test.name, test.granted, granted, steps)
+		} else if !granted && denied.reason != test.reason {
+			t.Errorf("%v: incorrect reason - expected:%v, got:%v (%v)", test.name, test.reason, denied.reason, steps)
+		}
+	}
+}
+
+func TestInterlocked(t *testing.T) {
+	tests := []struct {
+		interlock types.Interlock
+		door      uint8
+		expected  string
+	}{
+		{types.NoInterlock, 1, ""},
+		{types.Interlock12, 2, "1"},
+		{types.Interlock12, 3, ""},
+		{types.Interlock12_34, 4, "3"},
+		{types.Interlock123, 1, "2,3"},
+		{types.Interlock1234, 3, "1,2,4"},
+	}
+
+	for _, test := range tests {
+		if doors := joinDoors(interlocked(test.interlock, test.door)); doors != test.expected {
+			t.Errorf("%v door %v: incorrect interlocked doors - expected:%v, got:%v", test.interlock, test.door, test.expected, doors)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+// The controller API does not support retrieving the first card configuration, so the first
+// card configuration set by set-firstcard is recorded in the local state store.
+func firstCardFile(controller uint32) string {
+	return filepath.Join("firstcard", fmt.Sprintf("%v.json", controller))
+}
+
+// Retrieves the recorded first card configuration for a controller, keyed by door.
+func getFirstCards(ctx Context, controller uint32) (map[uint8]types.FirstCard, error) {
+	firstcards := map[uint8]types.FirstCard{}
+
+	if _, err := loadState(ctx, firstCardFile(controller), &firstcards); err != nil {
+		return nil, err
+	}
+
+	return firstcards, nil
+}
+
+func recordFirstCard(ctx Context, controller uint32, door uint8, firstcard types.FirstCard) {
+	firstcards, err := getFirstCards(ctx, controller)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "   WARN  error retrieving recorded first card configuration for %v (%v)\n", controller, err)
+		firstcards = map[uint8]types.FirstCard{}
+	}
+
+	firstcards[door] = firstcard
+
+	if err := saveState(ctx, firstCardFile(controller), firstcards); err != nil {
+		fmt.Fprintf(os.Stderr, "   WARN  error recording first card configuration for %v (%v)\n", controller, err)
+	}
+}
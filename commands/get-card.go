@@ -12,6 +12,8 @@ import (
 var GetCardCmd = GetCard{}
 
 type GetCard struct {
+	cardholders cardholders
+	profiles    profileNames
 }
 
 func (c *GetCard) Execute(ctx Context) error {
@@ -20,11 +22,14 @@ func (c *GetCard) Execute(ctx Context) error {
 		return err
 	}
 
-	cardNumber, err := getUint32(2, "Missing card number", "Invalid card number: %v")
+	cardNumber, err := getCardNumber(ctx, 2, "Missing card number", "Invalid card number: %v")
 	if err != nil {
 		return err
 	}
 
+	c.cardholders = getCardholders(ctx)
+	c.profiles = getProfileNames(ctx)
+
 	record, err := ctx.uhppote.GetCardByID(serialNumber, cardNumber)
 	if err != nil {
 		return err
@@ -48,16 +53,16 @@ func (c *GetCard) Description() string {
 }
 
 func (c *GetCard) Usage() string {
-	return "<serial number> <card number>"
+	return "<serial number> <card number|name>"
 }
 
 func (c *GetCard) Help() {
-	fmt.Println("Usage: uhppote-cli [options] get-card <serial number> <card number>")
+	fmt.Println("Usage: uhppote-cli [options] get-card <serial number> <card number|name>")
 	fmt.Println()
 	fmt.Println(" Retrieves the access granted for the card number from  the controller card list")
 	fmt.Println()
 	fmt.Println("  serial-number  (required) controller serial number")
-	fmt.Println("  card-number    (required) card number")
+	fmt.Println("  card-number    (required) card number or cardholder name from the cardholder registry")
 	fmt.Println()
 	fmt.Println("  Examples:")
 	fmt.Println()
@@ -92,7 +97,7 @@ func (c GetCard) print(card types.Card, w io.Writer) error {
 			return "Y"
 
 		case p >= 2 && p <= 254:
-			return fmt.Sprintf("%v", p)
+			return c.profiles.label(p)
 
 		default:
 			return "N"
@@ -123,5 +128,9 @@ func (c GetCard) print(card types.Card, w io.Writer) error {
 
 	fmt.Fprintf(w, "%-8v %-10v %-10v %v %v %v", card.CardNumber, from, to, doors, PIN, firstcard)
 
+	if name := c.cardholders.annotate(card.CardNumber); name != "" {
+		fmt.Fprintf(w, " %v", name)
+	}
+
 	return nil
 }
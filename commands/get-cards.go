@@ -12,6 +12,8 @@ import (
 var GetCardsCmd = GetCards{}
 
 type GetCards struct {
+	cardholders cardholders
+	profiles    profileNames
 }
 
 func (c *GetCards) Execute(ctx Context) error {
@@ -20,31 +22,14 @@ func (c *GetCards) Execute(ctx Context) error {
 		return err
 	}
 
-	N, err := ctx.uhppote.GetCards(serialNumber)
-	if err != nil {
-		return err
-	}
-
-	recordset := []types.Card{}
-	index := uint32(1)
-	for count := uint32(0); count < N; {
-		record, err := ctx.uhppote.GetCardByIndex(serialNumber, index)
-		if err != nil {
-			c.print(recordset, os.Stdout)
-			return err
-		}
+	c.cardholders = getCardholders(ctx)
+	c.profiles = getProfileNames(ctx)
 
-		if record != nil {
-			recordset = append(recordset, *record)
-			count++
-		}
-
-		index++
-	}
+	recordset, err := getCards(ctx, serialNumber)
 
 	c.print(recordset, os.Stdout)
 
-	return nil
+	return err
 }
 
 func (c *GetCards) print(recordset []types.Card, w io.Writer) error {
@@ -73,7 +58,7 @@ func (c *GetCards) print(recordset []types.Card, w io.Writer) error {
 			return "Y"
 
 		case p >= 2 && p <= 254:
-			return fmt.Sprintf("%v", p)
+			return c.profiles.label(p)
 
 		default:
 			return "N"
@@ -96,9 +81,9 @@ func (c *GetCards) print(recordset []types.Card, w io.Writer) error {
 		}
 	}
 
-	table := [][9]any{}
+	table := [][10]any{}
 	for _, card := range recordset {
-		table = append(table, [9]any{
+		table = append(table, [10]any{
 			fmt.Sprintf("%-8v", card.CardNumber),
 			fmt.Sprintf("%-10v", from(card)),
 			fmt.Sprintf("%-10v", to(card)),
@@ -108,17 +93,18 @@ func (c *GetCards) print(recordset []types.Card, w io.Writer) error {
 			fmt.Sprintf("%v", door(card.Doors[4])),
 			fmt.Sprintf("%v", pin(card)),
 			fmt.Sprintf("%v", firstcard(card)),
+			c.cardholders.annotate(card.CardNumber),
 		})
 	}
 
-	width := [9]int{}
+	width := [10]int{}
 	for _, row := range table {
 		for ix, field := range row {
 			width[ix] = max(width[ix], len(field.(string)))
 		}
 	}
 
-	fields := [9]string{}
+	fields := [10]string{}
 	for ix, w := range width {
 		fields[ix] = fmt.Sprintf("%%-%vv", w)
 	}
@@ -149,6 +135,9 @@ func (c *GetCards) Help() {
 	fmt.Println()
 	fmt.Println(" Retrieves the number of cards in the controller card list")
 	fmt.Println()
+	fmt.Println(" Cards are annotated with the cardholder name and department if the configuration defines a cardholder")
+	fmt.Println(" registry ('cli.cardholders')")
+	fmt.Println()
 	fmt.Println("  serial-number  (required) controller serial number")
 	fmt.Println()
 	fmt.Println("  Examples:")
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/uhppoted/uhppote-core/uhppote"
@@ -73,19 +74,42 @@ func (c *GetACL) Execute(ctx Context) error {
 	}
 
 	fmt.Println()
-	fmt.Println(w.String())
+	fmt.Println(c.annotate(w.String(), getCardholders(ctx)))
 	fmt.Println()
 
 	return nil
 }
 
+// Appends the cardholder name to each line of the flat file ACL that starts with a card
+// number in the cardholder registry.
+func (c *GetACL) annotate(acl string, registry cardholders) string {
+	if len(registry) == 0 {
+		return acl
+	}
+
+	lines := strings.Split(acl, "\n")
+	for i, line := range lines {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			if card, err := strconv.ParseUint(fields[0], 10, 32); err == nil {
+				if name := registry.annotate(uint32(card)); name != "" {
+					lines[i] = fmt.Sprintf("%v  %v", strings.TrimRight(line, " "), name)
+				}
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
 func (c *GetACL) parseArgs() error {
-	flagset := flag.NewFlagSet("", flag.ExitOnError)
+	flagset := newFlagSet()
 	withPIN := flagset.Bool("with-pin", false, "Include card keypad PIN code in retrieved ACL information")
 	file := ""
 	args := flag.Args()[1:]
 
-	flagset.Parse(args)
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
 
 	// ... file
 	if len(flagset.Args()) > 0 {
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+
+	"github.com/uhppoted/uhppote-core/types"
 )
 
 var GetEventCmd = GetEvent{}
@@ -104,8 +106,14 @@ func (c *GetEvent) Execute(ctx Context) error {
 		}
 	}
 
+	cardholders := getCardholders(ctx)
+
 	for _, event := range events {
-		fmt.Printf("%v\n", event)
+		if e, ok := event.(*types.Event); ok && cardholders.annotate(e.CardNumber) != "" {
+			fmt.Printf("%v  %v\n", event, cardholders.annotate(e.CardNumber))
+		} else {
+			fmt.Printf("%v\n", event)
+		}
 	}
 
 	return nil
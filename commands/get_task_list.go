@@ -0,0 +1,178 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+var GetTaskListCmd = GetTaskList{}
+
+type GetTaskList struct {
+}
+
+func (c *GetTaskList) Execute(ctx Context) error {
+	serialNumber, err := getSerialNumber(ctx)
+	if err != nil {
+		return err
+	}
+
+	list, err := getTaskList(ctx, serialNumber)
+	if err != nil {
+		return err
+	} else if list == nil {
+		return fmt.Errorf("no recorded task list for %v", serialNumber)
+	}
+
+	if !list.Complete {
+		fmt.Fprintf(os.Stderr, "   WARN  %v task list was not set with set-task-list and may include unrecorded tasks\n", serialNumber)
+	}
+
+	if len(flag.Args()) > 2 {
+		file := flag.Arg(2)
+		if isScheduleFile(file) {
+			return c.exportSchedule(file, list.Tasks)
+		}
+
+		return c.export(file, list.Tasks)
+	}
+
+	c.print(list, os.Stdout)
+
+	return nil
+}
+
+func (c *GetTaskList) print(list *taskList, w io.Writer) {
+	fmt.Fprintf(w, "   ... %v  task list recorded %v\n", list.Controller, list.Updated.Format("2006-01-02 15:04:05"))
+
+	table := [][]string{}
+	for i, task := range list.Tasks {
+		cards := ""
+		if task.Task == types.EnableMoreCards {
+			cards = fmt.Sprintf("%d", task.Cards)
+		}
+
+		table = append(table, []string{
+			fmt.Sprintf("%v", i+1),
+			fmt.Sprintf("%v", task.Task),
+			fmt.Sprintf("%v", task.Door),
+			fmt.Sprintf("%v:%v", task.From, task.To),
+			fmt.Sprintf("%v", task.Weekdays),
+			fmt.Sprintf("%v", task.Start),
+			cards,
+		})
+	}
+
+	for _, row := range format(table) {
+		fmt.Fprintf(w, "   ... %s\n", row)
+	}
+}
+
+// Writes the task list to a TSV file in the format used by set-task-list.
+func (c *GetTaskList) export(file string, tasks []types.Task) error {
+	var b bytes.Buffer
+
+	f := func(v bool) string {
+		if v {
+			return "Y"
+		}
+
+		return "N"
+	}
+
+	w := csv.NewWriter(&b)
+	w.Comma = '\t'
+
+	if err := w.Write([]string{"Task", "Door", "From", "To", "Mon", "Tue", "Wed", "Thurs", "Fri", "Sat", "Sun", "Start", "Cards"}); err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		row := []string{
+			fmt.Sprintf("%v", int(task.Task)+1),
+			fmt.Sprintf("%v", task.Door),
+			fmt.Sprintf("%v", task.From),
+			fmt.Sprintf("%v", task.To),
+			f(task.Weekdays[time.Monday]),
+			f(task.Weekdays[time.Tuesday]),
+			f(task.Weekdays[time.Wednesday]),
+			f(task.Weekdays[time.Thursday]),
+			f(task.Weekdays[time.Friday]),
+			f(task.Weekdays[time.Saturday]),
+			f(task.Weekdays[time.Sunday]),
+			fmt.Sprintf("%v", task.Start),
+			fmt.Sprintf("%v", task.Cards),
+		}
+
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+
+	return os.WriteFile(file, b.Bytes(), 0660)
+}
+
+// Writes the task list to a schedule file.
+func (c *GetTaskList) exportSchedule(file string, tasks []types.Task) error {
+	var b bytes.Buffer
+
+	for _, task := range tasks {
+		fmt.Fprintf(&b, "%v\n", formatTask(task))
+	}
+
+	return os.WriteFile(file, b.Bytes(), 0660)
+}
+
+func (c *GetTaskList) CLI() string {
+	return "get-task-list"
+}
+
+func (c *GetTaskList) Description() string {
+	return "Retrieves the last known task list for a controller from the local state store"
+}
+
+func (c *GetTaskList) Usage() string {
+	return "<serial number> [file]"
+}
+
+func (c *GetTaskList) Help() {
+	fmt.Println("Usage: uhppote-cli [options] get-task-list <serial number> [file]")
+	fmt.Println()
+	fmt.Println(" Retrieves the last known task list for a controller and (optionally) writes it to a TSV file (or a schedule")
+	fmt.Println(" file if the file has a .schedule extension).")
+	fmt.Println()
+	fmt.Println(" The controller does not support retrieving the task list, so the tasks sent by set-task-list, add-task,")
+	fmt.Println(" clear-task-list and import-holidays are recorded in a local state store. The state store is located in the")
+	fmt.Println(" directory defined by the 'cli.state' setting in the configuration file (defaults to 'uhppote-cli' in the")
+	fmt.Println(" user configuration directory). A task list that was not set with set-task-list (or cleared with")
+	fmt.Println(" clear-task-list) may not include all the tasks on the controller.")
+	fmt.Println()
+	fmt.Println("  serial number  (required) controller serial number")
+	fmt.Println("  file           (optional) TSV or .schedule file for the task list")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --config  File path for the 'conf' file containing the controller configuration")
+	fmt.Printf("              (defaults to %s)\n", config.DefaultConfig)
+	fmt.Println("    --debug   Displays internal information for diagnosing errors")
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println()
+	fmt.Println("    uhppote-cli get-task-list 9876543210")
+	fmt.Println("    uhppote-cli get-task-list 9876543210 9876543210.schedule")
+	fmt.Println()
+}
+
+// Returns false - configuration is useful but optional.
+func (c *GetTaskList) RequiresConfig() bool {
+	return false
+}
@@ -17,20 +17,27 @@ func (c *GetTimeProfile) Execute(ctx Context) error {
 		return err
 	}
 
-	profileID, err := getUint8(2, "missing time profile ID", "invalid time profile ID: %v")
+	profiles := getProfileNames(ctx)
+
+	profileID, err := getString(2, "missing time profile ID", "invalid time profile ID: %v")
+	if err != nil {
+		return err
+	}
+
+	id, err := profiles.resolve(profileID)
 	if err != nil {
 		return err
-	} else if profileID < 2 || profileID > 254 {
-		return fmt.Errorf("invalid time profile ID (%v) - valid range is from 2 to 254", profileID)
 	}
 
-	profile, err := ctx.uhppote.GetTimeProfile(serialNumber, profileID)
+	profile, err := ctx.uhppote.GetTimeProfile(serialNumber, id)
 	if err != nil {
 		return err
 	}
 
 	if profile == nil {
-		fmt.Printf("%v %v NO ACTIVE TIME PROFILE\n", serialNumber, profileID)
+		fmt.Printf("%v %v NO ACTIVE TIME PROFILE\n", serialNumber, id)
+	} else if name := profiles.name(id); name != "" {
+		fmt.Printf("%-10d %v %v\n", serialNumber, profile, name)
 	} else {
 		fmt.Printf("%-10d %v\n", serialNumber, profile)
 	}
@@ -56,7 +63,7 @@ func (c *GetTimeProfile) Help() {
 	fmt.Println(" Retrieves the time profile associated with a profile ID")
 	fmt.Println()
 	fmt.Println("  serial number  (required) controller serial number")
-	fmt.Println("  profile ID     (required) time profile ID (2-254)")
+	fmt.Println("  profile ID     (required) time profile ID (2-254) or time profile name")
 	fmt.Println()
 	fmt.Println("  Options:")
 	fmt.Println()
@@ -18,8 +18,8 @@ var GetTimeProfilesCmd = GetTimeProfiles{
 -------------------------------------------
 TIME PROFILES {{.DeviceID}} {{.Timestamp}}
 -------------------------------------------
-Profile  From       To          Mon Tue Wed Thurs Fri Sat Sun  Start1 End1   Start2 End2   Start3 End3   Linked{{range $id,$row := .Profiles}}
-{{printf "%-7s" $row.ID}}  {{printf "%-10s" $row.From}} {{printf "%-10s" $row.To}}  {{$row.Monday}}   {{$row.Tuesday}}   {{$row.Wednesday}}   {{$row.Thursday}}     {{$row.Friday}}   {{$row.Saturday}}   {{$row.Sunday}}    {{printf "%-5s" $row.Start1}}  {{printf "%-5s" $row.End1}}  {{printf "%-5s" $row.Start2}}  {{printf "%-5s" $row.End2}}  {{printf "%-5s" $row.Start3}}  {{printf "%-5s" $row.End3}}  {{printf "%-3s" $row.Linked}}{{end}}
+Profile  From       To          Mon Tue Wed Thurs Fri Sat Sun  Start1 End1   Start2 End2   Start3 End3   Linked{{if .Named}}  Name{{end}}{{range $id,$row := .Profiles}}
+{{printf "%-7s" $row.ID}}  {{printf "%-10s" $row.From}} {{printf "%-10s" $row.To}}  {{$row.Monday}}   {{$row.Tuesday}}   {{$row.Wednesday}}   {{$row.Thursday}}     {{$row.Friday}}   {{$row.Saturday}}   {{$row.Sunday}}    {{printf "%-5s" $row.Start1}}  {{printf "%-5s" $row.End1}}  {{printf "%-5s" $row.Start2}}  {{printf "%-5s" $row.End2}}  {{printf "%-5s" $row.Start3}}  {{printf "%-5s" $row.End3}}  {{printf "%-3s" $row.Linked}}{{if $.Named}}     {{$row.Name}}{{end}}{{end}}
 `,
 }
 
@@ -49,11 +49,14 @@ func (c *GetTimeProfiles) Execute(ctx Context) error {
 		}
 	}
 
+	profiles := getProfileNames(ctx)
+	list := []types.TimeProfile{}
 	recordset := []map[string]string{}
 	for id := 2; id <= 254; id++ {
 		if profile, err := ctx.uhppote.GetTimeProfile(serialNumber, uint8(id)); err != nil {
 			return err
 		} else if profile != nil {
+			list = append(list, *profile)
 			row := map[string]string{
 				"ID":        fmt.Sprintf("%v", profile.ID),
 				"From":      fmt.Sprintf("%v", profile.From),
@@ -66,6 +69,7 @@ func (c *GetTimeProfiles) Execute(ctx Context) error {
 				"Saturday":  f(profile.Weekdays[time.Saturday]),
 				"Sunday":    f(profile.Weekdays[time.Sunday]),
 				"Linked":    g(profile.LinkedProfileID),
+				"Name":      profiles.name(profile.ID),
 			}
 
 			if segment, ok := profile.Segments[1]; ok {
@@ -89,24 +93,28 @@ func (c *GetTimeProfiles) Execute(ctx Context) error {
 
 	if file, err := c.getTSVFile(); err != nil {
 		return err
+	} else if file != "" && isScheduleFile(file) {
+		return c.exportSchedule(file, list, profiles)
 	} else if file != "" {
-		return c.export(file, recordset)
+		return c.export(file, recordset, len(profiles) > 0)
 	}
 
-	return c.print(serialNumber, recordset)
+	return c.print(serialNumber, recordset, len(profiles) > 0)
 }
 
-func (c *GetTimeProfiles) print(serialNumber uint32, recordset []map[string]string) error {
+func (c *GetTimeProfiles) print(serialNumber uint32, recordset []map[string]string, named bool) error {
 	timestamp := types.DateTime(time.Now())
 
 	rpt := struct {
 		DeviceID  uint32
 		Timestamp *types.DateTime
 		Profiles  []map[string]string
+		Named     bool
 	}{
 		DeviceID:  serialNumber,
 		Timestamp: &timestamp,
 		Profiles:  recordset,
+		Named:     named,
 	}
 
 	t, err := template.New("report").Parse(c.template)
@@ -117,7 +125,7 @@ func (c *GetTimeProfiles) print(serialNumber uint32, recordset []map[string]stri
 	return t.Execute(os.Stdout, rpt)
 }
 
-func (c *GetTimeProfiles) export(file string, recordset []map[string]string) error {
+func (c *GetTimeProfiles) export(file string, recordset []map[string]string, named bool) error {
 	var b bytes.Buffer
 
 	w := csv.NewWriter(&b)
@@ -125,6 +133,10 @@ func (c *GetTimeProfiles) export(file string, recordset []map[string]string) err
 
 	// TSV header
 	header := []string{"Profile", "From", "To", "Mon", "Tue", "Wed", "Thurs", "Fri", "Sat", "Sun", "Start1", "End1", "Start2", "End2", "Start3", "End3", "Linked"}
+	if named {
+		header = append(header, "Name")
+	}
+
 	if err := w.Write(header); err != nil {
 		return err
 	}
@@ -150,6 +162,10 @@ func (c *GetTimeProfiles) export(file string, recordset []map[string]string) err
 			r["Linked"],
 		}
 
+		if named {
+			row = append(row, r["Name"])
+		}
+
 		if err := w.Write(row); err != nil {
 			return err
 		}
@@ -160,6 +176,21 @@ func (c *GetTimeProfiles) export(file string, recordset []map[string]string) err
 	return os.WriteFile(file, b.Bytes(), 0660)
 }
 
+// Writes the time profiles to a schedule file, with the time profile names (if any) as comments.
+func (c *GetTimeProfiles) exportSchedule(file string, list []types.TimeProfile, profiles profileNames) error {
+	var b bytes.Buffer
+
+	for _, profile := range list {
+		if name := profiles.name(profile.ID); name != "" {
+			fmt.Fprintf(&b, "%v # %v\n", formatTimeProfile(profile), name)
+		} else {
+			fmt.Fprintf(&b, "%v\n", formatTimeProfile(profile))
+		}
+	}
+
+	return os.WriteFile(file, b.Bytes(), 0660)
+}
+
 func (c *GetTimeProfiles) getTSVFile() (string, error) {
 	if len(flag.Args()) < 3 {
 		return "", nil
@@ -203,8 +234,16 @@ func (c *GetTimeProfiles) Help() {
 	fmt.Println()
 	fmt.Println(" Retrieves all the defined time profiles from a controller and (optionally) writes them to a TSV file")
 	fmt.Println()
+	fmt.Println(" The time profiles are written as a schedule (one time profile per line) if the file has a .schedule")
+	fmt.Println(" extension e.g.:")
+	fmt.Println()
+	fmt.Println("   29: Mon-Fri 08:00-12:00,13:00-17:00 from 2026-01-01 to 2026-12-31 then 30")
+	fmt.Println()
+	fmt.Println(" The time profile names are included if the configuration defines time profile names ('cli.profile.<name>'")
+	fmt.Println(" or 'cli.profiles')")
+	fmt.Println()
 	fmt.Println("  serial number  (required) controller serial number")
-	fmt.Println("  file           (optional) TSV file for time profiles")
+	fmt.Println("  file           (optional) TSV or .schedule file for time profiles")
 	fmt.Println()
 	fmt.Println("  Options:")
 	fmt.Println()
@@ -215,6 +254,7 @@ func (c *GetTimeProfiles) Help() {
 	fmt.Println("  Examples:")
 	fmt.Println()
 	fmt.Println("    uhppote-cli get-time-profiles 9876543210 ./9876543210.tsv")
+	fmt.Println("    uhppote-cli get-time-profiles 9876543210 ./9876543210.schedule")
 	fmt.Println()
 }
 
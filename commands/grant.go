@@ -3,8 +3,8 @@ package commands
 import (
 	"flag"
 	"fmt"
+	"os"
 	"regexp"
-	"strconv"
 	"strings"
 
 	"github.com/uhppoted/uhppote-core/types"
@@ -22,7 +22,13 @@ func (c *Grant) Execute(ctx Context) error {
 		return fmt.Errorf("grant requires a valid configuration file")
 	}
 
-	cardNumber, err := getUint32(1, "missing card number", "invalid card number: %v")
+	if file, err := c.parseArgs(); err != nil {
+		return err
+	} else if file != "" {
+		return c.batch(ctx, file)
+	}
+
+	cardNumber, err := getCardNumber(ctx, 1, "missing card number", "invalid card number: %v")
 	if err != nil {
 		return err
 	}
@@ -40,13 +46,13 @@ func (c *Grant) Execute(ctx Context) error {
 	var re = regexp.MustCompile("[0-9]+")
 	var profileID = 0
 	var doors []string
+	var profiles = getProfileNames(ctx)
 
-	if len(flag.Args()) > 5 && re.MatchString(flag.Arg(4)) {
-		profileID, err = strconv.Atoi(flag.Arg(4))
-		if err != nil {
+	if _, named := profiles.lookup(flag.Arg(4)); len(flag.Args()) > 5 && (named || re.MatchString(flag.Arg(4))) {
+		if v, err := profiles.resolve(flag.Arg(4)); err != nil {
 			return err
-		} else if profileID < 2 || profileID > 254 {
-			return fmt.Errorf("invalid time profile ID (%v) - valid range is from 2 to 254", profileID)
+		} else {
+			profileID = int(v)
 		}
 
 		doors, err = c.getDoors(5)
@@ -57,6 +63,12 @@ func (c *Grant) Execute(ctx Context) error {
 		return err
 	}
 
+	if profileID != 0 {
+		if err := c.verify(ctx, uint8(profileID), doors, profiles); err != nil {
+			return err
+		}
+	}
+
 	err = acl.Grant(ctx.uhppote, ctx.devices, cardNumber, types.Date(*from), types.Date(*to), profileID, doors)
 	if err != nil {
 		return err
@@ -67,6 +79,46 @@ func (c *Grant) Execute(ctx Context) error {
 	return nil
 }
 
+// Verifies that the time profile is defined on the controllers for the doors.
+func (c *Grant) verify(ctx Context, profileID uint8, doors []string, profiles profileNames) error {
+	configured := getConfiguredDoors(ctx)
+	cache := map[uint32]map[uint8]bool{}
+
+	for _, d := range doors {
+		for _, door := range configured {
+			if clean(d) == "all" || clean(d) == clean(door.name) {
+				if err := profiles.verify(ctx.uhppote, door.controller, profileID, cache); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Returns the change file if invoked as 'grant --file <file>'.
+func (c *Grant) parseArgs() (string, error) {
+	flagset := newFlagSet()
+	file := flagset.String("file", "", "TSV file with the grant and revoke changes to apply")
+
+	if err := flagset.Parse(flag.Args()[1:]); err != nil {
+		return "", err
+	}
+
+	if *file != "" {
+		if stat, err := os.Stat(*file); err != nil && os.IsNotExist(err) {
+			return "", fmt.Errorf("file '%s' does not exist", *file)
+		} else if err != nil {
+			return "", err
+		} else if !stat.Mode().IsRegular() {
+			return "", fmt.Errorf("file '%s' is not a real file", *file)
+		}
+	}
+
+	return *file, nil
+}
+
 func (c *Grant) getDoors(ix int) ([]string, error) {
 	doors := []string{}
 
@@ -91,18 +143,20 @@ func (c *Grant) Description() string {
 }
 
 func (c *Grant) Usage() string {
-	return "<card number> <start date> <end date> [profile] <doors>"
+	return "<card number|name> <start date> <end date> [profile] <doors> | --file <TSV file>"
 }
 
 func (c *Grant) Help() {
-	fmt.Println("Usage: uhppote-cli [options] grant <card number> <start date> <end date> <profile> <doors>")
+	fmt.Println("Usage: uhppote-cli [options] grant <card number|name> <start date> <end date> <profile> <doors>")
+	fmt.Println()
+	fmt.Println("       uhppote-cli [options] grant --file <TSV file>")
 	fmt.Println()
 	fmt.Println(" Sets the access permissions for a card")
 	fmt.Println()
-	fmt.Println("  <card number>    (required) card number")
+	fmt.Println("  <card number>    (required) card number or cardholder name from the cardholder registry")
 	fmt.Println("  <start date>     (required) start date YYYY-MM-DD")
 	fmt.Println("  <end date>       (required) end date   YYYY-MM-DD")
-	fmt.Println("  <profile>        (optional) predefined time profile, in the range [2..254]")
+	fmt.Println("  <profile>        (optional) predefined time profile, in the range [2..254] (or the time profile name)")
 	fmt.Println("  <doors>          (required) comma separated list of permitted doors e.g. Front Door, Workshop")
 	fmt.Println("                              Doors are case- and space insensitive and correspond to the doors")
 	fmt.Println("                              defined in the config file. The pseudo-door ALL will grant the")
@@ -114,6 +168,18 @@ func (c *Grant) Help() {
 	fmt.Println("                                    the earliest 'from' date and latest 'to' date combination")
 	fmt.Println("                                    for all records for this card across all controllers.")
 	fmt.Println()
+	fmt.Println("  --file <TSV file>  Applies the grant and revoke changes in a TSV change file with the columns:")
+	fmt.Println("                     - Card Number  (required) card number or cardholder name")
+	fmt.Println("                     - From         start date (required for grant)")
+	fmt.Println("                     - To           end date (required for grant)")
+	fmt.Println("                     - Profile      (optional) time profile ID (or name) for grant")
+	fmt.Println("                     - Doors        (required) comma separated list of doors (or ALL)")
+	fmt.Println("                     - Action       grant or revoke (defaults to grant)")
+	fmt.Println()
+	fmt.Println("                     The cards are retrieved once from each controller, the changes are applied in order")
+	fmt.Println("                     and only the updated cards are written back to the controllers. The result is")
+	fmt.Println("                     reported for each row. Revoking all access does not delete the card.")
+	fmt.Println()
 	fmt.Println("  Options:")
 	fmt.Println()
 	fmt.Println("    --config  File path for the 'conf' file containing the controller configuration")
@@ -125,6 +191,9 @@ func (c *Grant) Help() {
 	fmt.Println("    uhppote-cli grant 918273645 2020-01-01 2020-12-31 Front Door, Workshop")
 	fmt.Println(`    uhppote-cli grant 918273645 2020-01-01 2020-12-31 29 "Front Door, Workshop"`)
 	fmt.Println("    uhppote-cli grant 918273645 2020-01-01 2020-12-31 ALL")
+	fmt.Println(`    uhppote-cli grant "Eduardo Ramos" 2020-01-01 2020-12-31 Front Door, Workshop`)
+	fmt.Println(`    uhppote-cli grant 918273645 2020-01-01 2020-12-31 business-hours "Front Door, Workshop"`)
+	fmt.Println("    uhppote-cli grant --file onboarding.tsv")
 	fmt.Println()
 }
 
@@ -0,0 +1,356 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+// change is a single row in a grant/revoke change file.
+type change struct {
+	line    int
+	card    uint32
+	from    types.Date
+	to      types.Date
+	profile int
+	doors   []string
+	action  string
+	err     error
+}
+
+// Applies the grant and revoke changes in a TSV change file. The cards are retrieved once from
+// each controller, the changes are applied (in order) to the retrieved cards and only the
+// updated cards are written back to the controllers.
+func (c *Grant) batch(ctx Context, file string) error {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	changes, err := c.parseChanges(ctx, b)
+	if err != nil {
+		return err
+	}
+
+	cards := map[uint32]map[uint32]types.Card{}
+	for _, device := range ctx.devices {
+		list, err := getCards(ctx, device.DeviceID)
+		if err != nil {
+			return fmt.Errorf("%v: error retrieving cards (%v)", device.DeviceID, err)
+		}
+
+		cards[device.DeviceID] = map[uint32]types.Card{}
+		for _, card := range list {
+			cards[device.DeviceID][card.CardNumber] = card
+		}
+
+		fmt.Printf("   ... %v  retrieved %v cards\n", device.DeviceID, len(list))
+	}
+
+	updated := c.apply(ctx, changes, cards)
+
+	for _, controller := range slices.Sorted(maps.Keys(updated)) {
+		count := 0
+		for _, cardNumber := range slices.Sorted(maps.Keys(updated[controller])) {
+			card := cards[controller][cardNumber]
+			rows := updated[controller][cardNumber]
+
+			var err error
+			if ok, e := ctx.uhppote.PutCard(controller, card, ctx.config.CardFormat); e != nil {
+				err = fmt.Errorf("%v: error updating card %v (%v)", controller, cardNumber, e)
+			} else if !ok {
+				err = fmt.Errorf("%v: failed to update card %v", controller, cardNumber)
+			} else {
+				count++
+			}
+
+			if err != nil {
+				for _, ix := range rows {
+					if changes[ix].err == nil {
+						changes[ix].err = err
+					}
+				}
+			}
+		}
+
+		fmt.Printf("   ... %v  updated %v cards\n", controller, count)
+	}
+
+	fmt.Println()
+
+	failed := 0
+	for _, row := range changes {
+		if row.err != nil {
+			fmt.Printf("   line %-5v %-10v %-6v  ERROR  %v\n", row.line, row.card, row.action, row.err)
+			failed++
+		} else {
+			fmt.Printf("   line %-5v %-10v %-6v  ok\n", row.line, row.card, row.action)
+		}
+	}
+
+	fmt.Println()
+
+	if failed > 0 {
+		return fmt.Errorf("%v of %v changes failed", failed, len(changes))
+	}
+
+	return nil
+}
+
+// Parses a TSV change file with 'Card Number', 'From', 'To', 'Profile', 'Doors' and 'Action'
+// columns. Invalid rows are not fatal - the error is recorded against the row and reported
+// with the results.
+func (c *Grant) parseChanges(ctx Context, tsv []byte) ([]change, error) {
+	r := csv.NewReader(bytes.NewReader(tsv))
+	r.Comma = '\t'
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("change file is empty")
+	} else if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, h := range header {
+		switch clean(h) {
+		case "cardnumber", "card":
+			columns["card"] = i
+		case "from", "to", "profile", "doors", "action":
+			columns[clean(h)] = i
+		}
+	}
+
+	for _, k := range []string{"card", "doors"} {
+		if _, ok := columns[k]; !ok {
+			return nil, fmt.Errorf("change file is missing the '%v' column", map[string]string{"card": "Card Number", "doors": "Doors"}[k])
+		}
+	}
+
+	field := func(record []string, column string) string {
+		if ix, ok := columns[column]; ok && ix < len(record) {
+			return strings.TrimSpace(record[ix])
+		}
+
+		return ""
+	}
+
+	profiles := getProfileNames(ctx)
+	changes := []change{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		line, _ := r.FieldPos(0)
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		row := change{
+			line:   line,
+			action: "grant",
+			doors:  []string{},
+		}
+
+		for t := range strings.SplitSeq(field(record, "doors"), ",") {
+			if d := strings.TrimSpace(t); d != "" {
+				row.doors = append(row.doors, d)
+			}
+		}
+
+		row.err = func() error {
+			if v, err := resolveCardNumber(ctx, field(record, "card"), "invalid card number '%v'"); err != nil {
+				return err
+			} else {
+				row.card = v
+			}
+
+			switch clean(field(record, "action")) {
+			case "", "grant":
+				row.action = "grant"
+			case "revoke":
+				row.action = "revoke"
+			default:
+				return fmt.Errorf("invalid action '%v' (expected grant or revoke)", field(record, "action"))
+			}
+
+			if len(row.doors) == 0 {
+				return errors.New("missing doors")
+			}
+
+			if row.action == "grant" {
+				if v, err := types.ParseDate(field(record, "from")); err != nil {
+					return fmt.Errorf("invalid 'From' date '%v'", field(record, "from"))
+				} else {
+					row.from = v
+				}
+
+				if v, err := types.ParseDate(field(record, "to")); err != nil {
+					return fmt.Errorf("invalid 'To' date '%v'", field(record, "to"))
+				} else {
+					row.to = v
+				}
+
+				if row.to.Before(row.from) {
+					return fmt.Errorf("'From' date (%v) is after 'To' date (%v)", row.from, row.to)
+				}
+
+				if v := field(record, "profile"); v != "" {
+					if profile, err := profiles.resolve(v); err != nil {
+						return err
+					} else {
+						row.profile = int(profile)
+					}
+				}
+			}
+
+			return nil
+		}()
+
+		changes = append(changes, row)
+	}
+
+	return changes, nil
+}
+
+// Applies the valid changes (in order) to the cards retrieved from the controllers. Returns
+// the updated cards for each controller along with the indices of the changes that updated
+// each card.
+//
+// As for 'grant', granted permissions are added to the existing permissions and the 'from' and
+// 'to' dates are widened across all the controllers. As for 'revoke', revoked permissions are
+// removed from the existing permissions (the card is not deleted).
+func (c *Grant) apply(ctx Context, changes []change, cards map[uint32]map[uint32]types.Card) map[uint32]map[uint32][]int {
+	doors := getConfiguredDoors(ctx)
+	profiles := getProfileNames(ctx)
+	cache := map[uint32]map[uint8]bool{}
+	updated := map[uint32]map[uint32][]int{}
+
+	touch := func(controller, card uint32, ix int) {
+		if updated[controller] == nil {
+			updated[controller] = map[uint32][]int{}
+		}
+
+		if !slices.Contains(updated[controller][card], ix) {
+			updated[controller][card] = append(updated[controller][card], ix)
+		}
+	}
+
+	for ix := range changes {
+		row := &changes[ix]
+		if row.err != nil {
+			continue
+		}
+
+		// ... resolve doors
+		targets := []configuredDoor{}
+		for _, d := range row.doors {
+			if clean(d) == "all" {
+				for _, k := range slices.Sorted(maps.Keys(doors)) {
+					targets = append(targets, doors[k])
+				}
+			} else if door, ok := doors[clean(d)]; !ok {
+				row.err = fmt.Errorf("unknown door '%v'", d)
+			} else {
+				targets = append(targets, door)
+			}
+		}
+
+		// ... check time profile
+		if row.err == nil && row.profile != 0 {
+			for _, door := range targets {
+				if err := profiles.verify(ctx.uhppote, door.controller, uint8(row.profile), cache); err != nil {
+					row.err = err
+					break
+				}
+			}
+		}
+
+		if row.err != nil {
+			continue
+		}
+
+		// ... update cards
+		switch row.action {
+		case "grant":
+			permission := uint8(1)
+			if row.profile != 0 {
+				permission = uint8(row.profile)
+			}
+
+			for _, door := range targets {
+				card, ok := cards[door.controller][row.card]
+				if !ok {
+					card = types.Card{
+						CardNumber: row.card,
+						From:       row.from,
+						To:         row.to,
+						Doors:      map[uint8]uint8{1: 0, 2: 0, 3: 0, 4: 0},
+					}
+				} else {
+					card.Doors = maps.Clone(card.Doors)
+				}
+
+				card.Doors[door.door] = permission
+				if cards[door.controller] == nil {
+					cards[door.controller] = map[uint32]types.Card{}
+				}
+
+				cards[door.controller][row.card] = card
+				touch(door.controller, row.card, ix)
+			}
+
+			// ... widen from/to across all controllers
+			from := row.from
+			to := row.to
+			for _, controller := range cards {
+				if card, ok := controller[row.card]; ok {
+					if !card.From.IsZero() && card.From.Before(from) {
+						from = card.From
+					}
+
+					if card.To.After(to) {
+						to = card.To
+					}
+				}
+			}
+
+			for id, controller := range cards {
+				if card, ok := controller[row.card]; ok {
+					if !card.From.Equals(from) || !card.To.Equals(to) {
+						card.From = from
+						card.To = to
+						controller[row.card] = card
+						touch(id, row.card, ix)
+					}
+				}
+			}
+
+		case "revoke":
+			for _, door := range targets {
+				if card, ok := cards[door.controller][row.card]; ok && card.Doors[door.door] != 0 {
+					card.Doors = maps.Clone(card.Doors)
+					card.Doors[door.door] = 0
+					cards[door.controller][row.card] = card
+					touch(door.controller, row.card, ix)
+				}
+			}
+		}
+	}
+
+	return updated
+}
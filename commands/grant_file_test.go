@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppote-core/uhppote"
+)
+
+type grantStub struct {
+	stub
+	profiles map[uint32][]uint8
+}
+
+func (s *grantStub) GetTimeProfile(controller uint32, profileID uint8) (*types.TimeProfile, error) {
+	for _, id := range s.profiles[controller] {
+		if id == profileID {
+			return &types.TimeProfile{ID: profileID}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func TestGrantFile(t *testing.T) {
+	ctx := Context{
+		uhppote: &grantStub{
+			profiles: map[uint32][]uint8{
+				405419896: []uint8{29},
+			},
+		},
+		devices: []uhppote.Device{
+			uhppote.Device{DeviceID: 405419896, Doors: []string{"Gryffindor", "Hufflepuff", "Ravenclaw", "Slytherin"}},
+			uhppote.Device{DeviceID: 303986753, Doors: []string{"Great Hall", "Kitchen", "Dungeon", "Hogsmeade"}},
+		},
+	}
+
+	tsv := `Card Number	From	To	Profile	Doors	Action
+10058400	2026-01-01	2026-12-31		Gryffindor, Great Hall	grant
+10058401	2026-03-01	2026-06-30	29	Ravenclaw
+10058402				Kitchen	revoke
+10058403	2026-01-01	2026-12-31	29	Kitchen	grant
+10058404	2026-01-01	2026-12-31		Attic	grant
+10058400	2025-06-01	2026-03-31		Hogsmeade	grant
+`
+
+	cards := map[uint32]map[uint32]types.Card{
+		405419896: map[uint32]types.Card{
+			10058401: types.Card{CardNumber: 10058401, From: types.MustParseDate("2026-01-01"), To: types.MustParseDate("2026-03-31"), Doors: map[uint8]uint8{1: 1, 2: 0, 3: 0, 4: 0}},
+		},
+		303986753: map[uint32]types.Card{
+			10058402: types.Card{CardNumber: 10058402, From: types.MustParseDate("2026-01-01"), To: types.MustParseDate("2026-12-31"), Doors: map[uint8]uint8{1: 1, 2: 1, 3: 0, 4: 0}},
+		},
+	}
+
+	expected := map[uint32]map[uint32]types.Card{
+		405419896: map[uint32]types.Card{
+			10058400: types.Card{CardNumber: 10058400, From: types.MustParseDate("2025-06-01"), To: types.MustParseDate("2026-12-31"), Doors: map[uint8]uint8{1: 1, 2: 0, 3: 0, 4: 0}},
+			10058401: types.Card{CardNumber: 10058401, From: types.MustParseDate("2026-01-01"), To: types.MustParseDate("2026-06-30"), Doors: map[uint8]uint8{1: 1, 2: 0, 3: 29, 4: 0}},
+		},
+		303986753: map[uint32]types.Card{
+			10058400: types.Card{CardNumber: 10058400, From: types.MustParseDate("2025-06-01"), To: types.MustParseDate("2026-12-31"), Doors: map[uint8]uint8{1: 1, 2: 0, 3: 0, 4: 1}},
+			10058402: types.Card{CardNumber: 10058402, From: types.MustParseDate("2026-01-01"), To: types.MustParseDate("2026-12-31"), Doors: map[uint8]uint8{1: 1, 2: 0, 3: 0, 4: 0}},
+		},
+	}
+
+	expectedUpdates := map[uint32]map[uint32][]int{
+		405419896: map[uint32][]int{
+			10058400: []int{0, 5},
+			10058401: []int{1},
+		},
+		303986753: map[uint32][]int{
+			10058400: []int{0, 5},
+			10058402: []int{2},
+		},
+	}
+
+	grant := Grant{}
+
+	changes, err := grant.parseChanges(ctx, []byte(tsv))
+	if err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if len(changes) != 6 {
+		t.Fatalf("incorrect number of changes - expected:%v, got:%v", 6, len(changes))
+	}
+
+	updated := grant.apply(ctx, changes, cards)
+
+	if !reflect.DeepEqual(cards, expected) {
+		t.Errorf("incorrect cards\n   expected:%v\n   got:     %v", expected, cards)
+	}
+
+	if !reflect.DeepEqual(updated, expectedUpdates) {
+		t.Errorf("incorrect updates\n   expected:%v\n   got:     %v", expectedUpdates, updated)
+	}
+
+	for ix, row := range changes {
+		switch ix {
+		case 3, 4:
+			if row.err == nil {
+				t.Errorf("line %v: expected error", row.line)
+			}
+
+		default:
+			if row.err != nil {
+				t.Errorf("line %v: unexpected error (%v)", row.line, row.err)
+			}
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+var HealthCheckCmd = HealthCheck{
+	maxDrift: 1 * time.Minute,
+}
+
+type HealthCheck struct {
+	maxDrift time.Duration
+}
+
+func (c *HealthCheck) Execute(ctx Context) error {
+	flagset := newFlagSet()
+	maxDrift := flagset.Duration("max-drift", c.maxDrift, "Maximum difference between the controller time and the system time")
+
+	if err := flagset.Parse(flag.Args()[1:]); err != nil {
+		return err
+	}
+
+	c.maxDrift = *maxDrift
+
+	controllers := []uint32{}
+	for _, arg := range flagset.Args() {
+		if controller, err := parseSerialNumber(ctx, arg); err != nil {
+			return err
+		} else {
+			controllers = append(controllers, controller)
+		}
+	}
+
+	if len(controllers) == 0 {
+		for _, device := range ctx.devices {
+			controllers = append(controllers, device.DeviceID)
+		}
+	}
+
+	if len(controllers) == 0 {
+		return fmt.Errorf("no controllers (specify the controllers or define them in the configuration file)")
+	}
+
+	notifier, err := getNotifier(ctx)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, controller := range controllers {
+		if issues := c.check(ctx, controller, time.Now()); len(issues) == 0 {
+			fmt.Printf("   %v  ok\n", controller)
+		} else {
+			failed++
+			fmt.Printf("   %v  %v\n", controller, strings.Join(issues, "; "))
+
+			notifier.notify(alarm{
+				Alarm:      notifyHealthCheck,
+				State:      "failed",
+				Controller: controller,
+				Timestamp:  time.Now(),
+				Details:    strings.Join(issues, "; "),
+			})
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("health check failed for %v of %v controllers", failed, len(controllers))
+	}
+
+	return nil
+}
+
+// Checks that a controller is reachable, that the controller time is within the maximum drift
+// of the system time (in the controller timezone) and that the controller is not reporting a
+// system error.
+func (c *HealthCheck) check(ctx Context, controller uint32, now time.Time) []string {
+	issues := []string{}
+
+	if device, err := ctx.uhppote.GetDevice(controller); err != nil {
+		return append(issues, fmt.Sprintf("not reachable (%v)", err))
+	} else if device == nil {
+		return append(issues, "not reachable")
+	}
+
+	location := time.Local
+	for _, device := range ctx.devices {
+		if device.DeviceID == controller && device.TimeZone != nil {
+			location = device.TimeZone
+		}
+	}
+
+	if t, err := ctx.uhppote.GetTime(controller); err != nil {
+		issues = append(issues, fmt.Sprintf("error retrieving time (%v)", err))
+	} else if t != nil {
+		local := now.In(location)
+		wallclock := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, time.Local)
+		drift := time.Time(t.DateTime).Sub(wallclock)
+
+		if drift > c.maxDrift || drift < -c.maxDrift {
+			issues = append(issues, fmt.Sprintf("controller time %v differs from system time by %v", t.DateTime, drift.Round(time.Second)))
+		}
+	}
+
+	if status, err := ctx.uhppote.GetStatus(controller); err != nil {
+		issues = append(issues, fmt.Sprintf("error retrieving status (%v)", err))
+	} else if status != nil && status.SystemError != 0 {
+		issues = append(issues, fmt.Sprintf("system error %v", status.SystemError))
+	}
+
+	return issues
+}
+
+func (c *HealthCheck) CLI() string {
+	return "health-check"
+}
+
+func (c *HealthCheck) Description() string {
+	return "Checks that the controllers are reachable and healthy"
+}
+
+func (c *HealthCheck) Usage() string {
+	return "[--max-drift <duration>] [serial number...]"
+}
+
+func (c *HealthCheck) Help() {
+	fmt.Println("Usage: uhppote-cli [options] health-check [--max-drift <duration>] [serial number...]")
+	fmt.Println()
+	fmt.Println(" Checks that each controller is reachable, that the controller time is within the maximum drift of the system")
+	fmt.Println(" time and that the controller is not reporting a system error. A 'health-check' email notification is sent for")
+	fmt.Println(" each failed controller if an SMTP server and recipients are configured (cli.smtp.xxx and cli.notify.xxx")
+	fmt.Println(" settings).")
+	fmt.Println()
+	fmt.Println("  serial number  controller serial number (or name). Defaults to all the controllers in the configuration file")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --config     File path for the 'conf' file containing the controller configuration")
+	fmt.Printf("                 (defaults to %s)\n", config.DefaultConfig)
+	fmt.Println("    --debug      Displays internal information for diagnosing errors")
+	fmt.Println()
+	fmt.Println("    --max-drift  Maximum difference between the controller time and the system time (default 1m)")
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println()
+	fmt.Println("    uhppote-cli health-check")
+	fmt.Println("    uhppote-cli health-check --max-drift 30s 405419896")
+	fmt.Println()
+}
+
+// Returns false - configuration is useful but optional.
+func (c *HealthCheck) RequiresConfig() bool {
+	return false
+}
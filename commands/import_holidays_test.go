@@ -0,0 +1,180 @@
+package commands
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+func TestParseICS(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART;VALUE=DATE:20261225\r\n" +
+		"DTEND;VALUE=DATE:20261226\r\n" +
+		"SUMMARY:Christmas Day\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART;VALUE=DATE:20261226\r\n" +
+		"SUMMARY:Boxing\r\n" +
+		"  Day\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART:20261231T000000Z\r\n" +
+		"DTEND:20270102T000000Z\r\n" +
+		"SUMMARY:New Year\\, observed\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART;VALUE=DATE:20260101\r\n" +
+		"RRULE:FREQ=YEARLY\r\n" +
+		"SUMMARY:New Year's Day\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	holidays, warnings, err := parseICS([]byte(ics))
+	if err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	expected := []string{
+		"2026-12-25 2026-12-25 Christmas Day",
+		"2026-12-26 2026-12-26 Boxing Day",
+		"2026-12-31 2027-01-01 New Year, observed",
+	}
+
+	got := []string{}
+	for _, h := range holidays {
+		got = append(got, fmt.Sprintf("%v %v %v", h.from, h.to, h.summary))
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("incorrect holidays\n   expected:%q\n   got:     %q", expected, got)
+	}
+
+	if len(warnings) != 1 {
+		t.Errorf("expected 1 warning, got %v", warnings)
+	}
+
+	if _, _, err := parseICS([]byte("Card Number\tFrom\tTo\n")); err == nil {
+		t.Errorf("expected error for invalid iCalendar file")
+	}
+}
+
+func TestMergeHolidays(t *testing.T) {
+	holidays := []holiday{
+		{types.MustParseDate("2026-12-26"), types.MustParseDate("2026-12-26"), "Boxing Day"},
+		{types.MustParseDate("2026-12-25"), types.MustParseDate("2026-12-25"), "Christmas Day"},
+		{types.MustParseDate("2026-04-03"), types.MustParseDate("2026-04-03"), "Good Friday"},
+		{types.MustParseDate("2027-01-01"), types.MustParseDate("2027-01-01"), "New Year's Day"},
+	}
+
+	expected := []string{
+		"2026-12-25 2026-12-26 Christmas Day, Boxing Day",
+		"2027-01-01 2027-01-01 New Year's Day",
+	}
+
+	got := []string{}
+	for _, h := range mergeHolidays(holidays, types.MustParseDate("2026-10-19")) {
+		got = append(got, fmt.Sprintf("%v %v %v", h.from, h.to, h.summary))
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("incorrect holidays\n   expected:%q\n   got:     %q", expected, got)
+	}
+}
+
+func TestHolidayTasks(t *testing.T) {
+	holidays := []holiday{
+		{types.MustParseDate("2026-12-25"), types.MustParseDate("2026-12-26"), "Christmas"},
+	}
+
+	doors := []configuredDoor{
+		{controller: 405419896, door: 1, name: "Great Hall"},
+		{controller: 303986753, door: 3, name: "Kitchen"},
+	}
+
+	weekdays := types.Weekdays{time.Monday: true, time.Tuesday: true, time.Wednesday: true, time.Thursday: true, time.Friday: true}
+	everyday := types.Weekdays{time.Monday: true, time.Tuesday: true, time.Wednesday: true, time.Thursday: true, time.Friday: true, time.Saturday: true, time.Sunday: true}
+
+	schedules := map[uint32]holidaySchedule{
+		405419896: {
+			tasks: []types.Task{
+				{Task: types.DoorNormallyOpen, Door: 1, From: types.MustParseDate("2026-01-01"), To: types.MustParseDate("2026-12-31"), Weekdays: weekdays, Start: types.MustParseHHmm("08:30")},
+				{Task: types.DoorNormallyClosed, Door: 1, From: types.MustParseDate("2026-01-01"), To: types.MustParseDate("2026-12-31"), Weekdays: everyday, Start: types.MustParseHHmm("18:00")},
+				{Task: types.DoorNormallyOpen, Door: 2, From: types.MustParseDate("2026-01-01"), To: types.MustParseDate("2026-12-31"), Weekdays: everyday, Start: types.MustParseHHmm("10:00")},
+			},
+			firstcards: map[uint8]types.FirstCard{
+				1: {StartTime: types.MustParseHHmm("07:00"), EndTime: types.MustParseHHmm("08:00"), Active: types.Controlled, Inactive: types.NormallyClosed, Weekdays: weekdays},
+			},
+			current: map[uint8]types.ControlState{1: types.Controlled},
+		},
+	}
+
+	expected := map[uint32][]string{
+		405419896: {
+			"door 1 lock door: Mon-Sun 00:00 from 2026-12-25 to 2026-12-26",
+			"door 1 lock door: Fri 07:00 from 2026-12-25 to 2026-12-26",
+			"door 1 lock door: Fri 08:00 from 2026-12-25 to 2026-12-26",
+			"door 1 lock door: Fri 08:30 from 2026-12-25 to 2026-12-26",
+			"door 1 lock door: Mon-Sun 00:00 from 2026-12-27 to 2026-12-27",
+		},
+		303986753: {
+			"door 3 lock door: Mon-Sun 00:00 from 2026-12-25 to 2026-12-26",
+			"door 3 control door: Mon-Sun 00:00 from 2026-12-27 to 2026-12-27",
+		},
+	}
+
+	got := map[uint32][]string{}
+	for controller, tasks := range holidayTasks(holidays, doors, schedules) {
+		for _, task := range tasks {
+			got[controller] = append(got[controller], formatTask(task))
+		}
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("incorrect tasks\n   expected:%v\n   got:     %v", expected, got)
+	}
+}
+
+func TestSplitTimeProfile(t *testing.T) {
+	profile := testProfile(29, 30, "08:00", "17:00")
+	holidays := []holiday{
+		{types.MustParseDate("2026-04-03"), types.MustParseDate("2026-04-06"), "Easter"},
+		{types.MustParseDate("2026-06-13"), types.MustParseDate("2026-06-14"), "Weekend"},
+		{types.MustParseDate("2026-12-25"), types.MustParseDate("2026-12-25"), "Christmas Day"},
+	}
+
+	free := []uint8{254, 253}
+	allocate := func() (uint8, error) {
+		id := free[0]
+		free = free[1:]
+		return id, nil
+	}
+
+	pieces, err := splitTimeProfile(profile, holidays, allocate)
+	if err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	expected := []string{
+		"29: Mon-Fri 08:00-17:00 from 2026-01-01 to 2026-04-02 then 254",
+		"254: Mon-Fri 08:00-17:00 from 2026-04-07 to 2026-12-24 then 253",
+		"253: Mon-Fri 08:00-17:00 from 2026-12-26 to 2026-12-31 then 30",
+	}
+
+	got := []string{}
+	for _, p := range pieces {
+		got = append(got, formatTimeProfile(p))
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("incorrect time profiles\n   expected:%q\n   got:     %q", expected, got)
+	}
+
+	if profile.To.Equals(pieces[0].To) || profile.LinkedProfileID != 30 {
+		t.Errorf("original time profile modified")
+	}
+}
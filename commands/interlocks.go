@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+// The controller API does not support retrieving the door interlock, so the interlock set by
+// set-interlock is recorded in the local state store.
+func interlockFile(controller uint32) string {
+	return filepath.Join("interlock", fmt.Sprintf("%v.json", controller))
+}
+
+// Retrieves the recorded interlock for a controller. Returns nil if no interlock has been
+// recorded.
+func getInterlock(ctx Context, controller uint32) (*types.Interlock, error) {
+	var interlock types.Interlock
+
+	if ok, err := loadState(ctx, interlockFile(controller), &interlock); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, nil
+	}
+
+	return &interlock, nil
+}
+
+func recordInterlock(ctx Context, controller uint32, interlock types.Interlock) {
+	if err := saveState(ctx, interlockFile(controller), interlock); err != nil {
+		fmt.Fprintf(os.Stderr, "   WARN  error recording interlock for %v (%v)\n", controller, err)
+	}
+}
+
+// Returns the doors interlocked with a door.
+func interlocked(interlock types.Interlock, door uint8) []uint8 {
+	groups := map[types.Interlock][][]uint8{
+		types.Interlock12:    {{1, 2}},
+		types.Interlock34:    {{3, 4}},
+		types.Interlock12_34: {{1, 2}, {3, 4}},
+		types.Interlock123:   {{1, 2, 3}},
+		types.Interlock1234:  {{1, 2, 3, 4}},
+	}
+
+	doors := []uint8{}
+	for _, group := range groups[interlock] {
+		if slices.Contains(group, door) {
+			for _, d := range group {
+				if d != door {
+					doors = append(doors, d)
+				}
+			}
+		}
+	}
+
+	return doors
+}
@@ -0,0 +1,363 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+var LintACLCmd = LintACL{
+	file:    "",
+	format:  types.WiegandAny,
+	offline: false,
+}
+
+type LintACL struct {
+	file    string
+	source  aclSource
+	format  types.CardFormat
+	offline bool
+}
+
+type lintIssue struct {
+	line    int
+	message string
+}
+
+func (c *LintACL) Execute(ctx Context) error {
+	if ctx.config == nil {
+		return errors.New("lint-acl requires a valid configuration file")
+	}
+
+	if err := c.parseArgs(ctx); err != nil {
+		return err
+	}
+
+	if c.file == "" {
+		return fmt.Errorf("please specify the TSV file with the access control list to be checked")
+	}
+
+	tsv, err := c.source.read()
+	if err != nil {
+		return err
+	}
+
+	issues, err := c.lint(ctx, getProfileNames(ctx).resolveACL(tsv), time.Now())
+	if err != nil {
+		return err
+	}
+
+	if len(issues) == 0 {
+		fmt.Printf("   ... %v  ok\n", c.file)
+		return nil
+	}
+
+	for _, issue := range issues {
+		fmt.Printf("   line %-5v %v\n", issue.line, issue.message)
+	}
+
+	return fmt.Errorf("%v issues in ACL file '%v'", len(issues), c.file)
+}
+
+// Checks each row of the ACL for semantic errors i.e. errors that are not necessarily
+// syntax errors but which would result in the card not having the expected access.
+// Time profiles are checked against the controllers unless --offline is specified.
+func (c *LintACL) lint(ctx Context, tsv []byte, now time.Time) ([]lintIssue, error) {
+	issues := []lintIssue{}
+	warn := func(line int, format string, args ...any) {
+		issues = append(issues, lintIssue{
+			line:    line,
+			message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	r := csv.NewReader(bytes.NewReader(tsv))
+	r.Comma = '\t'
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("ACL file is empty")
+	} else if err != nil {
+		return nil, err
+	}
+
+	// ... map header to columns
+	doors := getConfiguredDoors(ctx)
+
+	columns := map[string]int{}
+	permissions := map[int]configuredDoor{}
+	for i, h := range header {
+		switch clean(h) {
+		case "cardnumber", "pin", "from", "to":
+			columns[clean(h)] = i
+
+		default:
+			if door, ok := doors[clean(h)]; ok {
+				permissions[i] = door
+			} else if clean(h) != "" {
+				warn(1, "unknown door '%v'", h)
+			}
+		}
+	}
+
+	for _, k := range []string{"cardnumber", "from", "to"} {
+		if _, ok := columns[k]; !ok {
+			return nil, fmt.Errorf("ACL file is missing the '%v' column", map[string]string{"cardnumber": "Card Number", "from": "From", "to": "To"}[k])
+		}
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(doors)) {
+		door := doors[k]
+		if !slices.ContainsFunc(header, func(h string) bool { return clean(h) == k }) {
+			warn(1, "missing column for door '%v'", door.name)
+		}
+	}
+
+	// ... check rows
+	cards := map[uint32]int{}
+	profiles := map[uint32]map[uint8]bool{}
+	today := types.ToDate(now.Year(), now.Month(), now.Day())
+	field := func(record []string, column string) string {
+		if ix, ok := columns[column]; ok && ix < len(record) {
+			return strings.TrimSpace(record[ix])
+		}
+
+		return ""
+	}
+
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		line, _ := r.FieldPos(0)
+
+		// ... card number
+		if card, err := strconv.ParseUint(field(record, "cardnumber"), 10, 32); err != nil || card == 0 {
+			warn(line, "invalid card number '%v'", field(record, "cardnumber"))
+		} else if !c.isValidCardNumber(uint32(card)) {
+			warn(line, "card number %v is not a valid %v card number", card, c.format)
+		} else if previous, ok := cards[uint32(card)]; ok {
+			warn(line, "card %v is duplicated (line %v)", card, previous)
+		} else {
+			cards[uint32(card)] = line
+		}
+
+		// ... PIN
+		if _, ok := columns["pin"]; ok {
+			if pin := field(record, "pin"); pin != "" {
+				if v, err := strconv.ParseUint(pin, 10, 32); err != nil || v > 999999 {
+					warn(line, "invalid PIN '%v' (expected 0-999999)", pin)
+				}
+			}
+		}
+
+		// ... from/to
+		from, errFrom := types.ParseDate(field(record, "from"))
+		to, errTo := types.ParseDate(field(record, "to"))
+
+		if errFrom != nil {
+			warn(line, "invalid 'From' date '%v'", field(record, "from"))
+		}
+
+		if errTo != nil {
+			warn(line, "invalid 'To' date '%v'", field(record, "to"))
+		}
+
+		if errFrom == nil && errTo == nil && to.Before(from) {
+			warn(line, "'From' date (%v) is after 'To' date (%v)", from, to)
+		} else if errTo == nil && to.Before(today) {
+			warn(line, "expired on %v", to)
+		}
+
+		// ... permissions
+		for _, ix := range slices.Sorted(maps.Keys(permissions)) {
+			door := permissions[ix]
+			if ix >= len(record) {
+				continue
+			}
+
+			switch v := strings.TrimSpace(record[ix]); {
+			case regexp.MustCompile(`^(?i:y|yes|n|no|true|false)?$`).MatchString(v):
+
+			case regexp.MustCompile(`^[0-9]+$`).MatchString(v):
+				if profile, err := strconv.ParseUint(v, 10, 8); err != nil || profile < 2 || profile > 254 {
+					warn(line, "invalid time profile '%v' for door '%v' (valid profiles are in the range 2 to 254)", v, door.name)
+				} else {
+					if profiles[door.controller] == nil {
+						profiles[door.controller] = map[uint8]bool{}
+					}
+
+					if defined, err := c.isProfileDefined(ctx, door.controller, uint8(profile), profiles[door.controller]); err != nil {
+						return nil, err
+					} else if !defined {
+						warn(line, "time profile %v for door '%v' is not defined on controller %v", profile, door.name, door.controller)
+					}
+				}
+
+			default:
+				warn(line, "invalid permission '%v' for door '%v'", v, door.name)
+			}
+		}
+	}
+
+	slices.SortStableFunc(issues, func(p, q lintIssue) int {
+		return p.line - q.line
+	})
+
+	return issues, nil
+}
+
+func (c *LintACL) isValidCardNumber(card uint32) bool {
+	switch c.format {
+	case types.Wiegand26:
+		return isWiegand26(card)
+
+	default:
+		return true
+	}
+}
+
+// Returns true if the card number is a valid Wiegand-26 card number i.e. a 3 digit facility
+// code in the range [0..255] followed by a 5 digit card number in the range [0..65535].
+func isWiegand26(card uint32) bool {
+	s := fmt.Sprintf("%08v", card)
+	facilityCode, _ := strconv.Atoi(s[:3])
+	cardNumber, _ := strconv.Atoi(s[3:])
+
+	return facilityCode <= 255 && cardNumber <= 65535
+}
+
+// Retrieves the time profile from the controller (once), caching the result in the
+// 'defined' map. Always returns true if --offline is specified.
+func (c *LintACL) isProfileDefined(ctx Context, controller uint32, profileID uint8, defined map[uint8]bool) (bool, error) {
+	if c.offline {
+		return true, nil
+	}
+
+	if v, ok := defined[profileID]; ok {
+		return v, nil
+	}
+
+	if profile, err := ctx.uhppote.GetTimeProfile(controller, profileID); err != nil {
+		return false, fmt.Errorf("%v: error retrieving time profile %v (%v)", controller, profileID, err)
+	} else {
+		defined[profileID] = profile != nil
+	}
+
+	return defined[profileID], nil
+}
+
+func (c *LintACL) parseArgs(ctx Context) error {
+	flagset := newFlagSet()
+	format := flagset.String("card-format", fmt.Sprintf("%v", ctx.config.CardFormat), "Card format for card number validation")
+	offline := flagset.Bool("offline", false, "Skips the checks that require the controllers")
+	aclFormat := flagset.String("acl-format", "", "ACL format (tsv, csv or json)")
+	delimiter := flagset.String("delimiter", ",", "CSV field delimiter")
+	file := ""
+	args := flag.Args()[1:]
+
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+
+	// ... file
+	if len(flagset.Args()) > 0 {
+		file = flagset.Arg(0)
+		if err := (aclSource{file: file}).validate(); err != nil {
+			return err
+		}
+	}
+
+	if v, err := parseACLFormat(*aclFormat); err != nil {
+		return err
+	} else {
+		c.source.format = v
+	}
+
+	if v, err := parseDelimiter(*delimiter); err != nil {
+		return err
+	} else {
+		c.source.delimiter = v
+	}
+
+	c.file = file
+	c.source.file = file
+	c.offline = *offline
+
+	if v, err := types.CardFormatFromString(*format); err != nil {
+		return err
+	} else {
+		c.format = v
+	}
+
+	return nil
+}
+
+func (c *LintACL) CLI() string {
+	return "lint-acl"
+}
+
+func (c *LintACL) Description() string {
+	return "Checks an access control list TSV file for errors against the configuration and controllers"
+}
+
+func (c *LintACL) Usage() string {
+	return "[--offline] [--card-format <format>] <TSV file>"
+}
+
+func (c *LintACL) Help() {
+	fmt.Println("Usage: uhppote-cli [options] lint-acl [--offline] [--card-format <format>] [--acl-format <format>] <TSV file>")
+	fmt.Println()
+	fmt.Println(" Checks the access control list in the TSV file for errors that would not be reported by load-acl but which would")
+	fmt.Println(" result in a card not having the expected access. Each issue is reported with the line number in the TSV file:")
+	fmt.Println()
+	fmt.Println("   - door columns that do not match a door in the configuration file")
+	fmt.Println("   - configured doors that do not have a column in the TSV file")
+	fmt.Println("   - invalid or duplicate card numbers, and card numbers that are not valid for the card format")
+	fmt.Println("   - 'From' dates after 'To' dates")
+	fmt.Println("   - rows that have already expired")
+	fmt.Println("   - invalid PINs")
+	fmt.Println("   - time profiles that are not defined on the controller for the door")
+	fmt.Println()
+	fmt.Println("  <TSV file>  (required) TSV file with access control list")
+	fmt.Println()
+	printACLSourcesHelp()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --config  File path for the 'conf' file containing the controller configuration")
+	fmt.Printf("              (defaults to %s)\n", config.DefaultConfig)
+	fmt.Println("    --debug   Displays internal information for diagnosing errors")
+	fmt.Println()
+	fmt.Println("    --offline     Skips the checks that require the controllers (i.e. time profiles)")
+	fmt.Println("    --card-format Card format for card number validation (any or Wiegand-26). Defaults to the")
+	fmt.Println("                  'card.format' setting in the configuration file")
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println()
+	fmt.Println("    uhppote-cli lint-acl \"uhppote-2023-03-07.tsv\"")
+	fmt.Println("    uhppote-cli --config .config lint-acl --offline --card-format wiegand-26 \"uhppote-2023-03-07.tsv\"")
+	fmt.Println()
+}
+
+// Returns true - configuration is not optional for this command to return valid information.
+func (c *LintACL) RequiresConfig() bool {
+	return true
+}
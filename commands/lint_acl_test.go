@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppote-core/uhppote"
+)
+
+func TestLintACL(t *testing.T) {
+	lintACL := LintACL{
+		format:  types.Wiegand26,
+		offline: true,
+	}
+
+	ctx := Context{
+		devices: []uhppote.Device{
+			uhppote.Device{
+				DeviceID: 405419896,
+				Doors:    []string{"Great Hall", "Kitchen", "Dungeon", "Hogsmeade"},
+			},
+		},
+	}
+
+	tsv := "Card Number\tPIN\tFrom\tTo\tGreat Hall\tKitchen\tDungeon\tHogsmeade\tAttic\n" +
+		"10058400\t7531\t2026-01-01\t2026-12-31\tY\tN\t29\tN\tN\n" +
+		"10058401\t\t2026-12-31\t2026-01-01\tY\tN\tN\tN\tN\n" +
+		"10058402\t1234567\t2025-01-01\t2025-12-31\tY\tN\tN\tN\tN\n" +
+		"99999999\t\t2026-01-01\t2026-12-31\tY\tN\t255\tX\tN\n" +
+		"10058400\t\t2026-01-01\t2026-12-31\tY\tN\tN\tN\tN\n"
+
+	expected := []lintIssue{
+		{1, "unknown door 'Attic'"},
+		{3, "'From' date (2026-12-31) is after 'To' date (2026-01-01)"},
+		{4, "invalid PIN '1234567' (expected 0-999999)"},
+		{4, "expired on 2025-12-31"},
+		{5, "card number 99999999 is not a valid Wiegand-26 card number"},
+		{5, "invalid time profile '255' for door 'Dungeon' (valid profiles are in the range 2 to 254)"},
+		{5, "invalid permission 'X' for door 'Hogsmeade'"},
+		{6, "card 10058400 is duplicated (line 2)"},
+	}
+
+	now := time.Date(2026, time.March, 1, 12, 30, 0, 0, time.Local)
+
+	issues, err := lintACL.lint(ctx, []byte(tsv), now)
+	if err != nil {
+		t.Fatalf("Unexpected error (%v)", err)
+	}
+
+	if !reflect.DeepEqual(issues, expected) {
+		t.Errorf("Incorrect lint issues\n   expected:%v\n   got:     %v", expected, issues)
+	}
+}
@@ -1,9 +1,15 @@
 package commands
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"sync"
+	"time"
 
 	"github.com/uhppoted/uhppote-core/types"
 )
@@ -14,14 +20,46 @@ type Listen struct {
 }
 
 type listener struct {
+	cardholders cardholders
+	archive     string
+	alarms      *alarmEngine
+	dispatcher  *alarmDispatcher
+	hub         *eventHub
+	sync.Mutex
 }
 
 func (l *listener) OnConnected() {
-	fmt.Printf("Listening...\n")
+	if l.alarms != nil {
+		fmt.Fprintf(os.Stderr, "Listening...\n")
+	} else {
+		fmt.Printf("Listening...\n")
+	}
 }
 
 func (l *listener) OnEvent(event *types.Status) {
-	fmt.Printf("%v\n", event)
+	if l.hub != nil {
+		l.hub.publish(*event)
+	}
+
+	if l.alarms != nil {
+		l.Lock()
+		alarms := l.alarms.onStatus(*event, time.Now())
+		l.Unlock()
+
+		for _, a := range alarms {
+			l.dispatcher.dispatch(a)
+		}
+	} else if name := l.cardholders.annotate(event.Event.CardNumber); name != "" && event.Event.Index > 0 {
+		fmt.Printf("%v  %v\n", event, name)
+	} else {
+		fmt.Printf("%v\n", event)
+	}
+
+	if l.archive != "" && event.Event.Index > 0 {
+		if err := archiveEvent(l.archive, statusEvent(*event)); err != nil {
+			fmt.Fprintf(os.Stderr, "   WARN  error archiving event %v (%v)\n", event.Event.Index, err)
+		}
+	}
 }
 
 func (l *listener) OnError(err error) bool {
@@ -29,14 +67,157 @@ func (l *listener) OnError(err error) bool {
 	return true
 }
 
+// Checks the time dependent alarms.
+func (l *listener) tick(now time.Time) {
+	l.Lock()
+	alarms := l.alarms.tick(now)
+	l.Unlock()
+
+	for _, a := range alarms {
+		l.dispatcher.dispatch(a)
+	}
+}
+
 func (c *Listen) Execute(ctx Context) error {
+	defaults, err := getAlarmSettings(ctx)
+	if err != nil {
+		return err
+	}
+
+	flagset := newFlagSet()
+	archive := flagset.String("archive", "", "Appends the received events to an event archive file")
+	alarms := flagset.Bool("alarms", false, "Displays alarms (as JSON) rather than events")
+	heldOpen := flagset.Duration("held-open", defaults.heldOpen, "Time after which an open door raises a 'held-open' alarm")
+	denied := flagset.Int("denied", defaults.denied, "Number of denied swipes at a door that raises a 'denied-swipes' alarm")
+	deniedWindow := flagset.Duration("denied-window", defaults.deniedWindow, "Window for the 'denied-swipes' alarm")
+	webhook := flagset.String("webhook", defaults.webhook, "URL to which to POST alarms")
+	hook := flagset.String("exec", defaults.exec, "Command to execute for each alarm")
+	address := flagset.String("http", "", "HTTP address on which to stream events to SSE and WebSocket clients")
+	replay := flagset.Int("replay", 100, "Number of recent events sent to a newly connected HTTP client")
+	origin := flagset.String("allow-origin", "", "Access-Control-Allow-Origin for cross-origin HTTP clients")
+	certificate := flagset.String("tls-cert", "", "TLS certificate file for HTTPS")
+	key := flagset.String("tls-key", "", "TLS private key file for HTTPS")
+
+	if err := flagset.Parse(flag.Args()[1:]); err != nil {
+		return err
+	}
+
+	if (*certificate == "") != (*key == "") {
+		return fmt.Errorf("--tls-cert and --tls-key must both be specified for HTTPS")
+	}
+
+	l := listener{
+		cardholders: getCardholders(ctx),
+		archive:     *archive,
+	}
+
+	if *alarms {
+		names := map[doorID]string{}
+		for _, d := range getConfiguredDoors(ctx) {
+			names[doorID{d.controller, d.door}] = d.name
+		}
+
+		l.alarms = newAlarmEngine(*heldOpen, max(*denied, 1), *deniedWindow, names)
+		sinks := []alarmSink{jsonSink{os.Stdout}}
+
+		if *webhook != "" {
+			sinks = append(sinks, newWebhookSink(*webhook))
+		}
+
+		if *hook != "" {
+			sinks = append(sinks, execSink{*hook})
+		}
+
+		if notifier, err := getNotifier(ctx); err != nil {
+			return err
+		} else if notifier != nil {
+			sinks = append(sinks, smtpSink{notifier})
+		}
+
+		l.dispatcher = newAlarmDispatcher(sinks)
+
+		ticker := time.NewTicker(1 * time.Second)
+		done := make(chan struct{})
+		stopped := make(chan struct{})
+
+		defer ticker.Stop()
+		defer func() {
+			close(done)
+			<-stopped
+			l.dispatcher.close()
+		}()
+
+		go func() {
+			defer close(stopped)
+
+			for {
+				select {
+				case <-done:
+					return
+				case now := <-ticker.C:
+					l.tick(now)
+				}
+			}
+		}()
+	}
+
+	if *address != "" {
+		bind := streamAddress(*address)
+		token := ctx.settings.get("listen.token", "")
+		tls := *certificate != ""
+
+		if token == "" && !isLoopback(bind) {
+			return fmt.Errorf("missing bearer token for event stream on %v (define 'cli.listen.token' in the configuration file)", bind)
+		} else if !tls && !isLoopback(bind) {
+			fmt.Fprintf(os.Stderr, "   WARN  streaming events over plain HTTP on %v - the bearer token is sent in the clear (use --tls-cert and --tls-key)\n", bind)
+		}
+
+		hub := newEventHub(ctx, *replay, token, *origin)
+		socket, err := net.Listen("tcp", bind)
+		if err != nil {
+			return err
+		}
+
+		server := http.Server{
+			Handler:           hub.handler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		defer server.Close()
+
+		go func() {
+			var err error
+			if tls {
+				err = server.ServeTLS(socket, *certificate, *key)
+			} else {
+				err = server.Serve(socket)
+			}
+
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fmt.Fprintf(os.Stderr, "   WARN  event stream server error (%v)\n", err)
+			}
+		}()
+
+		l.hub = hub
+	}
+
 	q := make(chan os.Signal, 1)
 
 	defer close(q)
 
 	signal.Notify(q, os.Interrupt)
 
-	return ctx.uhppote.Listen(&listener{}, q)
+	return ctx.uhppote.Listen(&l, q)
+}
+
+// Returns the event stream bind address, with the host defaulting to the loopback interface
+// if the address is just a port (e.g. :8081).
+func streamAddress(address string) string {
+	if host, port, err := net.SplitHostPort(address); err == nil && host == "" {
+		return net.JoinHostPort("127.0.0.1", port)
+	}
+
+	return address
 }
 
 func (c *Listen) CLI() string {
@@ -48,11 +229,69 @@ func (c *Listen) Description() string {
 }
 
 func (c *Listen) Usage() string {
-	return ""
+	return "[--archive <file>] [--alarms [alarm options]] [--http <address> [stream options]]"
 }
 
 func (c *Listen) Help() {
-	fmt.Println("Listens for access control events from UHPPOTE UT0311-L0x controllers configured to send events to this IP address and port")
+	fmt.Println("Usage: uhppote-cli [options] listen [--archive <file>] [--alarms [alarm options]] [--http <address> [stream options]]")
+	fmt.Println()
+	fmt.Println(" Listens for access control events from UHPPOTE UT0311-L0x controllers configured to send events to this IP address and port")
+	fmt.Println()
+	fmt.Println(" With --alarms, interprets the events and displays the alarms (as JSON) raised and cleared for:")
+	fmt.Println()
+	fmt.Println("   - forced-open    a door opened without a preceding granted swipe, pushbutton or remote open")
+	fmt.Println("   - held-open      a door that is open for longer than the held-open threshold")
+	fmt.Println("   - denied-swipes  repeated denied swipes at a door within the denied-window")
+	fmt.Println("   - tamper         the controller 'theft prevention' alarm (cleared after 5 minutes without one)")
+	fmt.Println()
+	fmt.Println(" The alarm options default to the cli.alarms.xxx settings in the configuration file. Alarms are also sent as email")
+	fmt.Println(" notifications if an SMTP server and recipients are configured (cli.smtp.xxx and cli.notify.xxx settings).")
+	fmt.Println()
+	fmt.Println(" With --http, also streams the events (as JSON) to any number of HTTP clients:")
+	fmt.Println()
+	fmt.Println("   - GET /events     Server-Sent Events (e.g. a browser EventSource)")
+	fmt.Println("   - GET /events/ws  WebSocket")
+	fmt.Println()
+	fmt.Println(" Clients can restrict the events with the 'controller', 'door' and 'card' query parameters (repeated or comma")
+	fmt.Println(" separated) e.g. /events?door=Great%20Hall&card=10058400. Controllers and doors may be given by name.")
+	fmt.Println()
+	fmt.Println(" The event stream is served on the loopback interface if the --http address does not include a host. If the")
+	fmt.Println(" cli.listen.token setting is defined, clients must supply it as a bearer token (or, over HTTPS only, as the 'token'")
+	fmt.Println(" query parameter for a browser EventSource). The token is required for a non-loopback address and --tls-cert and")
+	fmt.Println(" --tls-key serve the event stream over HTTPS.")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --archive        Appends the received events to an event archive file (one JSON encoded event per line)")
+	fmt.Println("                     for use with 'muster'")
+	fmt.Println("    --alarms         Displays alarms rather than events")
+	fmt.Println()
+	fmt.Println("  Alarm options:")
+	fmt.Println()
+	fmt.Println("    --held-open      Time after which an open door raises a 'held-open' alarm (default 60s)")
+	fmt.Println("    --denied         Number of denied swipes that raises a 'denied-swipes' alarm (default 3)")
+	fmt.Println("    --denied-window  Window for the 'denied-swipes' alarm (default 60s)")
+	fmt.Println("    --webhook        URL to which to POST each alarm as JSON")
+	fmt.Println("    --exec           Command to execute for each alarm. The alarm is passed as JSON on stdin and as the ALARM,")
+	fmt.Println("                     ALARM_STATE, ALARM_CONTROLLER, ALARM_DOOR and ALARM_DETAILS environment variables. The")
+	fmt.Println("                     command output is written to stderr")
+	fmt.Println()
+	fmt.Println("  Stream options:")
+	fmt.Println()
+	fmt.Println("    --http           HTTP address on which to stream events e.g. :8081 (127.0.0.1:8081) or 0.0.0.0:8443")
+	fmt.Println("    --replay         Number of recent events sent to a client on connecting (default 100)")
+	fmt.Println("    --allow-origin   Access-Control-Allow-Origin header (and allowed WebSocket origin) for cross-origin clients")
+	fmt.Println("                     e.g. https://example.com. WebSocket handshakes from other origins (than the same host)")
+	fmt.Println("                     are rejected")
+	fmt.Println("    --tls-cert       TLS certificate file for HTTPS")
+	fmt.Println("    --tls-key        TLS private key file for HTTPS")
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println()
+	fmt.Println("    uhppote-cli listen --archive events.json")
+	fmt.Println("    uhppote-cli listen --alarms --held-open 2m --webhook http://127.0.0.1:8000/alarms")
+	fmt.Println("    uhppote-cli listen --http :8081 --replay 20")
+	fmt.Println("    uhppote-cli listen --http 0.0.0.0:8443 --tls-cert server.crt --tls-key server.key")
 	fmt.Println()
 }
 
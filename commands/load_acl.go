@@ -5,7 +5,10 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
+	"time"
 
 	"github.com/uhppoted/uhppote-core/types"
 	"github.com/uhppoted/uhppote-core/uhppote"
@@ -23,6 +26,7 @@ var LoadACLCmd = LoadACL{
 
 type LoadACL struct {
 	file    string
+	source  aclSource
 	withPIN bool
 	format  types.CardFormat
 	strict  bool
@@ -42,12 +46,14 @@ func (c *LoadACL) Execute(ctx Context) error {
 		return fmt.Errorf("please specify the TSV file from which to load the access control list ")
 	}
 
-	tsv, err := os.ReadFile(c.file)
+	tsv, err := c.source.read()
 	if err != nil {
 		return err
 	}
 
-	list, warnings, err := acl.ParseTSV(bytes.NewReader(tsv), ctx.devices, c.strict)
+	profiles := getProfileNames(ctx)
+
+	list, warnings, err := acl.ParseTSV(bytes.NewReader(profiles.resolveACL(tsv)), ctx.devices, c.strict)
 	if err != nil {
 		return err
 	}
@@ -56,6 +62,10 @@ func (c *LoadACL) Execute(ctx Context) error {
 		fmt.Printf("   ... WARNING    %v\n", w)
 	}
 
+	if err := c.verify(ctx, list, profiles); err != nil {
+		return err
+	}
+
 	for k, l := range list {
 		fmt.Printf("   ... %v  ACL has %v records\n", k, len(l))
 	}
@@ -80,6 +90,10 @@ func (c *LoadACL) Execute(ctx Context) error {
 			len(v.Errors))
 	}
 
+	if !c.dryrun {
+		c.notify(ctx, rpt, errors)
+	}
+
 	if len(errors) > 0 {
 		return fmt.Errorf("%v", errors)
 	}
@@ -110,32 +124,107 @@ loop:
 	return nil
 }
 
+// Sends a 'load-acl' notification for each controller with failed or error entries in the
+// report (and for any errors).
+func (c *LoadACL) notify(ctx Context, rpt map[uint32]acl.Report, errs []error) {
+	notifier, err := getNotifier(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "   WARN  %v\n", err)
+		return
+	} else if notifier == nil {
+		return
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(rpt)) {
+		if r := rpt[k]; len(r.Failed) > 0 || len(r.Errors) > 0 {
+			details := fmt.Sprintf("failed:%v  errors:%v", len(r.Failed), len(r.Errors))
+			if len(r.Errors) > 0 {
+				details += fmt.Sprintf("  (%v)", r.Errors[0])
+			}
+
+			notifier.notify(alarm{
+				Alarm:      notifyLoadACL,
+				State:      "failed",
+				Controller: k,
+				Timestamp:  time.Now(),
+				Details:    details,
+				Cards:      r.Failed,
+			})
+		}
+	}
+
+	if len(errs) > 0 {
+		notifier.notify(alarm{
+			Alarm:     notifyLoadACL,
+			State:     "error",
+			Timestamp: time.Now(),
+			Details:   fmt.Sprintf("%v", errs),
+		})
+	}
+}
+
+// Verifies that the time profiles referenced by the ACL are defined on the controllers, since
+// the controller does not itself reject a card with an undefined time profile.
+func (c *LoadACL) verify(ctx Context, list acl.ACL, profiles profileNames) error {
+	cache := map[uint32]map[uint8]bool{}
+	errs := []error{}
+
+	for _, controller := range slices.Sorted(maps.Keys(list)) {
+		ids := map[uint8]bool{}
+		for _, card := range list[controller] {
+			for _, p := range card.Doors {
+				if p >= 2 && p <= 254 {
+					ids[p] = true
+				}
+			}
+		}
+
+		for _, id := range slices.Sorted(maps.Keys(ids)) {
+			if err := profiles.verify(ctx.uhppote, controller, id, cache); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 func (c *LoadACL) parseArgs(ctx Context) error {
-	flagset := flag.NewFlagSet("", flag.ExitOnError)
+	flagset := newFlagSet()
 	format := flagset.String("card-format", fmt.Sprintf("%v", ctx.config.CardFormat), "Card format for card number validation")
 	withPIN := flagset.Bool("with-pin", false, "Include card keypad PIN code in retrieved ACL information")
 	strict := flagset.Bool("strict", false, "Treat duplicate card numbers as errors")
+	aclFormat := flagset.String("acl-format", "", "ACL format (tsv, csv or json)")
+	delimiter := flagset.String("delimiter", ",", "CSV field delimiter")
 	file := ""
 	args := flag.Args()[1:]
 
-	flagset.Parse(args)
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
 
 	// ... file
 	if len(flagset.Args()) > 0 {
 		file = flagset.Arg(0)
-		stat, err := os.Stat(file)
-		if err != nil && os.IsNotExist(err) {
-			return fmt.Errorf("file '%s' does not exist", file)
-		} else if err != nil {
+		if err := (aclSource{file: file}).validate(); err != nil {
 			return err
-		} else if err == nil && stat.Mode().IsDir() {
-			return fmt.Errorf("file '%s' is a directory", file)
-		} else if err == nil && !stat.Mode().IsRegular() {
-			return fmt.Errorf("file '%s' is not a real file", file)
 		}
 	}
 
+	if v, err := parseACLFormat(*aclFormat); err != nil {
+		return err
+	} else {
+		c.source.format = v
+	}
+
+	if v, err := parseDelimiter(*delimiter); err != nil {
+		return err
+	} else {
+		c.source.delimiter = v
+	}
+
 	c.file = file
+	c.source.file = file
 	c.withPIN = *withPIN
 	c.strict = *strict
 
@@ -161,7 +250,7 @@ func (c *LoadACL) Usage() string {
 }
 
 func (c *LoadACL) Help() {
-	fmt.Println("Usage: uhppote-cli [options] load-acl [--with-pin] [--strict] <TSV file>")
+	fmt.Println("Usage: uhppote-cli [options] load-acl [--with-pin] [--strict] [--acl-format <format>] <TSV file>")
 	fmt.Println()
 	fmt.Println(" Downloads the access control list in the TSV file to the access controllers defined in the configuration")
 	fmt.Println(" file. Duplicate card numbers are ignored (or deleted if they exist) with a warning message unless the")
@@ -188,6 +277,12 @@ func (c *LoadACL) Help() {
 	fmt.Println("               123456789<tab>0<tab>2023-01-01<tab>2023-12-31<tab>Y<tab>N<tab> ...")
 	fmt.Println("               987654321<tab>7531<tab>2023-03-05<tab>2023-11-15<tab>N<tab>N<tab> ...")
 	fmt.Println()
+	printACLSourcesHelp()
+	fmt.Println("  Notifications:")
+	fmt.Println()
+	fmt.Println("    A 'load-acl' email notification is sent for each controller with failed or error entries if an SMTP server")
+	fmt.Println("    and recipients are configured (cli.smtp.xxx and cli.notify.xxx settings).")
+	fmt.Println()
 	fmt.Println("  Options:")
 	fmt.Println()
 	fmt.Println("    --config  File path for the 'conf' file containing the controller configuration")
@@ -198,6 +293,8 @@ func (c *LoadACL) Help() {
 	fmt.Println()
 	fmt.Println("    uhppote-cli load-acl \"uhppote-2023-03-07.tsv\"")
 	fmt.Println("    uhppote-cli --debug --config .config load-acl --with-pin \"uhppote-2023-03-07.tsv\"")
+	fmt.Println("    uhppote-cli load-acl --acl-format csv --delimiter ';' \"uhppote-2023-03-07.csv\"")
+	fmt.Println("    uhppote-cli load-acl \"https://docs.google.com/spreadsheets/d/<id>/export?format=tsv\"")
 	fmt.Println()
 }
 
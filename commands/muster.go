@@ -0,0 +1,450 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+var MusterCmd = Muster{}
+
+type Muster struct {
+	since   time.Time
+	archive string
+	live    bool
+	all     bool
+}
+
+// doorID identifies a door by controller and door number.
+type doorID struct {
+	controller uint32
+	door       uint8
+}
+
+// presence is the last granted swipe for a card.
+type presence struct {
+	card      uint32
+	inside    bool
+	door      doorID
+	timestamp time.Time
+}
+
+// muster tracks the last in/out direction for each card from the granted swipes. A swipe at an
+// 'entry' door marks the card as on site and a swipe at an 'exit' door marks the card as off site.
+// For any other door the direction is the reader direction recorded in the event.
+type muster struct {
+	roles map[doorID]string
+	cards map[uint32]presence
+}
+
+func (c *Muster) Execute(ctx Context) error {
+	controllers, err := c.parseArgs(ctx)
+	if err != nil {
+		return err
+	}
+
+	roles, err := c.roles(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(slices.Collect(maps.Values(roles)), "exit") {
+		fmt.Fprintf(os.Stderr, "   WARN  no exit doors defined (cli.muster.exit) - only swipes at 'out' readers mark cards as off site\n")
+	}
+
+	// ... events
+	events := []types.Event{}
+	if c.archive != "" {
+		list, err := loadArchive(c.archive)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range list {
+			if slices.Contains(controllers, uint32(e.SerialNumber)) && !time.Time(e.Timestamp).Before(c.since) {
+				events = append(events, e)
+			}
+		}
+	} else {
+		for _, controller := range controllers {
+			if list, err := c.scan(ctx, controller, c.since); err != nil {
+				return fmt.Errorf("%v: error retrieving events (%v)", controller, err)
+			} else {
+				events = append(events, list...)
+			}
+		}
+	}
+
+	slices.SortStableFunc(events, func(p, q types.Event) int {
+		return time.Time(p.Timestamp).Compare(time.Time(q.Timestamp))
+	})
+
+	m := muster{
+		roles: roles,
+		cards: map[uint32]presence{},
+	}
+
+	for _, e := range events {
+		m.update(e)
+	}
+
+	cardholders := getCardholders(ctx)
+	doors := map[doorID]string{}
+	for _, d := range getConfiguredDoors(ctx) {
+		doors[doorID{d.controller, d.door}] = d.name
+	}
+
+	c.print(m, cardholders, doors, os.Stdout)
+
+	if !c.live {
+		return nil
+	}
+
+	// ... live updates
+	q := make(chan os.Signal, 1)
+
+	defer close(q)
+
+	signal.Notify(q, os.Interrupt)
+
+	l := musterListener{
+		muster:      &m,
+		controllers: controllers,
+		cardholders: cardholders,
+		doors:       doors,
+	}
+
+	if err := ctx.uhppote.Listen(&l, q); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	c.print(m, cardholders, doors, os.Stdout)
+
+	return nil
+}
+
+// Updates the card presence from an event. Returns true if the event is a granted swipe that
+// is not older than the last recorded swipe for the card.
+func (m *muster) update(e types.Event) (presence, bool) {
+	if e.Type != eventSwipe || !e.Granted || e.CardNumber == 0 {
+		return presence{}, false
+	}
+
+	at := time.Time(e.Timestamp)
+	if p, ok := m.cards[e.CardNumber]; ok && at.Before(p.timestamp) {
+		return p, false
+	}
+
+	door := doorID{uint32(e.SerialNumber), e.Door}
+	inside := e.Direction != directionOut
+
+	switch m.roles[door] {
+	case "entry":
+		inside = true
+	case "exit":
+		inside = false
+	}
+
+	p := presence{
+		card:      e.CardNumber,
+		inside:    inside,
+		door:      door,
+		timestamp: at,
+	}
+
+	m.cards[e.CardNumber] = p
+
+	return p, true
+}
+
+// Returns the cards that are on site (or all cards), ordered by last seen.
+func (m *muster) list(all bool) []presence {
+	list := []presence{}
+	for _, p := range m.cards {
+		if all || p.inside {
+			list = append(list, p)
+		}
+	}
+
+	slices.SortFunc(list, func(p, q presence) int {
+		if v := p.timestamp.Compare(q.timestamp); v != 0 {
+			return v
+		}
+
+		return int(p.card) - int(q.card)
+	})
+
+	return list
+}
+
+// Retrieves the events on a controller since a time, scanning backwards from the 'last' event.
+func (c *Muster) scan(ctx Context, controller uint32, since time.Time) ([]types.Event, error) {
+	first, err := ctx.uhppote.GetEvent(controller, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := ctx.uhppote.GetEvent(controller, 0xffffffff)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []types.Event{}
+	if first == nil || last == nil {
+		return events, nil
+	}
+
+	for index := last.Index; index >= first.Index && index > 0; index-- {
+		event, err := ctx.uhppote.GetEvent(controller, index)
+		if err != nil {
+			return nil, err
+		} else if event == nil || event.Index != index {
+			continue
+		} else if time.Time(event.Timestamp).Before(since) {
+			break
+		}
+
+		events = append(events, *event)
+	}
+
+	slices.Reverse(events)
+
+	return events, nil
+}
+
+// Returns the door roles defined by the cli.muster.entry and cli.muster.exit settings. Doors
+// are identified by name or as <controller>:<door>.
+func (c *Muster) roles(ctx Context) (map[doorID]string, error) {
+	roles := map[doorID]string{}
+
+	for _, role := range []string{"entry", "exit"} {
+		for d := range strings.SplitSeq(ctx.settings.get("muster."+role, ""), ",") {
+			if strings.TrimSpace(d) == "" {
+				continue
+			}
+
+			door, err := resolveDoor(ctx, strings.TrimSpace(d))
+			if err != nil {
+				return nil, fmt.Errorf("cli.muster.%v: %v", role, err)
+			}
+
+			roles[doorID{door.controller, door.door}] = role
+		}
+	}
+
+	return roles, nil
+}
+
+func (c *Muster) print(m muster, cardholders cardholders, doors map[doorID]string, w io.Writer) {
+	list := m.list(c.all)
+
+	fmt.Fprintf(w, "   ON SITE  %v  (since %v)\n", len(m.list(false)), c.since.Format("2006-01-02 15:04"))
+
+	if len(list) == 0 {
+		return
+	}
+
+	table := [][]string{
+		{"CARD", "NAME", "STATUS", "DOOR", "LAST SEEN"},
+	}
+
+	for _, p := range list {
+		table = append(table, formatPresence(p, cardholders, doors))
+	}
+
+	fmt.Fprintln(w)
+	for _, line := range format(table) {
+		fmt.Fprintf(w, "   %v\n", strings.TrimSpace(line))
+	}
+}
+
+func formatPresence(p presence, cardholders cardholders, doors map[doorID]string) []string {
+	status := "out"
+	if p.inside {
+		status = "in"
+	}
+
+	door := fmt.Sprintf("%v:%v", p.door.controller, p.door.door)
+	if name := doors[p.door]; name != "" {
+		door = name
+	}
+
+	name := cardholders.annotate(p.card)
+	if name == "" {
+		name = "-"
+	}
+
+	return []string{
+		fmt.Sprintf("%v", p.card),
+		name,
+		status,
+		door,
+		p.timestamp.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// musterListener updates the muster from the events received by 'listen'.
+type musterListener struct {
+	muster      *muster
+	controllers []uint32
+	cardholders cardholders
+	doors       map[doorID]string
+}
+
+func (l *musterListener) OnConnected() {
+	fmt.Println()
+	fmt.Printf("   ... listening for events\n")
+}
+
+func (l *musterListener) OnEvent(status *types.Status) {
+	if status == nil || !slices.Contains(l.controllers, uint32(status.SerialNumber)) {
+		return
+	}
+
+	if p, ok := l.muster.update(statusEvent(*status)); ok {
+		fields := formatPresence(p, l.cardholders, l.doors)
+
+		fmt.Printf("   %-3v  %v  %v  %v  %v  (on site: %v)\n", strings.ToUpper(fields[2]), fields[0], fields[1], fields[3], fields[4], len(l.muster.list(false)))
+	}
+}
+
+func (l *musterListener) OnError(err error) bool {
+	fmt.Fprintf(os.Stderr, "   WARN  %v\n", err)
+	return true
+}
+
+func (c *Muster) parseArgs(ctx Context) ([]uint32, error) {
+	flagset := newFlagSet()
+	since := flagset.String("since", "", "Start date/time (or period) for the events")
+	archive := flagset.String("archive", "", "Event archive file")
+	live := flagset.Bool("live", false, "Updates the muster from the events received from the controllers")
+	all := flagset.Bool("all", false, "Lists all cards, including cards that are off site")
+
+	if err := flagset.Parse(flag.Args()[1:]); err != nil {
+		return nil, err
+	}
+
+	t, err := parseSince(*since, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	c.since = t
+	c.archive = *archive
+	c.live = *live
+	c.all = *all
+
+	controllers := []uint32{}
+	for _, arg := range flagset.Args() {
+		if controller, err := parseSerialNumber(ctx, arg); err != nil {
+			return nil, err
+		} else {
+			controllers = append(controllers, controller)
+		}
+	}
+
+	if len(controllers) == 0 {
+		for _, device := range ctx.devices {
+			controllers = append(controllers, device.DeviceID)
+		}
+	}
+
+	if len(controllers) == 0 {
+		return nil, fmt.Errorf("no controllers (specify the controllers or define them in the configuration file)")
+	}
+
+	return controllers, nil
+}
+
+// Parses a start time as a date, date and time, duration (e.g. 12h) or period (e.g. 2d). Defaults
+// to midnight today.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	if strings.TrimSpace(s) == "" {
+		return midnight, nil
+	}
+
+	s = strings.TrimSpace(s)
+
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+
+	if days, err := parsePeriod(s); err == nil && days >= 0 {
+		return midnight.AddDate(0, 0, -days), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid 'since' (%v)", s)
+}
+
+func (c *Muster) CLI() string {
+	return "muster"
+}
+
+func (c *Muster) Description() string {
+	return "Lists the cardholders believed to be on site"
+}
+
+func (c *Muster) Usage() string {
+	return "[--since <time>] [--archive <file>] [--live] [--all] [serial number...]"
+}
+
+func (c *Muster) Help() {
+	fmt.Println("Usage: uhppote-cli [options] muster [--since <time>] [--archive <file>] [--live] [--all] [serial number...]")
+	fmt.Println()
+	fmt.Println(" Reconstructs the last in/out direction for each card from the granted swipes since a start time and lists")
+	fmt.Println(" the cards believed to be on site, along with the door and time the card was last seen.")
+	fmt.Println()
+	fmt.Println(" A swipe at a door listed in the cli.muster.entry setting marks the card as on site and a swipe at a door")
+	fmt.Println(" listed in the cli.muster.exit setting marks the card as off site. A swipe at any other door marks the card")
+	fmt.Println(" as on site or off site according to the reader direction (in or out) recorded in the event e.g.")
+	fmt.Println()
+	fmt.Println("   cli.muster.entry = Front Door, 405419896:2")
+	fmt.Println("   cli.muster.exit = Exit Turnstile")
+	fmt.Println()
+	fmt.Println(" The events are retrieved from the controller event buffers unless an event archive (as written by")
+	fmt.Println(" 'listen --archive') is specified.")
+	fmt.Println()
+	fmt.Println("  serial number  controller serial number (or name). Defaults to all the controllers in the configuration file")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --config  File path for the 'conf' file containing the controller configuration")
+	fmt.Printf("              (defaults to %s)\n", config.DefaultConfig)
+	fmt.Println("    --debug   Displays internal information for diagnosing errors")
+	fmt.Println()
+	fmt.Println("    --since   Start date/time (YYYY-mm-dd [HH:mm]), duration (e.g. 12h) or number of days (e.g. 2d).")
+	fmt.Println("              Defaults to midnight today")
+	fmt.Println("    --archive Event archive file")
+	fmt.Println("    --live    Keeps updating the muster from the events received from the controllers until interrupted")
+	fmt.Println("    --all     Lists all cards, including the cards that are off site")
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println()
+	fmt.Println("    uhppote-cli muster")
+	fmt.Println("    uhppote-cli muster --since 12h --live 405419896")
+	fmt.Println("    uhppote-cli muster --archive events.json")
+	fmt.Println()
+}
+
+// Returns true - configuration is not optional for this command to return valid information.
+func (c *Muster) RequiresConfig() bool {
+	return true
+}
@@ -0,0 +1,205 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+type musterStub struct {
+	stub
+	events []types.Event
+}
+
+func (s *musterStub) GetEvent(controller, index uint32) (*types.Event, error) {
+	switch {
+	case len(s.events) == 0:
+		return nil, nil
+
+	case index == 0:
+		return &s.events[0], nil
+
+	case index == 0xffffffff:
+		return &s.events[len(s.events)-1], nil
+	}
+
+	for _, e := range s.events {
+		if e.Index == index {
+			return &e, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func swipe(index uint32, timestamp string, card uint32, door uint8, granted bool) types.Event {
+	return types.Event{
+		SerialNumber: 405419896,
+		Index:        index,
+		Type:         eventSwipe,
+		Granted:      granted,
+		Door:         door,
+		CardNumber:   card,
+		Timestamp:    types.MustParseDateTime(timestamp),
+		Reason:       reasonSwipe,
+	}
+}
+
+func TestMusterUpdate(t *testing.T) {
+	m := muster{
+		roles: map[doorID]string{
+			{405419896, 1}: "entry",
+			{405419896, 2}: "exit",
+		},
+		cards: map[uint32]presence{},
+	}
+
+	events := []types.Event{
+		swipe(1, "2026-10-19 08:00:00", 10058400, 1, true),
+		swipe(2, "2026-10-19 08:05:00", 10058401, 1, true),
+		swipe(3, "2026-10-19 08:10:00", 10058402, 3, true),
+		swipe(4, "2026-10-19 08:15:00", 10058403, 1, false),
+		swipe(5, "2026-10-19 12:00:00", 10058401, 2, true),
+		{SerialNumber: 405419896, Index: 6, Type: eventDoor, Door: 2, Timestamp: types.MustParseDateTime("2026-10-19 12:00:05"), Reason: reasonDoorOpened},
+	}
+
+	for _, e := range events {
+		m.update(e)
+	}
+
+	// ... out of order events are ignored
+	if _, ok := m.update(swipe(7, "2026-10-19 11:00:00", 10058401, 1, true)); ok {
+		t.Errorf("expected out of order swipe to be ignored")
+	}
+
+	onsite := []uint32{}
+	for _, p := range m.list(false) {
+		onsite = append(onsite, p.card)
+	}
+
+	if expected := []uint32{10058400, 10058402}; !reflect.DeepEqual(onsite, expected) {
+		t.Errorf("incorrect muster - expected:%v, got:%v", expected, onsite)
+	}
+
+	if all := m.list(true); len(all) != 3 {
+		t.Errorf("incorrect muster (all) - expected:%v cards, got:%v", 3, len(all))
+	} else if p := all[2]; p.card != 10058401 || p.inside || p.door != (doorID{405419896, 2}) {
+		t.Errorf("incorrect presence - expected:%v out at door 2, got:%+v", 10058401, p)
+	}
+}
+
+func TestMusterScan(t *testing.T) {
+	u := musterStub{
+		events: []types.Event{
+			swipe(11, "2026-10-18 17:00:00", 10058400, 1, true),
+			swipe(12, "2026-10-19 08:00:00", 10058401, 1, true),
+			swipe(14, "2026-10-19 09:00:00", 10058402, 1, true),
+		},
+	}
+
+	ctx := Context{uhppote: &u}
+	since := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+
+	events, err := (&Muster{}).scan(ctx, 405419896, since)
+	if err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	indices := []uint32{}
+	for _, e := range events {
+		indices = append(indices, e.Index)
+	}
+
+	if expected := []uint32{12, 14}; !reflect.DeepEqual(indices, expected) {
+		t.Errorf("incorrect events - expected:%v, got:%v", expected, indices)
+	}
+}
+
+func TestEventArchive(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "events.json")
+	events := []types.Event{
+		swipe(12, "2026-10-19 08:00:00", 10058401, 1, true),
+		swipe(14, "2026-10-19 09:00:00", 10058402, 2, false),
+	}
+
+	for _, e := range events {
+		if err := archiveEvent(file, e); err != nil {
+			t.Fatalf("unexpected error (%v)", err)
+		}
+	}
+
+	archived, err := loadArchive(file)
+	if err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	if fmt.Sprintf("%v", archived) != fmt.Sprintf("%v", events) {
+		t.Errorf("incorrect archived events\n   expected:%v\n   got:     %v", events, archived)
+	}
+
+	os.WriteFile(file, []byte("{\"Index\":\n"), 0644)
+	if _, err := loadArchive(file); err == nil {
+		t.Errorf("expected error for invalid event archive")
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 10, 19, 14, 30, 0, 0, time.Local)
+
+	tests := map[string]string{
+		"":                 "2026-10-19 00:00",
+		"2026-10-18":       "2026-10-18 00:00",
+		"2026-10-18 22:15": "2026-10-18 22:15",
+		"12h":              "2026-10-19 02:30",
+		"2d":               "2026-10-17 00:00",
+	}
+
+	for s, expected := range tests {
+		if since, err := parseSince(s, now); err != nil {
+			t.Errorf("%q: unexpected error (%v)", s, err)
+		} else if since.Format("2006-01-02 15:04") != expected {
+			t.Errorf("%q: incorrect 'since' - expected:%v, got:%v", s, expected, since.Format("2006-01-02 15:04"))
+		}
+	}
+
+	if _, err := parseSince("yesterday", now); err == nil {
+		t.Errorf("expected error for invalid 'since'")
+	}
+}
+
+func TestMusterUpdateWithDirection(t *testing.T) {
+	m := muster{
+		roles: map[doorID]string{
+			{405419896, 1}: "entry",
+			{405419896, 2}: "exit",
+		},
+		cards: map[uint32]presence{},
+	}
+
+	direction := func(e types.Event, direction uint8) types.Event {
+		e.Direction = direction
+		return e
+	}
+
+	tests := []struct {
+		event  types.Event
+		inside bool
+	}{
+		{direction(swipe(1, "2026-10-19 08:00:00", 10058400, 3, true), directionIn), true},
+		{direction(swipe(2, "2026-10-19 08:05:00", 10058400, 3, true), directionOut), false},
+		{direction(swipe(3, "2026-10-19 08:10:00", 10058400, 1, true), directionOut), true},
+		{direction(swipe(4, "2026-10-19 08:15:00", 10058400, 2, true), directionIn), false},
+		{direction(swipe(5, "2026-10-19 08:20:00", 10058400, 4, true), directionIn), true},
+	}
+
+	for _, test := range tests {
+		if p, ok := m.update(test.event); !ok || p.inside != test.inside {
+			t.Errorf("door %v direction %v: incorrect presence - expected inside:%v, got:%v", test.event.Door, test.event.Direction, test.inside, p.inside)
+		}
+	}
+}
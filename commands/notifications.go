@@ -0,0 +1,307 @@
+package commands
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"maps"
+	"net"
+	"net/smtp"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Notification types, in addition to the alarm types.
+const (
+	notifyLoadACL     = "load-acl"
+	notifyHealthCheck = "health-check"
+)
+
+const defaultSubject = `[uhppote] {{.Alarm}} {{.State}}{{if .Controller}} {{.Controller}}{{end}}{{if .DoorName}} {{.DoorName}}{{else if .Door}} door {{.Door}}{{end}}`
+
+const defaultBody = `{{.Alarm}} {{.State}}
+
+controller: {{.Controller}}
+{{- if .Door}}
+door:       {{.Door}} {{.DoorName}}
+{{- end}}
+time:       {{.Timestamp.Format "2006-01-02 15:04:05"}}
+{{- if .Details}}
+details:    {{.Details}}
+{{- end}}
+{{- if .Cards}}
+cards:      {{.Cards}}
+{{- end}}
+{{- if .Suppressed}}
+
+({{.Suppressed}} earlier notifications were suppressed by the rate limit)
+{{- end}}
+`
+
+// notifier sends email notifications for alarms and failed operations via SMTP. Notifications
+// are routed to the recipients subscribed to the notification type (the cli.notify.<address>
+// settings) and rate limited per recipient.
+type notifier struct {
+	server     string
+	username   string
+	password   string
+	from       string
+	starttls   bool
+	subject    *template.Template
+	body       *template.Template
+	recipients map[string][]string
+	limit      int
+	period     time.Duration
+	sent       map[string][]time.Time
+	suppressed map[string]int
+	sync.Mutex
+}
+
+// notification is the template data for a notification.
+type notification struct {
+	alarm
+	Suppressed int
+}
+
+// Returns the notifier configured by the cli.smtp.xxx and cli.notify.xxx settings, or nil if
+// no SMTP server or recipients are configured.
+func getNotifier(ctx Context) (*notifier, error) {
+	server := ctx.settings.get("smtp.server", "")
+	recipients := map[string][]string{}
+
+	for address, kinds := range ctx.settings.prefixed("notify.") {
+		for kind := range strings.SplitSeq(kinds, ",") {
+			if k := strings.ToLower(strings.TrimSpace(kind)); k != "" {
+				recipients[address] = append(recipients[address], k)
+			}
+		}
+	}
+
+	if server == "" || len(recipients) == 0 {
+		return nil, nil
+	}
+
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		return nil, fmt.Errorf("invalid cli.smtp.server (%v)", server)
+	}
+
+	n := notifier{
+		server:     server,
+		username:   ctx.settings.get("smtp.username", ""),
+		password:   ctx.settings.get("smtp.password", ""),
+		from:       ctx.settings.get("smtp.from", "uhppote-cli@localhost"),
+		starttls:   ctx.settings.get("smtp.starttls", "true") != "false",
+		recipients: recipients,
+		limit:      10,
+		period:     time.Hour,
+		sent:       map[string][]time.Time{},
+		suppressed: map[string]int{},
+	}
+
+	// ... templates
+	subject := ctx.settings.get("smtp.subject", defaultSubject)
+	body := defaultBody
+
+	if file := ctx.settings.path("smtp.body"); file != "" {
+		if b, err := os.ReadFile(file); err != nil {
+			return nil, fmt.Errorf("cli.smtp.body: %v", err)
+		} else {
+			body = string(b)
+		}
+	}
+
+	if t, err := template.New("subject").Parse(subject); err != nil {
+		return nil, fmt.Errorf("invalid cli.smtp.subject template (%v)", err)
+	} else {
+		n.subject = t
+	}
+
+	if t, err := template.New("body").Parse(body); err != nil {
+		return nil, fmt.Errorf("invalid cli.smtp.body template (%v)", err)
+	} else {
+		n.body = t
+	}
+
+	// ... rate limit
+	if v := ctx.settings.get("smtp.rate-limit", ""); v != "" {
+		match := regexp.MustCompile(`^\s*([0-9]+)\s*/\s*(\S+)\s*$`).FindStringSubmatch(v)
+		if match == nil {
+			return nil, fmt.Errorf("invalid cli.smtp.rate-limit (%v) - expected e.g. 10/1h", v)
+		}
+
+		limit, err := strconv.Atoi(match[1])
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("invalid cli.smtp.rate-limit (%v)", v)
+		}
+
+		period, err := time.ParseDuration(match[2])
+		if err != nil || period <= 0 {
+			return nil, fmt.Errorf("invalid cli.smtp.rate-limit (%v)", v)
+		}
+
+		n.limit = limit
+		n.period = period
+	}
+
+	return &n, nil
+}
+
+// Sends a notification to the recipients subscribed to the notification type, warning on any
+// errors. Notifications that exceed a recipient rate limit are dropped and counted in the next
+// notification sent to the recipient.
+func (n *notifier) notify(a alarm) {
+	if n == nil {
+		return
+	}
+
+	for _, recipient := range slices.Sorted(maps.Keys(n.recipients)) {
+		if !n.subscribed(recipient, a.Alarm) {
+			continue
+		}
+
+		suppressed, ok := n.allow(recipient, time.Now())
+		if !ok {
+			continue
+		}
+
+		if err := n.send(recipient, notification{a, suppressed}); err != nil {
+			fmt.Fprintf(os.Stderr, "   WARN  error sending '%v' notification to %v (%v)\n", a.Alarm, recipient, err)
+		}
+	}
+}
+
+// Replaces the line breaks in a header field value so that a template value cannot inject
+// additional headers.
+var headerLine = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// Formats a notification from the subject and body templates and sends it to a recipient.
+func (n *notifier) send(recipient string, data notification) error {
+	var subject, body bytes.Buffer
+
+	if err := n.subject.Execute(&subject, data); err != nil {
+		return err
+	}
+
+	if err := n.body.Execute(&body, data); err != nil {
+		return err
+	}
+
+	var msg bytes.Buffer
+
+	fmt.Fprintf(&msg, "From: %v\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %v\r\n", recipient)
+	fmt.Fprintf(&msg, "Subject: %v\r\n", headerLine.Replace(strings.TrimSpace(subject.String())))
+	fmt.Fprintf(&msg, "Date: %v\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(&msg, "\r\n")
+	fmt.Fprintf(&msg, "%v", strings.ReplaceAll(strings.ReplaceAll(body.String(), "\r\n", "\n"), "\n", "\r\n"))
+
+	return n.deliver(recipient, msg.Bytes())
+}
+
+// Delivers a message to a recipient via the SMTP server, using STARTTLS (if enabled) and
+// PLAIN authentication (if a username is configured).
+func (n *notifier) deliver(recipient string, msg []byte) error {
+	host, _, _ := net.SplitHostPort(n.server)
+
+	c, err := smtp.Dial(n.server)
+	if err != nil {
+		return err
+	}
+
+	defer c.Close()
+
+	if hostname, err := os.Hostname(); err == nil {
+		if err := c.Hello(hostname); err != nil {
+			return err
+		}
+	}
+
+	if n.starttls {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%v does not support STARTTLS", n.server)
+		} else if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+
+	if n.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", n.username, n.password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(n.from); err != nil {
+		return err
+	} else if err := c.Rcpt(recipient); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	} else if _, err := w.Write(msg); err != nil {
+		return err
+	} else if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// Returns true if the recipient is subscribed to the notification type. A recipient subscribed
+// to 'alarms' receives all the alarm notifications and a recipient subscribed to '*' receives
+// all notifications.
+func (n *notifier) subscribed(recipient string, kind string) bool {
+	alarms := []string{alarmForcedOpen, alarmHeldOpen, alarmDeniedSwipes, alarmTamper}
+
+	for _, k := range n.recipients[recipient] {
+		if k == "*" || k == kind || (k == "alarms" && slices.Contains(alarms, kind)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Applies the recipient rate limit, returning the number of notifications suppressed since the
+// last notification and false if the notification exceeds the rate limit.
+func (n *notifier) allow(recipient string, now time.Time) (int, bool) {
+	n.Lock()
+	defer n.Unlock()
+
+	sent := slices.DeleteFunc(n.sent[recipient], func(t time.Time) bool {
+		return now.Sub(t) >= n.period
+	})
+
+	if len(sent) >= n.limit {
+		n.sent[recipient] = sent
+		n.suppressed[recipient]++
+		return 0, false
+	}
+
+	suppressed := n.suppressed[recipient]
+
+	n.sent[recipient] = append(sent, now)
+	n.suppressed[recipient] = 0
+
+	return suppressed, true
+}
+
+// smtpSink delivers alarm events as email notifications.
+type smtpSink struct {
+	notifier *notifier
+}
+
+func (s smtpSink) send(a alarm) error {
+	s.notifier.notify(a)
+
+	return nil
+}
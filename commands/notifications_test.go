@@ -0,0 +1,278 @@
+package commands
+
+import (
+	"bufio"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpStandIn is a minimal SMTP server that records the messages it receives.
+type smtpStandIn struct {
+	listener net.Listener
+	messages chan smtpMessage
+}
+
+type smtpMessage struct {
+	from string
+	to   []string
+	auth string
+	data string
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error starting SMTP stand-in (%v)", err)
+	}
+
+	s := smtpStandIn{
+		listener: listener,
+		messages: make(chan smtpMessage, 16),
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(conn)
+		}
+	}()
+
+	t.Cleanup(func() { listener.Close() })
+
+	return &s
+}
+
+func (s *smtpStandIn) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(v string) {
+		conn.Write([]byte(v + "\r\n"))
+	}
+
+	reply("220 localhost ESMTP")
+
+	msg := smtpMessage{}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		command, args, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(command) {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+
+		case "AUTH":
+			msg.auth = args
+			reply("235 authenticated")
+
+		case "MAIL":
+			msg.from = args
+			reply("250 ok")
+
+		case "RCPT":
+			msg.to = append(msg.to, args)
+			reply("250 ok")
+
+		case "DATA":
+			reply("354 go ahead")
+
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				} else if line == ".\r\n" {
+					break
+				}
+
+				data.WriteString(line)
+			}
+
+			msg.data = data.String()
+			s.messages <- msg
+			msg = smtpMessage{}
+			reply("250 queued")
+
+		case "QUIT":
+			reply("221 bye")
+			return
+
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	server := newSMTPStandIn(t)
+	ctx := Context{
+		settings: Settings{
+			values: map[string]string{
+				"smtp.server":                     server.listener.Addr().String(),
+				"smtp.starttls":                   "false",
+				"smtp.username":                   "uhppote",
+				"smtp.password":                   "secret",
+				"smtp.from":                       "uhppote@example.com",
+				"smtp.subject":                    "{{.Alarm}} {{.State}} {{.DoorName}}",
+				"notify.facilities@example.com":   "alarms",
+				"notify.admin@example.com":        "load-acl, health-check",
+				"notify.security@example.com":     "forced-open",
+				"notify.nobody@example.com":       "",
+				"notify.everybody@example.com":    "*",
+				"notify.uninterested@example.com": "tamper",
+			},
+		},
+	}
+
+	notifier, err := getNotifier(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if notifier == nil {
+		t.Fatalf("expected notifier")
+	}
+
+	notifier.notify(alarm{
+		Alarm:      alarmHeldOpen,
+		State:      alarmRaised,
+		Controller: 405419896,
+		Door:       1,
+		DoorName:   "Great Hall",
+		Timestamp:  time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local),
+		Details:    "open for more than 60s",
+	})
+
+	recipients := []string{}
+	for range 2 {
+		select {
+		case msg := <-server.messages:
+			recipients = append(recipients, msg.to...)
+
+			if msg.from != "FROM:<uhppote@example.com>" {
+				t.Errorf("incorrect sender - expected:%v, got:%v", "FROM:<uhppote@example.com>", msg.from)
+			}
+
+			if msg.auth == "" {
+				t.Errorf("expected AUTH")
+			}
+
+			if !strings.Contains(msg.data, "Subject: held-open raised Great Hall\r\n") {
+				t.Errorf("incorrect subject\n%v", msg.data)
+			}
+
+			if !strings.Contains(msg.data, "details:    open for more than 60s\r\n") {
+				t.Errorf("incorrect body\n%v", msg.data)
+			}
+
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for notification")
+		}
+	}
+
+	if expected := []string{"TO:<everybody@example.com>", "TO:<facilities@example.com>"}; !reflect.DeepEqual(recipients, expected) {
+		t.Errorf("incorrect recipients - expected:%v, got:%v", expected, recipients)
+	}
+
+	select {
+	case msg := <-server.messages:
+		t.Errorf("unexpected notification to %v", msg.to)
+	default:
+	}
+}
+
+func TestNotificationsRateLimit(t *testing.T) {
+	ctx := Context{
+		settings: Settings{
+			values: map[string]string{
+				"smtp.server":                   "127.0.0.1:25",
+				"smtp.rate-limit":               "2/10m",
+				"notify.facilities@example.com": "*",
+			},
+		},
+	}
+
+	notifier, err := getNotifier(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		at         time.Duration
+		allowed    bool
+		suppressed int
+	}{
+		{0, true, 0},
+		{1 * time.Minute, true, 0},
+		{2 * time.Minute, false, 0},
+		{3 * time.Minute, false, 0},
+		{10 * time.Minute, true, 2},
+		{11 * time.Minute, true, 0},
+		{12 * time.Minute, false, 0},
+	}
+
+	for _, test := range tests {
+		suppressed, allowed := notifier.allow("facilities@example.com", now.Add(test.at))
+		if allowed != test.allowed || suppressed != test.suppressed {
+			t.Errorf("%v: expected:%v/%v, got:%v/%v", test.at, test.allowed, test.suppressed, allowed, suppressed)
+		}
+	}
+
+	for _, v := range []string{"10", "10/", "0/1h", "10/1x"} {
+		ctx.settings.values["smtp.rate-limit"] = v
+		if _, err := getNotifier(ctx); err == nil {
+			t.Errorf("%v: expected error for invalid rate limit", v)
+		}
+	}
+}
+
+func TestNotificationsSubjectLineBreaks(t *testing.T) {
+	server := newSMTPStandIn(t)
+	ctx := Context{
+		settings: Settings{
+			values: map[string]string{
+				"smtp.server":                  server.listener.Addr().String(),
+				"smtp.starttls":                "false",
+				"smtp.from":                    "uhppote@example.com",
+				"smtp.subject":                 "{{.Alarm}} {{.DoorName}}",
+				"notify.everybody@example.com": "*",
+			},
+		},
+	}
+
+	notifier, err := getNotifier(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	notifier.notify(alarm{
+		Alarm:      alarmForcedOpen,
+		State:      alarmRaised,
+		Controller: 405419896,
+		Door:       1,
+		DoorName:   "Great Hall\rBcc: eve@example.com\r\nX-Spam: no\nCc: mallory@example.com",
+		Timestamp:  time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local),
+	})
+
+	select {
+	case msg := <-server.messages:
+		if !strings.Contains(msg.data, "Subject: forced-open Great Hall Bcc: eve@example.com X-Spam: no Cc: mallory@example.com\r\n") {
+			t.Errorf("incorrect subject\n%v", msg.data)
+		}
+
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for notification")
+	}
+}
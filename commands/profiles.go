@@ -0,0 +1,186 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/uhppoted/uhppote-core/uhppote"
+	"github.com/uhppoted/uhppoted-lib/encoding/tsv"
+)
+
+// profileNames maps time profile IDs to names. The names are defined by:
+//   - 'cli.profile.<name> = <ID>' entries in the uhppoted.conf file
+//   - the 'Profile' and 'Name' columns of the TSV file defined by the 'cli.profiles'
+//     setting (e.g. the set-time-profiles TSV file with an additional 'Name' column)
+type profileNames map[uint8]string
+
+// Loads the time profile names defined in the configuration. Returns the names defined in
+// the uhppoted.conf file (with a warning) if the profiles file cannot be loaded.
+func getProfileNames(ctx Context) profileNames {
+	names := profileNames{}
+
+	if file := ctx.settings.path("profiles"); file != "" {
+		if v, err := loadProfileNames(file); err != nil {
+			fmt.Fprintf(os.Stderr, "   WARN  error loading time profile names (%v)\n", err)
+		} else {
+			names = v
+		}
+	}
+
+	for name, v := range ctx.settings.prefixed("profile.") {
+		if id, err := strconv.ParseUint(v, 10, 8); err != nil || id < 2 || id > 254 {
+			fmt.Fprintf(os.Stderr, "   WARN  invalid time profile ID '%v' for '%v'\n", v, name)
+		} else {
+			names[uint8(id)] = name
+		}
+	}
+
+	return names
+}
+
+func loadProfileNames(file string) (profileNames, error) {
+	type tsvProfile struct {
+		ID   int    `tsv:"Profile"`
+		Name string `tsv:"Name"`
+	}
+
+	bytes, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	recordset := []tsvProfile{}
+	if err := tsv.Unmarshal(bytes, &recordset); err != nil {
+		return nil, err
+	}
+
+	names := profileNames{}
+	for _, record := range recordset {
+		if record.ID < 2 || record.ID > 254 {
+			return nil, fmt.Errorf("%v: invalid time profile ID (%v)", file, record.ID)
+		} else if name := strings.TrimSpace(record.Name); name != "" {
+			names[uint8(record.ID)] = name
+		}
+	}
+
+	return names, nil
+}
+
+// Returns the name for a time profile ID (or "" if the profile is not named).
+func (p profileNames) name(id uint8) string {
+	return p[id]
+}
+
+// Returns the time profile name if defined, otherwise the time profile ID.
+func (p profileNames) label(id uint8) string {
+	if name := p[id]; name != "" {
+		return name
+	}
+
+	return fmt.Sprintf("%v", id)
+}
+
+// Returns the time profile ID and name formatted for an error message e.g. 29 (business-hours).
+func (p profileNames) describe(id uint8) string {
+	if name := p[id]; name != "" {
+		return fmt.Sprintf("%v (%v)", id, name)
+	}
+
+	return fmt.Sprintf("%v", id)
+}
+
+// Finds the time profile ID for a (case- and space-insensitive) time profile name.
+func (p profileNames) lookup(name string) (uint8, bool) {
+	ids := []uint8{}
+	for id, v := range p {
+		if clean(v) == clean(name) {
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) == 0 {
+		return 0, false
+	}
+
+	return slices.Min(ids), true
+}
+
+// Resolves a time profile ID or name to a time profile ID in the range [2..254].
+func (p profileNames) resolve(s string) (uint8, error) {
+	if regexp.MustCompile(`^\s*[0-9]+\s*$`).MatchString(s) {
+		if v, err := strconv.ParseUint(strings.TrimSpace(s), 10, 8); err != nil || v < 2 || v > 254 {
+			return 0, fmt.Errorf("invalid time profile ID (%v) - valid range is from 2 to 254", strings.TrimSpace(s))
+		} else {
+			return uint8(v), nil
+		}
+	}
+
+	if id, ok := p.lookup(s); ok {
+		return id, nil
+	}
+
+	return 0, fmt.Errorf("unknown time profile '%v'", s)
+}
+
+// Replaces time profile names in the door columns of a TSV ACL with the time profile IDs.
+func (p profileNames) resolveACL(acl []byte) []byte {
+	if len(p) == 0 {
+		return acl
+	}
+
+	lines := strings.Split(string(acl), "\n")
+	if len(lines) < 2 {
+		return acl
+	}
+
+	doors := map[int]bool{}
+	for i, h := range strings.Split(strings.TrimRight(lines[0], "\r"), "\t") {
+		switch clean(h) {
+		case "cardnumber", "pin", "from", "to":
+		default:
+			doors[i] = true
+		}
+	}
+
+	for i, line := range lines[1:] {
+		fields := strings.Split(strings.TrimRight(line, "\r"), "\t")
+		for j, v := range fields {
+			if v = strings.TrimSpace(v); doors[j] && v != "" {
+				if id, ok := p.lookup(v); ok {
+					fields[j] = fmt.Sprintf("%v", id)
+				}
+			}
+		}
+
+		lines[i+1] = strings.Join(fields, "\t")
+	}
+
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// Verifies that a time profile is defined on a controller, caching the result.
+func (p profileNames) verify(u uhppote.IUHPPOTE, controller uint32, id uint8, cache map[uint32]map[uint8]bool) error {
+	if cache[controller] == nil {
+		cache[controller] = map[uint8]bool{}
+	}
+
+	defined, ok := cache[controller][id]
+	if !ok {
+		if profile, err := u.GetTimeProfile(controller, id); err != nil {
+			return fmt.Errorf("%v: error retrieving time profile %v (%v)", controller, p.describe(id), err)
+		} else {
+			defined = profile != nil
+			cache[controller][id] = defined
+		}
+	}
+
+	if !defined {
+		return fmt.Errorf("time profile %v is not defined on controller %v", p.describe(id), controller)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"testing"
+)
+
+func TestProfileNamesResolve(t *testing.T) {
+	profiles := profileNames{
+		29: "business-hours",
+		30: "Weekends",
+	}
+
+	tests := []struct {
+		arg      string
+		expected uint8
+	}{
+		{"29", 29},
+		{"100", 100},
+		{"business-hours", 29},
+		{"Business Hours", 0},
+		{"weekends", 30},
+		{"1", 0},
+		{"255", 0},
+		{"holidays", 0},
+	}
+
+	for _, test := range tests {
+		id, err := profiles.resolve(test.arg)
+		if test.expected == 0 && err == nil {
+			t.Errorf("%v: expected error, got %v", test.arg, id)
+		} else if test.expected != 0 && err != nil {
+			t.Errorf("%v: unexpected error (%v)", test.arg, err)
+		} else if id != test.expected {
+			t.Errorf("%v: incorrect time profile - expected:%v, got:%v", test.arg, test.expected, id)
+		}
+	}
+}
+
+func TestProfileNamesResolveACL(t *testing.T) {
+	profiles := profileNames{
+		29: "business-hours",
+	}
+
+	acl := "Card Number\tFrom\tTo\tGreat Hall\tKitchen\n" +
+		"10058400\t2026-01-01\t2026-12-31\tY\tBusiness-Hours\n" +
+		"10058401\t2026-01-01\t2026-12-31\t29\tN\n"
+
+	expected := "Card Number\tFrom\tTo\tGreat Hall\tKitchen\n" +
+		"10058400\t2026-01-01\t2026-12-31\tY\t29\n" +
+		"10058401\t2026-01-01\t2026-12-31\t29\tN\n"
+
+	if resolved := string(profiles.resolveACL([]byte(acl))); resolved != expected {
+		t.Errorf("incorrectly resolved ACL\n   expected:%q\n   got:     %q", expected, resolved)
+	}
+}
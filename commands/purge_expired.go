@@ -0,0 +1,200 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+var PurgeExpiredCmd = PurgeExpired{
+	file:   "",
+	dryrun: false,
+}
+
+type PurgeExpired struct {
+	file   string
+	dryrun bool
+}
+
+func (c *PurgeExpired) Execute(ctx Context) error {
+	if ctx.config == nil {
+		return errors.New("purge-expired requires a valid configuration file")
+	}
+
+	if err := c.parseArgs(); err != nil {
+		return err
+	}
+
+	cardholders := getCardholders(ctx)
+	purged := []expiringCard{}
+	errs := []error{}
+
+	for _, device := range ctx.devices {
+		deviceID := device.DeviceID
+
+		cards, err := getCards(ctx, deviceID)
+		if err != nil {
+			fmt.Printf("   ... %v  ERROR  %v\n", deviceID, err)
+			errs = append(errs, fmt.Errorf("%v: %v", deviceID, err))
+			continue
+		}
+
+		expired := c.expired(cards, deviceID, today(device))
+		deleted := 0
+		failed := 0
+
+		for _, v := range expired {
+			if c.dryrun {
+				purged = append(purged, v)
+				continue
+			}
+
+			if ok, err := ctx.uhppote.DeleteCard(deviceID, v.card.CardNumber); err != nil {
+				errs = append(errs, fmt.Errorf("%v: error deleting card %v (%v)", deviceID, v.card.CardNumber, err))
+				failed++
+			} else if !ok {
+				errs = append(errs, fmt.Errorf("%v: failed to delete card %v", deviceID, v.card.CardNumber))
+				failed++
+			} else {
+				purged = append(purged, v)
+				deleted++
+			}
+		}
+
+		switch {
+		case c.dryrun:
+			fmt.Printf("   ... %v  %v of %v cards expired (dry run)\n", deviceID, len(expired), len(cards))
+
+		case failed > 0:
+			fmt.Printf("   ... %v  deleted %v of %v expired cards (%v failed)\n", deviceID, deleted, len(expired), failed)
+
+		default:
+			fmt.Printf("   ... %v  deleted %v expired cards (of %v)\n", deviceID, deleted, len(cards))
+		}
+	}
+
+	if c.dryrun && len(purged) > 0 {
+		printCards(purged, cardholders)
+	}
+
+	if c.file != "" {
+		if err := c.report(purged, cardholders); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Returns the cards with a 'To' date before today.
+func (c *PurgeExpired) expired(cards []types.Card, controller uint32, today types.Date) []expiringCard {
+	list := []expiringCard{}
+
+	for _, card := range cards {
+		if !card.To.IsZero() && card.To.Before(today) {
+			list = append(list, expiringCard{
+				controller: controller,
+				card:       card,
+			})
+		}
+	}
+
+	return list
+}
+
+// Writes the purged cards to a TSV file.
+func (c *PurgeExpired) report(purged []expiringCard, cardholders cardholders) error {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "Controller\tCard Number\tFrom\tTo\tCardholder\n")
+	for _, v := range purged {
+		fmt.Fprintf(&b, "%v\t%v\t%v\t%v\t%v\n", v.controller, v.card.CardNumber, v.card.From, v.card.To, cardholders.annotate(v.card.CardNumber))
+	}
+
+	return os.WriteFile(c.file, b.Bytes(), 0660)
+}
+
+func (c *PurgeExpired) parseArgs() error {
+	flagset := newFlagSet()
+	dryrun := flagset.Bool("dry-run", false, "Lists the expired cards without deleting them")
+	file := ""
+	args := flag.Args()[1:]
+
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+
+	// ... file
+	if len(flagset.Args()) > 0 {
+		file = flagset.Arg(0)
+		stat, err := os.Stat(file)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		} else if err == nil && stat.Mode().IsDir() {
+			return fmt.Errorf("file '%s' is a directory", file)
+		} else if err == nil && !stat.Mode().IsRegular() {
+			return fmt.Errorf("file '%s' is not a real file", file)
+		}
+	}
+
+	c.file = file
+	c.dryrun = *dryrun
+
+	return nil
+}
+
+func (c *PurgeExpired) CLI() string {
+	return "purge-expired"
+}
+
+func (c *PurgeExpired) Description() string {
+	return "Deletes expired cards from all configured controllers"
+}
+
+func (c *PurgeExpired) Usage() string {
+	return "[--dry-run] [TSV file]"
+}
+
+func (c *PurgeExpired) Help() {
+	fmt.Println("Usage: uhppote-cli [options] purge-expired [--dry-run] [TSV file]")
+	fmt.Println()
+	fmt.Println(" Deletes the cards with a 'To' date before today (in the controller timezone) from the controllers defined in the")
+	fmt.Println(" configuration file and displays a summary for each controller.")
+	fmt.Println()
+	fmt.Println("  <TSV file>  (optional) file to which to write the list of deleted (or, for --dry-run, expired) cards")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --config  File path for the 'conf' file containing the controller configuration")
+	fmt.Printf("              (defaults to %s)\n", config.DefaultConfig)
+	fmt.Println("    --debug   Displays internal information for diagnosing errors")
+	fmt.Println()
+	fmt.Println("    --dry-run Lists the expired cards for each controller without deleting them")
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println()
+	fmt.Println("    uhppote-cli purge-expired --dry-run expired.tsv")
+	fmt.Println("    uhppote-cli purge-expired --dry-run")
+	fmt.Println()
+	fmt.Println("    > ... 405419896  2 of 120 cards expired (dry run)")
+	fmt.Println("      ... 303986753  0 of 17 cards expired (dry run)")
+	fmt.Println()
+	fmt.Println("      405419896  10058400  2025-01-01  2026-06-30  Alice")
+	fmt.Println("      405419896  10058401  2025-01-01  2026-09-30")
+	fmt.Println()
+	fmt.Println("    uhppote-cli purge-expired")
+	fmt.Println()
+	fmt.Println("    > ... 405419896  deleted 3 expired cards (of 120)")
+	fmt.Println("      ... 303986753  deleted 0 expired cards (of 17)")
+	fmt.Println()
+}
+
+// Returns true - configuration is not optional for this command to return valid information.
+func (c *PurgeExpired) RequiresConfig() bool {
+	return true
+}
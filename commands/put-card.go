@@ -81,7 +81,7 @@ func (c *PutCard) Execute(ctx Context) error {
 				}
 
 			case 4:
-				if v, err := getPermissions(ix); err != nil {
+				if v, err := getPermissions(ix, getProfileNames(ctx)); err != nil {
 					return err
 				} else {
 					permissions = v
@@ -106,7 +106,7 @@ func (c *PutCard) Execute(ctx Context) error {
 			if profile, err := ctx.uhppote.GetTimeProfile(serialNumber, uint8(v)); err != nil {
 				return err
 			} else if profile == nil {
-				return fmt.Errorf("time profile %v is not defined", v)
+				return fmt.Errorf("time profile %v is not defined on controller %v", getProfileNames(ctx).describe(v), serialNumber)
 			}
 		}
 	}
@@ -157,7 +157,9 @@ func (c *PutCard) Help() {
 	fmt.Println("  <card number>    (required) card number")
 	fmt.Println("  <start date>     (required) start date YYYY-MM-DD")
 	fmt.Println("  <end date>       (required) end date   YYYY-MM-DD")
-	fmt.Println("  <doors>          (required) list of permitted doors [1,2,3,4]. Unlisted doors will be set to 'N'")
+	fmt.Println("  <doors>          (required) list of permitted doors [1,2,3,4]. Unlisted doors will be set to 'N'. A door may")
+	fmt.Println("                   be assigned a time profile as door:profile, where the time profile is either the time profile")
+	fmt.Println("                   ID or a time profile name defined in the configuration e.g. 1,2:29,4:business-hours")
 	fmt.Println()
 	fmt.Println("  Options:")
 	fmt.Println()
@@ -168,6 +170,7 @@ func (c *PutCard) Help() {
 	fmt.Println("  Examples:")
 	fmt.Println()
 	fmt.Println("    uhppote-cli put-card 12345678 918273645 2020-01-01 2020-12-31 1,2,4")
+	fmt.Println("    uhppote-cli put-card 12345678 918273645 2020-01-01 2020-12-31 1,2:29,4:business-hours")
 	fmt.Println()
 }
 
@@ -176,13 +179,23 @@ func (c *PutCard) RequiresConfig() bool {
 	return false
 }
 
-func getPermissions(index int) (map[uint8]uint8, error) {
+func getPermissions(index int, profiles profileNames) (map[uint8]uint8, error) {
 	permissions := map[uint8]uint8{1: 0, 2: 0, 3: 0, 4: 0}
 
 	if len(flag.Args()) > index {
 		tokens := strings.SplitSeq(flag.Arg(index), ",")
 
 		for token := range tokens {
+			if match := regexp.MustCompile(`^\s*([1-4]):(.*[^0-9\s].*)$`).FindStringSubmatch(token); match != nil {
+				if profile, err := profiles.resolve(match[2]); err != nil {
+					return nil, err
+				} else {
+					door, _ := strconv.ParseUint(match[1], 10, 8)
+					permissions[uint8(door)] = profile
+					continue
+				}
+			}
+
 			match := regexp.MustCompile("([1-4])(?::([0-9]+))?").FindStringSubmatch(token)
 			if len(match) < 3 {
 				return nil, fmt.Errorf("invalid door '%v'", token)
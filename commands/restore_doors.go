@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+var RestoreDoorsCmd = RestoreDoors{}
+
+// RestoreDoors restores the door control modes and delays recorded in the door snapshots by
+// 'lockdown' and 'evacuate'.
+type RestoreDoors struct {
+}
+
+func (c *RestoreDoors) Execute(ctx Context) error {
+	flagset := newFlagSet()
+
+	if err := flagset.Parse(flag.Args()[1:]); err != nil {
+		return err
+	}
+
+	snapshots, err := getDoorSnapshots(ctx)
+	if err != nil {
+		return err
+	}
+
+	if flagset.NArg() > 0 {
+		controllers, err := overrideControllers(ctx, flagset.Args())
+		if err != nil {
+			return err
+		}
+
+		snapshots = slices.DeleteFunc(snapshots, func(s doorSnapshot) bool {
+			return !slices.Contains(controllers, s.Controller)
+		})
+	}
+
+	if len(snapshots) == 0 {
+		fmt.Println("   ... no door snapshots to restore")
+		return nil
+	}
+
+	results := make([][]doorResult, len(snapshots))
+	wg := sync.WaitGroup{}
+
+	for i, snapshot := range snapshots {
+		wg.Go(func() {
+			results[i] = c.restore(ctx, snapshot)
+		})
+	}
+
+	wg.Wait()
+
+	return reportDoors(c.CLI(), slices.Concat(results...))
+}
+
+// Restores the doors in a snapshot and then removes the restored doors from the snapshot. The
+// snapshot is deleted once all the doors have been restored.
+func (c *RestoreDoors) restore(ctx Context, snapshot doorSnapshot) []doorResult {
+	results := []doorResult{}
+
+	for _, door := range slices.Sorted(maps.Keys(snapshot.Doors)) {
+		d := snapshot.Doors[door]
+		result := doorResult{
+			controller: snapshot.Controller,
+			door:       door,
+			name:       d.Name,
+			mode:       d.Mode,
+		}
+
+		if state, err := ctx.uhppote.SetDoorControlState(snapshot.Controller, door, d.Mode, d.Delay); err != nil {
+			result.err = err
+		} else if state == nil || state.ControlState != d.Mode {
+			result.err = fmt.Errorf("door control mode not set")
+		} else {
+			delete(snapshot.Doors, door)
+		}
+
+		results = append(results, result)
+	}
+
+	if len(snapshot.Doors) == 0 {
+		if err := deleteDoorSnapshot(ctx, snapshot.Controller); err != nil {
+			fmt.Fprintf(os.Stderr, "   WARN  error removing door snapshot for %v (%v)\n", snapshot.Controller, err)
+		}
+	} else if err := saveDoorSnapshot(ctx, snapshot); err != nil {
+		fmt.Fprintf(os.Stderr, "   WARN  error updating door snapshot for %v (%v)\n", snapshot.Controller, err)
+	}
+
+	return results
+}
+
+func (c *RestoreDoors) CLI() string {
+	return "restore-doors"
+}
+
+func (c *RestoreDoors) Description() string {
+	return "Restores the doors after a lockdown or evacuation"
+}
+
+func (c *RestoreDoors) Usage() string {
+	return "[serial number...]"
+}
+
+func (c *RestoreDoors) Help() {
+	fmt.Println("Usage: uhppote-cli [options] restore-doors [serial number...]")
+	fmt.Println()
+	fmt.Println(" Restores the door control modes and delays recorded by 'lockdown' or 'evacuate'. The controllers are updated")
+	fmt.Println(" concurrently. Doors that could not be restored are reported on stderr, remain in the snapshot for a subsequent")
+	fmt.Println(" 'restore-doors' and the command exits with an error.")
+	fmt.Println()
+	fmt.Println("  serial number  controller serial number (or name). Defaults to all the controllers with a door snapshot")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --config  File path for the 'conf' file containing the controller configuration")
+	fmt.Printf("              (defaults to %s)\n", config.DefaultConfig)
+	fmt.Println("    --debug   Displays internal information for diagnosing errors")
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println()
+	fmt.Println("    uhppote-cli restore-doors")
+	fmt.Println("    uhppote-cli restore-doors 405419896")
+	fmt.Println()
+}
+
+// Returns false - configuration is useful but optional.
+func (c *RestoreDoors) RequiresConfig() bool {
+	return false
+}
@@ -18,7 +18,7 @@ func (c *Revoke) Execute(ctx Context) error {
 		return fmt.Errorf("revoke requires a valid configuration file")
 	}
 
-	cardNumber, err := getUint32(1, "Missing card number", "Invalid card number: %v")
+	cardNumber, err := getCardNumber(ctx, 1, "Missing card number", "Invalid card number: %v")
 	if err != nil {
 		return err
 	}
@@ -62,15 +62,15 @@ func (c *Revoke) Description() string {
 }
 
 func (c *Revoke) Usage() string {
-	return "<card number> <doors>"
+	return "<card number|name> <doors>"
 }
 
 func (c *Revoke) Help() {
-	fmt.Println("Usage: uhppote-cli [options] revoke <card number> <doors>")
+	fmt.Println("Usage: uhppote-cli [options] revoke <card number|name> <doors>")
 	fmt.Println()
 	fmt.Println(" Revokes access permissions for a card")
 	fmt.Println()
-	fmt.Println("  <card number>    (required) card number")
+	fmt.Println("  <card number>    (required) card number or cardholder name from the cardholder registry")
 	fmt.Println("  <doors>          (required) comma separated list of permitted doors e.g. Front Door, Workshop")
 	fmt.Println("                              Doors are case- and space insensitive and correspond to the doors")
 	fmt.Println("                              defined in the config file). 'revoked' permissions are REMOVED from")
@@ -88,6 +88,7 @@ func (c *Revoke) Help() {
 	fmt.Println("  Examples:")
 	fmt.Println()
 	fmt.Println("    uhppote-cli -debug --config .config revoke 918273645 Front Door, Workshop")
+	fmt.Println(`    uhppote-cli revoke "Eduardo Ramos" Front Door, Workshop`)
 	fmt.Println()
 }
 
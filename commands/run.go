@@ -0,0 +1,350 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+var RunCmd = Run{}
+
+// Run executes a script of CLI command lines in a single process, sharing the configuration,
+// settings and controller connection between the commands. Scripts may define variables, set
+// the current controller and choose whether to continue or abort when a command fails.
+type Run struct {
+	commands []Command
+}
+
+// scriptResult is the outcome of a single script line.
+type scriptResult struct {
+	line    int
+	command string
+	err     error
+	warning error
+	skipped bool
+}
+
+// scriptVariables is a flag.Value for the repeatable --var NAME=value option.
+type scriptVariables map[string]string
+
+const (
+	onErrorAbort    = "abort"
+	onErrorContinue = "continue"
+)
+
+// Sets the commands available to a script.
+func (c *Run) SetCommands(commands []Command) {
+	c.commands = commands
+}
+
+func (c *Run) Execute(ctx Context) error {
+	vars := scriptVariables{}
+
+	flagset := newFlagSet()
+	onError := flagset.String("on-error", onErrorAbort, "Action on a failed command ('continue' or 'abort')")
+	flagset.Var(&vars, "var", "Defines a script variable e.g. --var CONTROLLER=405419896")
+
+	if err := flagset.Parse(flag.Args()[1:]); err != nil {
+		return err
+	}
+
+	if flagset.NArg() < 1 {
+		return fmt.Errorf("missing script file")
+	} else if *onError != onErrorAbort && *onError != onErrorContinue {
+		return fmt.Errorf("invalid --on-error '%v' (expected 'continue' or 'abort')", *onError)
+	}
+
+	var r io.Reader = os.Stdin
+	if file := flagset.Arg(0); file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		r = f
+	}
+
+	results, err := c.run(ctx, r, vars, *onError)
+	if err != nil {
+		return err
+	}
+
+	return reportScript(results)
+}
+
+// Executes the script lines in order. Lines after a failed command are skipped if the
+// 'on-error' mode is 'abort'.
+func (c *Run) run(ctx Context, r io.Reader, vars scriptVariables, onError string) ([]scriptResult, error) {
+	// ... invalid command options fail the script line rather than exiting
+	mode := flagErrorHandling
+	flagErrorHandling = flag.ContinueOnError
+	defer func() {
+		flagErrorHandling = mode
+	}()
+
+	shell := Shell{
+		commands: c.commands,
+		cards:    map[uint32]bool{},
+	}
+
+	results := []scriptResult{}
+	aborted := false
+	exited := false
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		words, err := splitLine(text)
+		if err == nil && (len(words) == 0 || strings.HasPrefix(words[0], "#")) {
+			continue
+		} else if exited {
+			break
+		}
+
+		result := scriptResult{
+			line:    line,
+			command: text,
+		}
+
+		if aborted {
+			result.skipped = true
+			results = append(results, result)
+			continue
+		}
+
+		if err == nil {
+			words, err = vars.expand(words)
+		}
+
+		if err == nil {
+			result.command = joinWords(words)
+			fmt.Printf("%v> %v\n", line, result.command)
+
+			switch words[0] {
+			case "set":
+				err = vars.define(words[1:])
+
+			case "on-error":
+				if len(words) != 2 || (words[1] != onErrorAbort && words[1] != onErrorContinue) {
+					err = fmt.Errorf("invalid 'on-error' (expected 'on-error continue' or 'on-error abort')")
+				} else {
+					onError = words[1]
+				}
+
+			case "use":
+				err = shell.use(ctx, words[1:])
+
+			case "exit":
+				exited = true
+
+			default:
+				err = c.exec(ctx, &shell, words)
+			}
+		}
+
+		if errors.Is(err, ErrDrift) {
+			result.warning = err
+			fmt.Fprintf(os.Stderr, "\n   WARN:  %v\n\n", err)
+		} else if err != nil {
+			result.err = err
+			fmt.Fprintf(os.Stderr, "\n   ERROR: %v\n\n", err)
+			aborted = onError == onErrorAbort
+		}
+
+		results = append(results, result)
+	}
+
+	return results, scanner.Err()
+}
+
+func (c *Run) exec(ctx Context, shell *Shell, words []string) error {
+	cmd := shell.lookup(words[0])
+	if cmd == nil {
+		return fmt.Errorf("unknown command '%v'", words[0])
+	}
+
+	switch cmd.CLI() {
+	case "run", "shell", "tui":
+		return fmt.Errorf("'%v' cannot be used in a script", cmd.CLI())
+	}
+
+	return shell.execute(ctx, cmd, words)
+}
+
+// Prints the per-line summary and returns an error if any of the script lines failed.
+func reportScript(results []scriptResult) error {
+	table := [][]string{}
+	failed := 0
+	skipped := 0
+
+	for _, r := range results {
+		status := "ok"
+		switch {
+		case r.skipped:
+			status = "skipped"
+			skipped++
+		case r.err != nil:
+			status = fmt.Sprintf("FAILED (%v)", r.err)
+			failed++
+		case r.warning != nil:
+			status = fmt.Sprintf("ok    %v", r.warning)
+		}
+
+		table = append(table, []string{
+			fmt.Sprintf("%v", r.line),
+			r.command,
+			status,
+		})
+	}
+
+	fmt.Println()
+	for _, line := range format(table) {
+		fmt.Printf("   %v\n", strings.TrimSpace(line))
+	}
+	fmt.Println()
+
+	if failed > 0 && skipped > 0 {
+		return fmt.Errorf("%v of %v script lines failed (%v skipped)", failed, len(results), skipped)
+	} else if failed > 0 {
+		return fmt.Errorf("%v of %v script lines failed", failed, len(results))
+	}
+
+	return nil
+}
+
+// Replaces $NAME and ${NAME} in each word with the variable value. '$$' is a literal '$'.
+func (v scriptVariables) expand(words []string) ([]string, error) {
+	re := regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+	expanded := []string{}
+
+	for _, word := range words {
+		var err error
+
+		s := re.ReplaceAllStringFunc(word, func(m string) string {
+			if m == "$$" {
+				return "$"
+			}
+
+			match := re.FindStringSubmatch(m)
+			name := match[1] + match[2]
+			if value, ok := v[name]; ok {
+				return value
+			}
+
+			err = fmt.Errorf("undefined variable '%v'", name)
+			return m
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		expanded = append(expanded, s)
+	}
+
+	return expanded, nil
+}
+
+// Defines a variable from a 'set NAME value' script line.
+func (v scriptVariables) define(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("invalid 'set' (expected 'set NAME value')")
+	} else if !regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`).MatchString(args[0]) {
+		return fmt.Errorf("invalid variable name '%v'", args[0])
+	}
+
+	v[args[0]] = args[1]
+
+	return nil
+}
+
+func (v *scriptVariables) String() string {
+	list := []string{}
+	for name, value := range *v {
+		list = append(list, fmt.Sprintf("%v=%v", name, value))
+	}
+
+	return strings.Join(list, " ")
+}
+
+func (v *scriptVariables) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("invalid variable '%v' (expected NAME=value)", s)
+	}
+
+	return v.define([]string{strings.TrimSpace(name), value})
+}
+
+// Joins words into a command line, quoting words that contain spaces.
+func joinWords(words []string) string {
+	list := []string{}
+	for _, w := range words {
+		if w == "" || strings.ContainsAny(w, " \t") {
+			list = append(list, fmt.Sprintf("'%v'", w))
+		} else {
+			list = append(list, w)
+		}
+	}
+
+	return strings.Join(list, " ")
+}
+
+func (c *Run) CLI() string {
+	return "run"
+}
+
+func (c *Run) Description() string {
+	return "Executes a script of CLI commands"
+}
+
+func (c *Run) Usage() string {
+	return "[--var NAME=value...] [--on-error continue|abort] <script>"
+}
+
+func (c *Run) Help() {
+	fmt.Println("Usage: uhppote-cli [options] run [--var NAME=value...] [--on-error continue|abort] <script>")
+	fmt.Println()
+	fmt.Println(" Executes a script of CLI command lines in a single process, sharing the configuration and controller")
+	fmt.Println(" connection between the commands, and prints a success/failure summary for each line. A script line is")
+	fmt.Println(" a CLI command (without the 'uhppote-cli' and global options) or one of:")
+	fmt.Println()
+	fmt.Println("    # ...                      comment")
+	fmt.Println("    set NAME value             defines a variable, used as $NAME or ${NAME} in later lines ($$ for a '$')")
+	fmt.Println("    on-error continue|abort    continues with (or skips) the remaining lines after a failed command")
+	fmt.Println("    use <controller>           sets the current controller for commands that require a serial number")
+	fmt.Println("    exit                       ends the script")
+	fmt.Println()
+	fmt.Println(" The script exits with an error if any line failed. Invalid command options fail the line.")
+	fmt.Println()
+	fmt.Println("  script  script file ('-' for stdin)")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --config    File path for the 'conf' file containing the controller configuration")
+	fmt.Printf("                (defaults to %s)\n", config.DefaultConfig)
+	fmt.Println("    --debug     Displays internal information for diagnosing errors")
+	fmt.Println()
+	fmt.Println("    --var       Defines a script variable e.g. --var CONTROLLER=405419896. May be repeated")
+	fmt.Println("    --on-error  Initial action on a failed command ('continue' or 'abort'). Defaults to 'abort'")
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println()
+	fmt.Println("    uhppote-cli run provision.txt")
+	fmt.Println("    uhppote-cli run --var CONTROLLER=405419896 --on-error continue provision.txt")
+	fmt.Println()
+}
+
+// Returns false - configuration is useful but optional.
+func (c *Run) RequiresConfig() bool {
+	return false
+}
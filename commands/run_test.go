@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunScript(t *testing.T) {
+	ctx := shellContext(t)
+	getCard := shellCommand{cli: "get-card", usage: "<serial number> <card number>"}
+	open := shellCommand{cli: "open", usage: "<serial number> <door>", err: fmt.Errorf("timeout")}
+	unlock := shellCommand{cli: "unlock", usage: "<door> --for <duration> | --restore [--all]"}
+
+	c := Run{
+		commands: []Command{&getCard, &open, &unlock},
+	}
+
+	script := strings.Join([]string{
+		"# provisioning",
+		"set CARD 10058400",
+		"use $CONTROLLER",
+		"",
+		"get-card ${CARD}",
+		"unlock '$DOOR' --for 45m",
+		"on-error continue",
+		"open 1",
+		"get-card $CARD1",
+		"get-card 10058401",
+		"on-error abort",
+		"open 405419896 2",
+		"get-card 10058402",
+	}, "\n")
+
+	vars := scriptVariables{"CONTROLLER": "Beta", "DOOR": "Great Hall"}
+
+	results, err := c.run(ctx, strings.NewReader(script), vars, onErrorAbort)
+	if err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	expected := [][]string{
+		{"get-card", "303986753", "10058400"},
+		{"get-card", "303986753", "10058401"},
+	}
+
+	if !reflect.DeepEqual(getCard.args, expected) {
+		t.Errorf("incorrect get-card arguments\n   expected:%q\n   got:     %q", expected, getCard.args)
+	}
+
+	if expected := [][]string{{"unlock", "Great Hall", "--for", "45m"}}; !reflect.DeepEqual(unlock.args, expected) {
+		t.Errorf("incorrect unlock arguments\n   expected:%q\n   got:     %q", expected, unlock.args)
+	}
+
+	if len(open.args) != 2 {
+		t.Errorf("incorrect number of 'open' commands - expected:%v, got:%v", 2, len(open.args))
+	}
+
+	summary := []string{}
+	for _, r := range results {
+		switch {
+		case r.skipped:
+			summary = append(summary, fmt.Sprintf("%v skipped", r.line))
+		case r.err != nil:
+			summary = append(summary, fmt.Sprintf("%v %v", r.line, r.err))
+		default:
+			summary = append(summary, fmt.Sprintf("%v ok", r.line))
+		}
+	}
+
+	if expected := []string{
+		"2 ok",
+		"3 ok",
+		"5 ok",
+		"6 ok",
+		"7 ok",
+		"8 timeout",
+		"9 undefined variable 'CARD1'",
+		"10 ok",
+		"11 ok",
+		"12 timeout",
+		"13 skipped",
+	}; !reflect.DeepEqual(summary, expected) {
+		t.Errorf("incorrect summary\n   expected:%q\n   got:     %q", expected, summary)
+	}
+
+	if err := reportScript(results); err == nil || err.Error() != "3 of 11 script lines failed (1 skipped)" {
+		t.Errorf("incorrect script error (%v)", err)
+	}
+}
+
+func TestRunScriptInvalidOptions(t *testing.T) {
+	ctx := shellContext(t)
+	unlock := optionsCommand{shellCommand: shellCommand{cli: "unlock"}}
+
+	c := Run{
+		commands: []Command{&unlock},
+	}
+
+	script := strings.Join([]string{
+		"unlock --for 45m",
+		"on-error continue",
+		"unlock --bogus",
+		"on-error abort",
+		"unlock --for never",
+		"unlock --for 15m",
+	}, "\n")
+
+	results, err := c.run(ctx, strings.NewReader(script), scriptVariables{}, onErrorAbort)
+	if err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	summary := []string{}
+	for _, r := range results {
+		switch {
+		case r.skipped:
+			summary = append(summary, fmt.Sprintf("%v skipped", r.line))
+		case r.err != nil:
+			summary = append(summary, fmt.Sprintf("%v failed", r.line))
+		default:
+			summary = append(summary, fmt.Sprintf("%v ok", r.line))
+		}
+	}
+
+	if expected := []string{"1 ok", "2 ok", "3 failed", "4 ok", "5 failed", "6 skipped"}; !reflect.DeepEqual(summary, expected) {
+		t.Errorf("incorrect results\n   expected:%v\n   got:     %v", expected, summary)
+	}
+
+	if expected := []time.Duration{45 * time.Minute}; !reflect.DeepEqual(unlock.periods, expected) {
+		t.Errorf("incorrect options\n   expected:%v\n   got:     %v", expected, unlock.periods)
+	}
+
+	if flagErrorHandling != flag.ExitOnError {
+		t.Errorf("flag error handling not restored after script")
+	}
+}
+
+func TestRunScriptVariables(t *testing.T) {
+	vars := scriptVariables{"A": "405419896", "B": "Great Hall"}
+
+	if words, err := vars.expand([]string{"$A", "${B}", "x${A}y", "$$A", "10$$"}); err != nil {
+		t.Errorf("unexpected error (%v)", err)
+	} else if expected := []string{"405419896", "Great Hall", "x405419896y", "$A", "10$"}; !reflect.DeepEqual(words, expected) {
+		t.Errorf("incorrect expansion - expected:%q, got:%q", expected, words)
+	}
+
+	for _, args := range [][]string{{"A"}, {"1A", "x"}, {"A", "x", "y"}} {
+		if err := vars.define(args); err == nil {
+			t.Errorf("expected error for 'set %v'", strings.Join(args, " "))
+		}
+	}
+
+	if err := vars.Set("C=one two"); err != nil || vars["C"] != "one two" {
+		t.Errorf("--var not set (%v)", err)
+	}
+}
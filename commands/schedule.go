@@ -0,0 +1,469 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+// schedule is the set of time profiles and tasks defined in a schedule file. A schedule file
+// is a text file with one time profile or task per line, e.g.:
+//
+//	# office hours
+//	29: Mon-Fri 08:00-12:00,13:00-17:00 from 2026-01-01 to 2026-12-31 then 30
+//	30: Sat,Sun 09:00-13:00 from 2026-01-01 to 2026-12-31
+//
+//	door 1 lock door: Mon-Fri 18:00 from 2026-01-01 to 2026-12-31
+//	door 2 enable more cards: Mon-Fri 08:00 from 2026-01-01 to 2026-12-31 cards 3
+//
+// Time profiles are labelled with the profile ID (optionally prefixed with 'profile'), tasks
+// with the door and task type. Weekdays default to every day if not specified and a task start
+// time defaults to 00:00. Everything after a '#' is a comment. Time segments are numbered in
+// order, so an unused segment before a used segment is written as 00:00-00:00 e.g.
+//
+//	31: Sat,Sun 00:00-00:00,09:00-13:00 from 2026-01-01 to 2026-12-31
+type schedule struct {
+	profiles []types.TimeProfile
+	tasks    []types.Task
+}
+
+// scheduleEntry is the parsed specification of a single schedule line, before it is compiled
+// into a time profile or task.
+type scheduleEntry struct {
+	weekdays *types.Weekdays
+	segments []types.Segment
+	start    *types.HHmm
+	from     *types.Date
+	to       *types.Date
+	linked   *uint8
+	cards    *uint8
+}
+
+var weekdayNames = []struct {
+	day  time.Weekday
+	name string
+}{
+	{time.Monday, "Mon"},
+	{time.Tuesday, "Tue"},
+	{time.Wednesday, "Wed"},
+	{time.Thursday, "Thu"},
+	{time.Friday, "Fri"},
+	{time.Saturday, "Sat"},
+	{time.Sunday, "Sun"},
+}
+
+// Returns true if the file is a schedule file i.e. has a .schedule extension.
+func isScheduleFile(file string) bool {
+	return strings.EqualFold(filepath.Ext(file), ".schedule")
+}
+
+func loadSchedule(file string) (schedule, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return schedule{}, err
+	}
+
+	return parseSchedule(b)
+}
+
+// Compiles the time profiles and tasks in a schedule file. Errors are reported with the line
+// number of the offending entry.
+func parseSchedule(b []byte) (schedule, error) {
+	s := schedule{
+		profiles: []types.TimeProfile{},
+		tasks:    []types.Task{},
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		if text = strings.TrimSpace(text); text == "" {
+			continue
+		}
+
+		label, spec, ok := strings.Cut(text, ":")
+		if !ok {
+			return s, fmt.Errorf("line %v: missing ':' after time profile or task label", line)
+		}
+
+		label = strings.TrimSpace(label)
+		if match := regexp.MustCompile(`^(?i:profile\s+)?([0-9]+)$`).FindStringSubmatch(label); match != nil {
+			if profile, err := compileTimeProfile(match[1], spec); err != nil {
+				return s, fmt.Errorf("line %v: %v", line, err)
+			} else {
+				s.profiles = append(s.profiles, profile)
+			}
+		} else if match := regexp.MustCompile(`^(?i:door)\s+([0-9]+)\s+(.+)$`).FindStringSubmatch(label); match != nil {
+			if task, err := compileTask(match[1], match[2], spec); err != nil {
+				return s, fmt.Errorf("line %v: %v", line, err)
+			} else {
+				s.tasks = append(s.tasks, task)
+			}
+		} else {
+			return s, fmt.Errorf("line %v: unrecognised time profile or task '%v'", line, label)
+		}
+	}
+
+	return s, scanner.Err()
+}
+
+func compileTimeProfile(id, spec string) (types.TimeProfile, error) {
+	profileID, err := strconv.ParseUint(id, 10, 8)
+	if err != nil || profileID < 2 || profileID > 254 {
+		return types.TimeProfile{}, fmt.Errorf("invalid time profile ID (%v) - valid range is from 2 to 254", id)
+	}
+
+	entry, err := parseScheduleEntry(spec)
+	if err != nil {
+		return types.TimeProfile{}, fmt.Errorf("profile %v: %v", profileID, err)
+	}
+
+	switch {
+	case entry.from == nil:
+		return types.TimeProfile{}, fmt.Errorf("profile %v: missing 'from' date", profileID)
+	case entry.to == nil:
+		return types.TimeProfile{}, fmt.Errorf("profile %v: missing 'to' date", profileID)
+	case entry.start != nil:
+		return types.TimeProfile{}, fmt.Errorf("profile %v: invalid start time %v (expected a time segment e.g. 08:00-17:00)", profileID, entry.start)
+	case entry.cards != nil:
+		return types.TimeProfile{}, fmt.Errorf("profile %v: 'cards' is only valid for tasks", profileID)
+	case len(entry.segments) > 3:
+		return types.TimeProfile{}, fmt.Errorf("profile %v: too many time segments (maximum is 3)", profileID)
+	}
+
+	profile := types.TimeProfile{
+		ID:       uint8(profileID),
+		From:     *entry.from,
+		To:       *entry.to,
+		Weekdays: *entry.weekdays,
+		Segments: types.Segments{
+			1: types.Segment{},
+			2: types.Segment{},
+			3: types.Segment{},
+		},
+	}
+
+	for i, segment := range entry.segments {
+		profile.Segments[uint8(i+1)] = segment
+	}
+
+	if entry.linked != nil {
+		if *entry.linked == profile.ID {
+			return types.TimeProfile{}, fmt.Errorf("profile %v: invalid linked profile (link to self creates circular reference)", profileID)
+		}
+
+		profile.LinkedProfileID = *entry.linked
+	}
+
+	return profile, nil
+}
+
+func compileTask(door, taskType, spec string) (types.Task, error) {
+	d, err := strconv.ParseUint(door, 10, 8)
+	if err != nil || d < 1 || d > 4 {
+		return types.Task{}, fmt.Errorf("invalid door (%v) - valid range is [1..4]", door)
+	}
+
+	t, ok := lookupTaskType(taskType)
+	if !ok {
+		return types.Task{}, fmt.Errorf("invalid task '%v'", strings.TrimSpace(taskType))
+	}
+
+	entry, err := parseScheduleEntry(spec)
+	if err != nil {
+		return types.Task{}, fmt.Errorf("door %v %v: %v", d, t, err)
+	}
+
+	switch {
+	case entry.from == nil:
+		return types.Task{}, fmt.Errorf("door %v %v: missing 'from' date", d, t)
+	case entry.to == nil:
+		return types.Task{}, fmt.Errorf("door %v %v: missing 'to' date", d, t)
+	case len(entry.segments) > 0:
+		return types.Task{}, fmt.Errorf("door %v %v: invalid time segment (expected a start time e.g. 08:00)", d, t)
+	case entry.linked != nil:
+		return types.Task{}, fmt.Errorf("door %v %v: 'then' is only valid for time profiles", d, t)
+	case entry.cards != nil && t != types.EnableMoreCards:
+		return types.Task{}, fmt.Errorf("door %v %v: 'cards' is only valid for the 'enable more cards' task", d, t)
+	}
+
+	task := types.Task{
+		Task:     t,
+		Door:     uint8(d),
+		From:     *entry.from,
+		To:       *entry.to,
+		Weekdays: *entry.weekdays,
+		Start:    types.NewHHmm(0, 0),
+	}
+
+	if entry.start != nil {
+		task.Start = *entry.start
+	}
+
+	if entry.cards != nil {
+		task.Cards = *entry.cards
+	}
+
+	return task, nil
+}
+
+// Parses the specification part of a schedule entry e.g.
+//
+//	Mon-Fri 08:00-12:00,13:00-17:00 from 2026-01-01 to 2026-12-31 then 30
+func parseScheduleEntry(spec string) (scheduleEntry, error) {
+	entry := scheduleEntry{}
+	tokens := strings.Fields(spec)
+
+	next := func(i int, keyword string) (string, error) {
+		if i+1 >= len(tokens) {
+			return "", fmt.Errorf("missing value for '%v'", keyword)
+		}
+
+		return tokens[i+1], nil
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		keyword := strings.ToLower(token)
+
+		switch {
+		case keyword == "from" || keyword == "to":
+			v, err := next(i, keyword)
+			if err != nil {
+				return entry, err
+			}
+
+			date, err := types.ParseDate(v)
+			if err != nil {
+				return entry, fmt.Errorf("invalid '%v' date '%v'", keyword, v)
+			}
+
+			if keyword == "from" {
+				entry.from = &date
+			} else {
+				entry.to = &date
+			}
+
+			i++
+
+		case keyword == "then" || keyword == "cards":
+			v, err := next(i, keyword)
+			if err != nil {
+				return entry, err
+			}
+
+			n, err := strconv.ParseUint(v, 10, 8)
+			if err != nil {
+				return entry, fmt.Errorf("invalid '%v' value '%v'", keyword, v)
+			}
+
+			value := uint8(n)
+			if keyword == "then" {
+				if value < 2 || value > 254 {
+					return entry, fmt.Errorf("invalid linked profile (%v) - valid range is from 2 to 254", v)
+				}
+				entry.linked = &value
+			} else {
+				entry.cards = &value
+			}
+
+			i++
+
+		case keyword == "at":
+			v, err := next(i, keyword)
+			if err != nil {
+				return entry, err
+			}
+
+			hhmm, err := types.ParseHHmm(v)
+			if err != nil || hhmm == nil {
+				return entry, fmt.Errorf("invalid start time '%v'", v)
+			}
+
+			entry.start = hhmm
+			i++
+
+		case regexp.MustCompile(`^[0-9]{1,2}:[0-9]{2}$`).MatchString(token):
+			hhmm, err := types.ParseHHmm(token)
+			if err != nil || hhmm == nil {
+				return entry, fmt.Errorf("invalid start time '%v'", token)
+			}
+
+			entry.start = hhmm
+
+		case regexp.MustCompile(`^[0-9]{1,2}:[0-9]{2}-[0-9]{1,2}:[0-9]{2}(,[0-9]{1,2}:[0-9]{2}-[0-9]{1,2}:[0-9]{2})*$`).MatchString(token):
+			for s := range strings.SplitSeq(token, ",") {
+				start, end, _ := strings.Cut(s, "-")
+				from, err := types.ParseHHmm(start)
+				if err != nil || from == nil {
+					return entry, fmt.Errorf("invalid time segment '%v'", s)
+				}
+
+				to, err := types.ParseHHmm(end)
+				if err != nil || to == nil {
+					return entry, fmt.Errorf("invalid time segment '%v'", s)
+				}
+
+				if to.Before(*from) {
+					return entry, fmt.Errorf("invalid time segment '%v' - end is before start", s)
+				}
+
+				entry.segments = append(entry.segments, types.Segment{Start: *from, End: *to})
+			}
+
+		default:
+			weekdays, err := parseWeekdays(token)
+			if err != nil {
+				return entry, err
+			}
+
+			entry.weekdays = &weekdays
+		}
+	}
+
+	if entry.weekdays == nil {
+		weekdays := types.Weekdays{}
+		for _, d := range weekdayNames {
+			weekdays[d.day] = true
+		}
+
+		entry.weekdays = &weekdays
+	}
+
+	if entry.from != nil && entry.to != nil && entry.to.Before(*entry.from) {
+		return entry, fmt.Errorf("'to' date (%v) is before 'from' date (%v)", entry.to, entry.from)
+	}
+
+	return entry, nil
+}
+
+// Parses a list of weekdays and weekday ranges e.g. Mon-Fri or Mon,Wed,Fri or Sat-Mon. 'none'
+// is a valid list of weekdays.
+func parseWeekdays(s string) (types.Weekdays, error) {
+	weekdays := types.Weekdays{}
+	for _, d := range weekdayNames {
+		weekdays[d.day] = false
+	}
+
+	if strings.EqualFold(s, "none") {
+		return weekdays, nil
+	}
+
+	index := func(v string) (int, error) {
+		for i, d := range weekdayNames {
+			if len(v) >= 3 && strings.HasPrefix(strings.ToLower(v), strings.ToLower(d.name)) {
+				return i, nil
+			}
+		}
+
+		return 0, fmt.Errorf("unrecognised weekday '%v'", v)
+	}
+
+	for item := range strings.SplitSeq(s, ",") {
+		start, end, isRange := strings.Cut(item, "-")
+
+		from, err := index(start)
+		if err != nil {
+			return weekdays, err
+		}
+
+		to := from
+		if isRange {
+			if to, err = index(end); err != nil {
+				return weekdays, err
+			}
+		}
+
+		for i := from; ; i = (i + 1) % len(weekdayNames) {
+			weekdays[weekdayNames[i].day] = true
+			if i == to {
+				break
+			}
+		}
+	}
+
+	return weekdays, nil
+}
+
+// Formats a time profile as a schedule entry.
+func formatTimeProfile(profile types.TimeProfile) string {
+	spec := []string{formatWeekdays(profile.Weekdays)}
+
+	// ... segments up to the last defined segment, so that the segment numbers are preserved
+	segments := []string{}
+	for _, i := range []uint8{1, 2, 3} {
+		segment := profile.Segments[i]
+		segments = append(segments, fmt.Sprintf("%v-%v", segment.Start, segment.End))
+
+		if segment != (types.Segment{}) {
+			spec = append(spec[:1], strings.Join(segments, ","))
+		}
+	}
+
+	spec = append(spec, fmt.Sprintf("from %v to %v", profile.From, profile.To))
+
+	if profile.LinkedProfileID != 0 {
+		spec = append(spec, fmt.Sprintf("then %v", profile.LinkedProfileID))
+	}
+
+	return fmt.Sprintf("%v: %v", profile.ID, strings.Join(spec, " "))
+}
+
+// Formats a task as a schedule entry.
+func formatTask(task types.Task) string {
+	spec := []string{
+		formatWeekdays(task.Weekdays),
+		fmt.Sprintf("%v", task.Start),
+		fmt.Sprintf("from %v to %v", task.From, task.To),
+	}
+
+	if task.Task == types.EnableMoreCards {
+		spec = append(spec, fmt.Sprintf("cards %v", task.Cards))
+	}
+
+	return fmt.Sprintf("door %v %v: %v", task.Door, strings.ToLower(fmt.Sprintf("%v", task.Task)), strings.Join(spec, " "))
+}
+
+// Formats weekdays as a compact list of weekdays and weekday ranges e.g. Mon-Fri or Mon,Wed,Sat,Sun.
+func formatWeekdays(weekdays types.Weekdays) string {
+	list := []string{}
+
+	for i := 0; i < len(weekdayNames); i++ {
+		if !weekdays[weekdayNames[i].day] {
+			continue
+		}
+
+		j := i
+		for j+1 < len(weekdayNames) && weekdays[weekdayNames[j+1].day] {
+			j++
+		}
+
+		switch {
+		case j-i >= 2:
+			list = append(list, fmt.Sprintf("%v-%v", weekdayNames[i].name, weekdayNames[j].name))
+		case j > i:
+			list = append(list, weekdayNames[i].name, weekdayNames[j].name)
+		default:
+			list = append(list, weekdayNames[i].name)
+		}
+
+		i = j
+	}
+
+	if len(list) == 0 {
+		return "none"
+	}
+
+	return strings.Join(list, ",")
+}
@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+func TestParseSchedule(t *testing.T) {
+	text := `
+# office hours
+29: Mon-Fri 08:00-12:00,13:00-17:00 from 2026-01-01 to 2026-12-31 then 30
+profile 30: Sat,Sun 09:00-13:00 from 2026-01-01 to 2026-12-31   # weekends
+
+door 1 lock door: Mon-Fri 18:00 from 2026-01-01 to 2026-12-31
+door 2 Enable More Cards: Sat-Mon at 08:30 from 2026-01-01 to 2026-12-31 cards 3
+`
+
+	weekdays := func(days ...time.Weekday) types.Weekdays {
+		w := types.Weekdays{}
+		for _, d := range weekdayNames {
+			w[d.day] = false
+		}
+		for _, d := range days {
+			w[d] = true
+		}
+		return w
+	}
+
+	segment := func(start, end string) types.Segment {
+		return types.Segment{Start: types.MustParseHHmm(start), End: types.MustParseHHmm(end)}
+	}
+
+	expected := schedule{
+		profiles: []types.TimeProfile{
+			{
+				ID:              29,
+				LinkedProfileID: 30,
+				From:            types.MustParseDate("2026-01-01"),
+				To:              types.MustParseDate("2026-12-31"),
+				Weekdays:        weekdays(time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday),
+				Segments: types.Segments{
+					1: segment("08:00", "12:00"),
+					2: segment("13:00", "17:00"),
+					3: types.Segment{},
+				},
+			},
+			{
+				ID:       30,
+				From:     types.MustParseDate("2026-01-01"),
+				To:       types.MustParseDate("2026-12-31"),
+				Weekdays: weekdays(time.Saturday, time.Sunday),
+				Segments: types.Segments{
+					1: segment("09:00", "13:00"),
+					2: types.Segment{},
+					3: types.Segment{},
+				},
+			},
+		},
+		tasks: []types.Task{
+			{
+				Task:     types.DoorNormallyClosed,
+				Door:     1,
+				From:     types.MustParseDate("2026-01-01"),
+				To:       types.MustParseDate("2026-12-31"),
+				Weekdays: weekdays(time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday),
+				Start:    types.MustParseHHmm("18:00"),
+			},
+			{
+				Task:     types.EnableMoreCards,
+				Door:     2,
+				From:     types.MustParseDate("2026-01-01"),
+				To:       types.MustParseDate("2026-12-31"),
+				Weekdays: weekdays(time.Saturday, time.Sunday, time.Monday),
+				Start:    types.MustParseHHmm("08:30"),
+				Cards:    3,
+			},
+		},
+	}
+
+	s, err := parseSchedule([]byte(text))
+	if err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	if !reflect.DeepEqual(s, expected) {
+		t.Errorf("incorrect schedule\n   expected:%v\n   got:     %v", expected, s)
+	}
+}
+
+func TestParseScheduleWithErrors(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"29 Mon-Fri from 2026-01-01 to 2026-12-31", "line 1: missing ':' after time profile or task label"},
+		{"1: Mon-Fri from 2026-01-01 to 2026-12-31", "line 1: invalid time profile ID (1) - valid range is from 2 to 254"},
+		{"29: Mon-Fri 08:00-17:00 from 2026-01-01", "line 1: profile 29: missing 'to' date"},
+		{"29: Mon-Fri 17:00-08:00 from 2026-01-01 to 2026-12-31", "line 1: profile 29: invalid time segment '17:00-08:00' - end is before start"},
+		{"29: Mon-Fri 08:00-17:00 from 2026-01-01 to 2026-12-31 then 29", "line 1: profile 29: invalid linked profile (link to self creates circular reference)"},
+		{"29: Mon-Fry from 2026-01-01 to 2026-12-31", "line 1: profile 29: unrecognised weekday 'Fry'"},
+		{"\ndoor 5 lock door: Mon-Fri 18:00 from 2026-01-01 to 2026-12-31", "line 2: invalid door (5) - valid range is [1..4]"},
+		{"door 1 lock door: Mon-Fri 18:00 from 2026-01-01 to 2026-12-31 cards 3", "line 1: door 1 LOCK DOOR: 'cards' is only valid for the 'enable more cards' task"},
+		{"door 1 jam door: Mon-Fri 18:00 from 2026-01-01 to 2026-12-31", "line 1: invalid task 'jam door'"},
+	}
+
+	for _, test := range tests {
+		if _, err := parseSchedule([]byte(test.text)); err == nil {
+			t.Errorf("%q: expected error, got nil", test.text)
+		} else if err.Error() != test.expected {
+			t.Errorf("%q: incorrect error\n   expected:%v\n   got:     %v", test.text, test.expected, err)
+		}
+	}
+}
+
+func TestScheduleRoundTrip(t *testing.T) {
+	text := "29: Mon-Fri 08:00-12:00,13:00-17:00 from 2026-01-01 to 2026-12-31 then 30\n" +
+		"30: Mon,Wed,Sat,Sun 09:00-13:00 from 2026-01-01 to 2026-12-31\n" +
+		"31: none from 2026-01-01 to 2026-12-31\n" +
+		"32: Sat,Sun 00:00-00:00,09:00-13:00 from 2026-01-01 to 2026-12-31\n" +
+		"33: Mon-Fri 08:00-12:00,00:00-00:00,18:00-20:00 from 2026-01-01 to 2026-12-31\n" +
+		"door 1 lock door: Mon-Sun 18:00 from 2026-01-01 to 2026-12-31\n" +
+		"door 4 enable more cards: Tue,Thu 08:30 from 2026-01-01 to 2026-12-31 cards 3\n"
+
+	s, err := parseSchedule([]byte(text))
+	if err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	formatted := ""
+	for _, p := range s.profiles {
+		formatted += formatTimeProfile(p) + "\n"
+	}
+
+	for _, task := range s.tasks {
+		formatted += formatTask(task) + "\n"
+	}
+
+	if formatted != text {
+		t.Errorf("incorrect round trip\n   expected:%q\n   got:     %q", text, formatted)
+	}
+}
@@ -0,0 +1,688 @@
+package commands
+
+import (
+	"cmp"
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppote-core/uhppote"
+	"github.com/uhppoted/uhppoted-lib/acl"
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+var ServeCmd = Serve{
+	address: "127.0.0.1:8080",
+}
+
+// Serve exposes the controllers in the configuration file as a JSON REST API, authenticated
+// with a bearer token. The OpenAPI document for the API is embedded in the binary and served
+// from /openapi.json. The API is served on the loopback interface unless --http is given and
+// over HTTPS if --tls-cert and --tls-key are given.
+type Serve struct {
+	address string
+}
+
+//go:embed openapi.json
+var openapi []byte
+
+// restAPI implements the REST API handlers.
+type restAPI struct {
+	ctx   Context
+	token string
+}
+
+// statusRecord is the JSON representation of a controller status (or a received event).
+type statusRecord struct {
+	Controller     uint32         `json:"controller"`
+	SystemDateTime types.DateTime `json:"system-date-time"`
+	DoorOpen       map[uint8]bool `json:"door-open"`
+	DoorButton     map[uint8]bool `json:"door-button"`
+	DoorUnlocked   map[uint8]bool `json:"door-unlocked"`
+	Inputs         uint8          `json:"inputs"`
+	SystemError    uint8          `json:"system-error"`
+	SequenceID     uint32         `json:"sequence-id"`
+	Event          *eventRecord   `json:"event,omitempty"`
+}
+
+type eventRecord struct {
+	Index      uint32         `json:"index"`
+	Type       uint8          `json:"type"`
+	Granted    bool           `json:"granted"`
+	Door       uint8          `json:"door"`
+	Direction  uint8          `json:"direction"`
+	Card       uint32         `json:"card"`
+	Timestamp  types.DateTime `json:"timestamp"`
+	Reason     uint8          `json:"reason"`
+	ReasonText string         `json:"reason-text,omitempty"`
+}
+
+// aclRecord is the JSON representation of the access permissions for a card across all the
+// configured controllers.
+type aclRecord struct {
+	Card  uint32            `json:"card"`
+	Name  string            `json:"name,omitempty"`
+	From  types.Date        `json:"start-date"`
+	To    types.Date        `json:"end-date"`
+	Doors map[string]string `json:"doors"`
+}
+
+func (c *Serve) Execute(ctx Context) error {
+	flagset := newFlagSet()
+	address := flagset.String("http", c.address, "HTTP address on which to serve the REST API")
+	certificate := flagset.String("tls-cert", "", "TLS certificate file for HTTPS")
+	key := flagset.String("tls-key", "", "TLS private key file for HTTPS")
+
+	if err := flagset.Parse(flag.Args()[1:]); err != nil {
+		return err
+	}
+
+	if (*certificate == "") != (*key == "") {
+		return fmt.Errorf("--tls-cert and --tls-key must both be specified for HTTPS")
+	}
+
+	tls := *certificate != ""
+	if !tls && !isLoopback(*address) {
+		fmt.Fprintf(os.Stderr, "   WARN  serving REST API over plain HTTP on %v - the bearer token is sent in the clear (use --tls-cert and --tls-key)\n", *address)
+	}
+
+	token := ctx.settings.get("serve.token", "")
+	if token == "" {
+		return fmt.Errorf("missing bearer token (define 'cli.serve.token' in the configuration file)")
+	}
+
+	api := restAPI{
+		ctx:   ctx,
+		token: token,
+	}
+
+	server := http.Server{
+		Addr:              *address,
+		Handler:           api.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		if tls {
+			errs <- server.ListenAndServeTLS(*certificate, *key)
+		} else {
+			errs <- server.ListenAndServe()
+		}
+	}()
+
+	if tls {
+		log.Printf("serving REST API on %v (HTTPS)", *address)
+	} else {
+		log.Printf("serving REST API on %v", *address)
+	}
+
+	q := make(chan os.Signal, 1)
+
+	signal.Notify(q, os.Interrupt)
+	defer signal.Stop(q)
+
+	select {
+	case err := <-errs:
+		return err
+
+	case <-q:
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		return server.Shutdown(shutdown)
+	}
+}
+
+// Returns the REST API handler, wrapped with the request logging and bearer token
+// authentication. The OpenAPI document does not require authentication.
+func (a *restAPI) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /controllers", a.getControllers)
+	mux.HandleFunc("GET /controllers/{id}/status", a.configured(a.getStatus))
+	mux.HandleFunc("GET /controllers/{id}/time", a.configured(a.getTime))
+	mux.HandleFunc("GET /controllers/{id}/cards", a.configured(a.getCards))
+	mux.HandleFunc("GET /controllers/{id}/cards/{card}", a.configured(a.getCard))
+	mux.HandleFunc("PUT /controllers/{id}/cards/{card}", a.configured(a.putCard))
+	mux.HandleFunc("DELETE /controllers/{id}/cards/{card}", a.configured(a.deleteCard))
+	mux.HandleFunc("GET /doors", a.getDoors)
+	mux.HandleFunc("POST /doors/{name}/open", a.openDoor)
+	mux.HandleFunc("GET /acl", a.getACL)
+
+	authorized := a.authorize(mux)
+
+	return a.log(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/openapi.json" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(openapi)
+		} else {
+			authorized.ServeHTTP(w, r)
+		}
+	}))
+}
+
+// Wraps a /controllers/{id} handler to reject requests for controllers that are not in the
+// configuration file.
+func (a *restAPI) configured(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if controller, err := a.controller(r); err != nil || !slices.ContainsFunc(a.ctx.devices, func(d uhppote.Device) bool {
+			return d.DeviceID == controller
+		}) {
+			writeError(w, http.StatusNotFound, fmt.Errorf("controller %v not found", r.PathValue("id")))
+		} else {
+			h(w, r)
+		}
+	}
+}
+
+func (a *restAPI) authorize(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(a.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="uhppote-cli"`)
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid bearer token"))
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// loggedResponse records the response status for the request log.
+type loggedResponse struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *loggedResponse) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (a *restAPI) log(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		response := loggedResponse{w, http.StatusOK}
+
+		h.ServeHTTP(&response, r)
+
+		log.Printf("%v %v %v %v %v", r.RemoteAddr, r.Method, r.URL.Path, response.status, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+func (a *restAPI) getControllers(w http.ResponseWriter, r *http.Request) {
+	type controller struct {
+		Controller uint32           `json:"controller"`
+		Name       string           `json:"name,omitempty"`
+		Doors      map[uint8]string `json:"doors"`
+	}
+
+	list := []controller{}
+	for _, device := range a.ctx.devices {
+		doors := map[uint8]string{}
+		for i, name := range device.Doors {
+			doors[uint8(i+1)] = name
+		}
+
+		list = append(list, controller{
+			Controller: device.DeviceID,
+			Name:       device.Name,
+			Doors:      doors,
+		})
+	}
+
+	slices.SortFunc(list, func(p, q controller) int {
+		return cmp.Compare(p.Controller, q.Controller)
+	})
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (a *restAPI) getStatus(w http.ResponseWriter, r *http.Request) {
+	controller, err := a.controller(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if status, err := a.ctx.uhppote.GetStatus(controller); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+	} else if status == nil {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("no response from %v", controller))
+	} else {
+		writeJSON(w, http.StatusOK, newStatusRecord(*status))
+	}
+}
+
+func (a *restAPI) getTime(w http.ResponseWriter, r *http.Request) {
+	controller, err := a.controller(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if t, err := a.ctx.uhppote.GetTime(controller); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+	} else if t == nil {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("no response from %v", controller))
+	} else {
+		writeJSON(w, http.StatusOK, struct {
+			Controller uint32         `json:"controller"`
+			DateTime   types.DateTime `json:"date-time"`
+		}{
+			Controller: controller,
+			DateTime:   t.DateTime,
+		})
+	}
+}
+
+func (a *restAPI) getCards(w http.ResponseWriter, r *http.Request) {
+	controller, err := a.controller(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if cards, err := getCards(a.ctx, controller); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+	} else {
+		writeJSON(w, http.StatusOK, cards)
+	}
+}
+
+func (a *restAPI) getCard(w http.ResponseWriter, r *http.Request) {
+	controller, card, err := a.card(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if record, err := a.ctx.uhppote.GetCardByID(controller, card); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+	} else if record == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("card %v not found on %v", card, controller))
+	} else {
+		writeJSON(w, http.StatusOK, record)
+	}
+}
+
+// Adds (or updates) a card. The request body is a JSON card record e.g.
+//
+//	{ "start-date": "2026-01-01", "end-date": "2026-12-31", "doors": { "1": 1, "2": 29 }, "PIN": 7531 }
+func (a *restAPI) putCard(w http.ResponseWriter, r *http.Request) {
+	controller, cardNumber, err := a.card(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// ... types.Card ignores doors other than 1-4 and truncates the permissions, so the doors are
+	//     also decoded 'as is' for validation
+	var card types.Card
+	var doors struct {
+		Doors map[string]int `json:"doors"`
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 64*1024))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid card (%v)", err))
+		return
+	} else if err := json.Unmarshal(body, &card); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid card (%v)", err))
+		return
+	} else if err := json.Unmarshal(body, &doors); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid card (%v)", err))
+		return
+	} else if card.CardNumber != 0 && card.CardNumber != cardNumber {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("card number %v does not match URL card number %v", card.CardNumber, cardNumber))
+		return
+	} else if card.From.IsZero() {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("missing or invalid start-date"))
+		return
+	} else if card.To.IsZero() {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("missing or invalid end-date"))
+		return
+	} else if card.To.Before(card.From) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("end-date (%v) is before start-date (%v)", card.To, card.From))
+		return
+	}
+
+	card.CardNumber = cardNumber
+
+	for _, door := range slices.Sorted(maps.Keys(doors.Doors)) {
+		if !slices.Contains([]string{"1", "2", "3", "4"}, door) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid door '%v'", door))
+			return
+		} else if v := doors.Doors[door]; v < 0 || v > 254 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid time profile '%v' (valid profiles are in the range 2 to 254)", v))
+			return
+		}
+	}
+
+	for _, door := range slices.Sorted(maps.Keys(card.Doors)) {
+		if v := card.Doors[door]; v >= 2 && v <= 254 {
+			if profile, err := a.ctx.uhppote.GetTimeProfile(controller, v); err != nil {
+				writeError(w, http.StatusBadGateway, err)
+				return
+			} else if profile == nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("time profile %v is not defined on controller %v", getProfileNames(a.ctx).describe(v), controller))
+				return
+			}
+		}
+	}
+
+	formats := []types.CardFormat{}
+	if a.ctx.config != nil {
+		formats = append(formats, a.ctx.config.CardFormat)
+	}
+
+	if ok, err := a.ctx.uhppote.PutCard(controller, card, formats...); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+	} else if !ok {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("card %v not stored on %v", cardNumber, controller))
+	} else {
+		writeJSON(w, http.StatusOK, card)
+	}
+}
+
+func (a *restAPI) deleteCard(w http.ResponseWriter, r *http.Request) {
+	controller, card, err := a.card(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if ok, err := a.ctx.uhppote.DeleteCard(controller, card); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+	} else if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("card %v not found on %v", card, controller))
+	} else {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (a *restAPI) getDoors(w http.ResponseWriter, r *http.Request) {
+	type door struct {
+		Name       string `json:"name"`
+		Controller uint32 `json:"controller"`
+		Door       uint8  `json:"door"`
+	}
+
+	list := []door{}
+	for _, d := range getConfiguredDoors(a.ctx) {
+		list = append(list, door{
+			Name:       d.name,
+			Controller: d.controller,
+			Door:       d.door,
+		})
+	}
+
+	slices.SortFunc(list, func(p, q door) int {
+		return strings.Compare(p.Name, q.Name)
+	})
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (a *restAPI) openDoor(w http.ResponseWriter, r *http.Request) {
+	door, err := resolveDoor(a.ctx, r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	if result, err := a.ctx.uhppote.OpenDoor(door.controller, door.door); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+	} else if result == nil || !result.Succeeded {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("failed to open %v door %v", door.controller, door.door))
+	} else {
+		writeJSON(w, http.StatusOK, struct {
+			Controller uint32 `json:"controller"`
+			Door       uint8  `json:"door"`
+			Name       string `json:"name,omitempty"`
+			Opened     bool   `json:"opened"`
+		}{
+			Controller: door.controller,
+			Door:       door.door,
+			Name:       door.name,
+			Opened:     true,
+		})
+	}
+}
+
+func (a *restAPI) getACL(w http.ResponseWriter, r *http.Request) {
+	if len(a.ctx.devices) == 0 {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("no controllers in the configuration file"))
+		return
+	}
+
+	list, errs := acl.GetACL(a.ctx.uhppote, a.ctx.devices)
+	if len(errs) > 0 {
+		writeError(w, http.StatusBadGateway, errors.Join(errs...))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, aclRecords(a.ctx, list))
+}
+
+// Returns the controller in the request URL.
+func (a *restAPI) controller(r *http.Request) (uint32, error) {
+	return parseSerialNumber(a.ctx, r.PathValue("id"))
+}
+
+// Returns the controller and card number in the request URL.
+func (a *restAPI) card(r *http.Request) (uint32, uint32, error) {
+	controller, err := a.controller(r)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	card, err := strconv.ParseUint(r.PathValue("card"), 10, 32)
+	if err != nil || card == 0 {
+		return 0, 0, fmt.Errorf("invalid card number (%v)", r.PathValue("card"))
+	}
+
+	return controller, uint32(card), nil
+}
+
+func newStatusRecord(status types.Status) statusRecord {
+	record := statusRecord{
+		Controller:     uint32(status.SerialNumber),
+		SystemDateTime: status.SystemDateTime,
+		DoorOpen:       map[uint8]bool{},
+		DoorButton:     map[uint8]bool{},
+		DoorUnlocked:   map[uint8]bool{},
+		Inputs:         status.InputState,
+		SystemError:    status.SystemError,
+		SequenceID:     status.SequenceId,
+	}
+
+	for _, door := range []uint8{1, 2, 3, 4} {
+		record.DoorOpen[door] = status.DoorState[door]
+		record.DoorButton[door] = status.DoorButton[door]
+		record.DoorUnlocked[door] = status.RelayState&(1<<(door-1)) != 0
+	}
+
+	if e := status.Event; e.Index > 0 {
+		record.Event = &eventRecord{
+			Index:      e.Index,
+			Type:       e.Type,
+			Granted:    e.Granted,
+			Door:       e.Door,
+			Direction:  e.Direction,
+			Card:       e.CardNumber,
+			Timestamp:  e.Timestamp,
+			Reason:     e.Reason,
+			ReasonText: eventReason(e.Reason),
+		}
+	}
+
+	return record
+}
+
+// Converts an ACL to a list of cards with the door permissions by door name. A permission is
+// 'Y' or a time profile (name or ID).
+func aclRecords(ctx Context, list acl.ACL) []aclRecord {
+	cardholders := getCardholders(ctx)
+	profiles := getProfileNames(ctx)
+	records := map[uint32]*aclRecord{}
+
+	for _, device := range ctx.devices {
+		for _, card := range list[device.DeviceID] {
+			record, ok := records[card.CardNumber]
+			if !ok {
+				record = &aclRecord{
+					Card:  card.CardNumber,
+					Name:  cardholders.annotate(card.CardNumber),
+					From:  card.From,
+					To:    card.To,
+					Doors: map[string]string{},
+				}
+
+				records[card.CardNumber] = record
+			}
+
+			if card.From.Before(record.From) {
+				record.From = card.From
+			}
+
+			if card.To.After(record.To) {
+				record.To = card.To
+			}
+
+			for i, door := range device.Doors {
+				name := door
+				if clean(name) == "" {
+					name = fmt.Sprintf("%v:%v", device.DeviceID, i+1)
+				}
+
+				switch p := card.Doors[uint8(i+1)]; {
+				case p == 1:
+					record.Doors[name] = "Y"
+				case p >= 2 && p <= 254:
+					record.Doors[name] = profiles.label(p)
+				}
+			}
+		}
+	}
+
+	acl := []aclRecord{}
+	for _, card := range slices.Sorted(maps.Keys(records)) {
+		acl = append(acl, *records[card])
+	}
+
+	return acl
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(b, '\n'))
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	b, _ := json.Marshal(struct {
+		Error string `json:"error"`
+	}{
+		Error: err.Error(),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(b, '\n'))
+}
+
+// Returns true if the address host is a loopback address (or 'localhost').
+func isLoopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	} else if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+func (c *Serve) CLI() string {
+	return "serve"
+}
+
+func (c *Serve) Description() string {
+	return "Serves a REST API for the configured controllers"
+}
+
+func (c *Serve) Usage() string {
+	return "[--http <address>] [--tls-cert <file> --tls-key <file>]"
+}
+
+func (c *Serve) Help() {
+	fmt.Println("Usage: uhppote-cli [options] serve [--http <address>] [--tls-cert <file> --tls-key <file>]")
+	fmt.Println()
+	fmt.Println(" Serves a JSON REST API for the controllers in the configuration file. Requests are authenticated with the")
+	fmt.Println(" bearer token defined by the 'cli.serve.token' setting and logged to stderr. The OpenAPI document for the API")
+	fmt.Println(" is served (without authentication) from /openapi.json.")
+	fmt.Println()
+	fmt.Println(" The API is served on the loopback interface by default. The bearer token is sent in the clear over plain HTTP,")
+	fmt.Println(" so serve the API over HTTPS (--tls-cert and --tls-key) if it is accessible from other hosts.")
+	fmt.Println()
+	fmt.Println("    GET    /controllers")
+	fmt.Println("    GET    /controllers/{id}/status")
+	fmt.Println("    GET    /controllers/{id}/time")
+	fmt.Println("    GET    /controllers/{id}/cards")
+	fmt.Println("    GET    /controllers/{id}/cards/{card}")
+	fmt.Println("    PUT    /controllers/{id}/cards/{card}")
+	fmt.Println("    DELETE /controllers/{id}/cards/{card}")
+	fmt.Println("    GET    /doors")
+	fmt.Println("    POST   /doors/{name}/open")
+	fmt.Println("    GET    /acl")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --config  File path for the 'conf' file containing the controller configuration")
+	fmt.Printf("              (defaults to %s)\n", config.DefaultConfig)
+	fmt.Println("    --debug   Displays internal information for diagnosing errors")
+	fmt.Println()
+	fmt.Println("    --http      HTTP address on which to serve the REST API (defaults to 127.0.0.1:8080)")
+	fmt.Println("    --tls-cert  TLS certificate file. Serves the REST API over HTTPS (requires --tls-key)")
+	fmt.Println("    --tls-key   TLS private key file for --tls-cert")
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println()
+	fmt.Println("    uhppote-cli serve")
+	fmt.Println("    curl -H 'Authorization: Bearer <token>' http://localhost:8080/controllers/405419896/status")
+	fmt.Println()
+	fmt.Println("    uhppote-cli serve --http :8443 --tls-cert server.crt --tls-key server.key")
+	fmt.Println("    curl -H 'Authorization: Bearer <token>' https://uhppote.example.com:8443/controllers/405419896/status")
+	fmt.Println()
+}
+
+// Returns true - configuration is not optional for this command to return valid information.
+func (c *Serve) RequiresConfig() bool {
+	return true
+}
@@ -0,0 +1,317 @@
+package commands
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppoted-lib/acl"
+)
+
+type serveStub struct {
+	stub
+	cards  map[uint32]types.Card
+	opened []doorID
+}
+
+func (s *serveStub) GetStatus(controller uint32) (*types.Status, error) {
+	return &types.Status{
+		SerialNumber:   types.SerialNumber(controller),
+		DoorState:      map[uint8]bool{1: true},
+		DoorButton:     map[uint8]bool{},
+		SystemDateTime: types.DateTime(time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)),
+		RelayState:     0x02,
+		Event: types.StatusEvent{
+			Index:      57,
+			Type:       eventSwipe,
+			Granted:    true,
+			Door:       1,
+			CardNumber: 10058400,
+			Reason:     reasonSwipe,
+		},
+	}, nil
+}
+
+func (s *serveStub) GetCardByID(controller, card uint32) (*types.Card, error) {
+	if v, ok := s.cards[card]; ok {
+		return &v, nil
+	}
+
+	return nil, nil
+}
+
+func (s *serveStub) PutCard(controller uint32, card types.Card, formats ...types.CardFormat) (bool, error) {
+	s.cards[card.CardNumber] = card
+
+	return true, nil
+}
+
+func (s *serveStub) GetTimeProfile(controller uint32, profileID uint8) (*types.TimeProfile, error) {
+	if profileID == 29 {
+		return &types.TimeProfile{ID: 29}, nil
+	}
+
+	return nil, nil
+}
+
+func (s *serveStub) OpenDoor(controller uint32, door uint8) (*types.Result, error) {
+	s.opened = append(s.opened, doorID{controller, door})
+
+	return &types.Result{SerialNumber: types.SerialNumber(controller), Succeeded: true}, nil
+}
+
+func serveAPI(t *testing.T, s *serveStub) http.Handler {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	ctx := shellContext(t)
+	ctx.uhppote = s
+
+	api := restAPI{
+		ctx:   ctx,
+		token: "qwerty",
+	}
+
+	return api.handler()
+}
+
+func request(h http.Handler, method, url, token, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, url, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestServeAuthorization(t *testing.T) {
+	h := serveAPI(t, &serveStub{})
+
+	for _, token := range []string{"", "uiop"} {
+		if w := request(h, "GET", "/controllers", token, ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("token %q: incorrect status - expected:%v, got:%v", token, http.StatusUnauthorized, w.Code)
+		}
+	}
+
+	if w := request(h, "GET", "/controllers", "qwerty", ""); w.Code != http.StatusOK {
+		t.Errorf("incorrect status - expected:%v, got:%v", http.StatusOK, w.Code)
+	}
+
+	if w := request(h, "GET", "/openapi.json", "", ""); w.Code != http.StatusOK || !json.Valid(w.Body.Bytes()) {
+		t.Errorf("invalid OpenAPI document (%v)", w.Code)
+	}
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	var doc struct {
+		Paths map[string]map[string]any `json:"paths"`
+	}
+
+	if err := json.Unmarshal(openapi, &doc); err != nil {
+		t.Fatalf("invalid OpenAPI document (%v)", err)
+	}
+
+	routes := []string{
+		"GET /controllers",
+		"GET /controllers/{id}/status",
+		"GET /controllers/{id}/time",
+		"GET /controllers/{id}/cards",
+		"GET /controllers/{id}/cards/{card}",
+		"PUT /controllers/{id}/cards/{card}",
+		"DELETE /controllers/{id}/cards/{card}",
+		"GET /doors",
+		"POST /doors/{name}/open",
+		"GET /acl",
+	}
+
+	for _, route := range routes {
+		method, path, _ := strings.Cut(route, " ")
+		if _, ok := doc.Paths[path][strings.ToLower(method)]; !ok {
+			t.Errorf("route %v missing from OpenAPI document", route)
+		}
+	}
+}
+
+func TestServeStatus(t *testing.T) {
+	h := serveAPI(t, &serveStub{})
+
+	w := request(h, "GET", "/controllers/Alpha/status", "qwerty", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("incorrect status - expected:%v, got:%v (%v)", http.StatusOK, w.Code, w.Body)
+	}
+
+	var status map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatalf("invalid response (%v)", err)
+	}
+
+	expected := map[string]any{
+		"controller":       405419896.0,
+		"system-date-time": "2026-10-19 12:00:00 UTC",
+		"door-open":        map[string]any{"1": true, "2": false, "3": false, "4": false},
+		"door-button":      map[string]any{"1": false, "2": false, "3": false, "4": false},
+		"door-unlocked":    map[string]any{"1": false, "2": true, "3": false, "4": false},
+		"inputs":           0.0,
+		"system-error":     0.0,
+		"sequence-id":      0.0,
+		"event": map[string]any{
+			"index":       57.0,
+			"type":        1.0,
+			"granted":     true,
+			"door":        1.0,
+			"direction":   0.0,
+			"card":        10058400.0,
+			"timestamp":   "",
+			"reason":      1.0,
+			"reason-text": "swipe",
+		},
+	}
+
+	if !reflect.DeepEqual(status, expected) {
+		t.Errorf("incorrect status\n   expected:%v\n   got:     %v", expected, status)
+	}
+
+	for _, url := range []string{"/controllers/x/status", "/controllers/123456789/status", "/controllers/123456789/cards/10058400"} {
+		if w := request(h, "GET", url, "qwerty", ""); w.Code != http.StatusNotFound {
+			t.Errorf("%v: incorrect status - expected:%v, got:%v", url, http.StatusNotFound, w.Code)
+		}
+	}
+}
+
+func TestServePutCard(t *testing.T) {
+	s := serveStub{cards: map[uint32]types.Card{}}
+	h := serveAPI(t, &s)
+
+	body := `{ "start-date": "2026-01-01", "end-date": "2026-12-31", "doors": { "1": 1, "3": 29 } }`
+	if w := request(h, "PUT", "/controllers/405419896/cards/10058400", "qwerty", body); w.Code != http.StatusOK {
+		t.Fatalf("incorrect status - expected:%v, got:%v (%v)", http.StatusOK, w.Code, w.Body)
+	}
+
+	card, ok := s.cards[10058400]
+	if !ok {
+		t.Fatalf("card not stored")
+	} else if card.From != types.MustParseDate("2026-01-01") || card.To != types.MustParseDate("2026-12-31") || card.Doors[1] != 1 || card.Doors[3] != 29 {
+		t.Errorf("incorrect card stored %v", card)
+	}
+
+	if w := request(h, "GET", "/controllers/405419896/cards/10058400", "qwerty", ""); w.Code != http.StatusOK {
+		t.Errorf("incorrect status - expected:%v, got:%v (%v)", http.StatusOK, w.Code, w.Body)
+	}
+
+	if w := request(h, "GET", "/controllers/405419896/cards/10058401", "qwerty", ""); w.Code != http.StatusNotFound {
+		t.Errorf("incorrect status - expected:%v, got:%v (%v)", http.StatusNotFound, w.Code, w.Body)
+	}
+
+	invalid := map[string]string{
+		"invalid JSON":        `{ "start-date": `,
+		"invalid date":        `{ "start-date": "2026-13-01", "end-date": "2026-12-31" }`,
+		"mismatched card":     `{ "card-number": 10058401, "start-date": "2026-01-01", "end-date": "2026-12-31" }`,
+		"undefined profile":   `{ "start-date": "2026-01-01", "end-date": "2026-12-31", "doors": { "1": 30 } }`,
+		"missing start date":  `{ "end-date": "2026-12-31", "doors": { "1": 1 } }`,
+		"missing end date":    `{ "start-date": "2026-01-01", "doors": { "1": 1 } }`,
+		"missing dates":       `{ "doors": { "1": 1 } }`,
+		"invalid date range":  `{ "start-date": "2026-12-31", "end-date": "2026-01-01", "doors": { "1": 1 } }`,
+		"invalid door":        `{ "start-date": "2026-01-01", "end-date": "2026-12-31", "doors": { "5": 1 } }`,
+		"door 0":              `{ "start-date": "2026-01-01", "end-date": "2026-12-31", "doors": { "0": 1 } }`,
+		"invalid profile":     `{ "start-date": "2026-01-01", "end-date": "2026-12-31", "doors": { "1": 255 } }`,
+		"negative profile":    `{ "start-date": "2026-01-01", "end-date": "2026-12-31", "doors": { "1": -1 } }`,
+		"profile overflow":    `{ "start-date": "2026-01-01", "end-date": "2026-12-31", "doors": { "1": 285 } }`,
+		"invalid card number": ``,
+	}
+
+	for test, body := range invalid {
+		url := "/controllers/405419896/cards/10058402"
+		if body == "" {
+			url = "/controllers/405419896/cards/x"
+		}
+
+		if w := request(h, "PUT", url, "qwerty", body); w.Code != http.StatusBadRequest {
+			t.Errorf("%v: incorrect status - expected:%v, got:%v (%v)", test, http.StatusBadRequest, w.Code, w.Body)
+		}
+	}
+}
+
+func TestServeOpenDoor(t *testing.T) {
+	s := serveStub{}
+	h := serveAPI(t, &s)
+
+	for _, url := range []string{"/doors/Great%20Hall/open", "/doors/greathall/open", "/doors/Beta:1/open"} {
+		if w := request(h, "POST", url, "qwerty", ""); w.Code != http.StatusOK {
+			t.Errorf("%v: incorrect status - expected:%v, got:%v (%v)", url, http.StatusOK, w.Code, w.Body)
+		}
+	}
+
+	if expected := []doorID{{405419896, 1}, {405419896, 1}, {303986753, 1}}; !reflect.DeepEqual(s.opened, expected) {
+		t.Errorf("incorrect doors opened - expected:%v, got:%v", expected, s.opened)
+	}
+
+	if w := request(h, "POST", "/doors/Attic/open", "qwerty", ""); w.Code != http.StatusNotFound {
+		t.Errorf("incorrect status - expected:%v, got:%v (%v)", http.StatusNotFound, w.Code, w.Body)
+	}
+
+	if w := request(h, "GET", "/doors/Kitchen/open", "qwerty", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("incorrect status - expected:%v, got:%v (%v)", http.StatusMethodNotAllowed, w.Code, w.Body)
+	}
+}
+
+func TestServeIsLoopback(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1:8080":     true,
+		"localhost:8080":     true,
+		"[::1]:8080":         true,
+		":8080":              false,
+		"0.0.0.0:8080":       false,
+		"192.168.1.100:8080": false,
+		"example.com:8080":   false,
+	}
+
+	for address, expected := range tests {
+		if loopback := isLoopback(address); loopback != expected {
+			t.Errorf("%v: incorrect loopback - expected:%v, got:%v", address, expected, loopback)
+		}
+	}
+}
+
+func TestACLRecords(t *testing.T) {
+	ctx := shellContext(t)
+
+	list := acl.ACL{
+		405419896: {
+			10058400: {CardNumber: 10058400, From: types.MustParseDate("2026-01-01"), To: types.MustParseDate("2026-06-30"), Doors: map[uint8]uint8{1: 1, 2: 29}},
+		},
+		303986753: {
+			10058400: {CardNumber: 10058400, From: types.MustParseDate("2026-02-01"), To: types.MustParseDate("2026-12-31"), Doors: map[uint8]uint8{1: 1, 3: 1}},
+			10058401: {CardNumber: 10058401, From: types.MustParseDate("2026-01-01"), To: types.MustParseDate("2026-12-31"), Doors: map[uint8]uint8{}},
+		},
+	}
+
+	expected := []aclRecord{
+		{
+			Card:  10058400,
+			From:  types.MustParseDate("2026-01-01"),
+			To:    types.MustParseDate("2026-12-31"),
+			Doors: map[string]string{"Great Hall": "Y", "Kitchen": "29", "Gate": "Y", "303986753:3": "Y"},
+		},
+		{
+			Card:  10058401,
+			From:  types.MustParseDate("2026-01-01"),
+			To:    types.MustParseDate("2026-12-31"),
+			Doors: map[string]string{},
+		},
+	}
+
+	if records := aclRecords(ctx, list); !reflect.DeepEqual(records, expected) {
+		t.Errorf("incorrect ACL\n   expected:%+v\n   got:     %+v", expected, records)
+	}
+}
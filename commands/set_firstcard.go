@@ -23,6 +23,7 @@ func (c *SetFirstCard) Execute(ctx Context) error {
 	} else if ok, err := ctx.uhppote.SetFirstCard(serialNumber, door, firstcard); err != nil {
 		return err
 	} else if ok {
+		recordFirstCard(ctx, serialNumber, door, firstcard)
 		fmt.Printf("%v  %v set first-card ok\n", serialNumber, door)
 	} else {
 		fmt.Printf("%v  %v set first-card failed\n", serialNumber, door)
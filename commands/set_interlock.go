@@ -26,6 +26,7 @@ func (c *SetInterlock) Execute(ctx Context) error {
 	} else if !ok {
 		return fmt.Errorf("%v  failed to set interlock %v", controllerID, interlock)
 	} else {
+		recordInterlock(ctx, controllerID, interlock)
 		fmt.Printf("%v  set interlock %v\n", controllerID, interlock)
 	}
 
@@ -51,21 +51,26 @@ func (c *SetTaskList) Execute(ctx Context) error {
 	// ... set tasks
 	created, warnings, err := c.load(ctx, serialNumber, tasks)
 	if err != nil {
+		recordTaskList(ctx, serialNumber, created, false)
 		return err
 	}
 
-	if created == 0 {
-		fmt.Printf("   ... %v created %v tasks\n", serialNumber, created)
+	if len(created) == 0 {
+		fmt.Printf("   ... %v created %v tasks\n", serialNumber, len(created))
 	}
 
 	// ... refresh task list
 	refreshed, err := ctx.uhppote.RefreshTaskList(serialNumber)
 	if err != nil {
+		recordTaskList(ctx, serialNumber, created, false)
 		return err
 	} else if !refreshed {
+		recordTaskList(ctx, serialNumber, created, false)
 		return fmt.Errorf("could not refresh task list")
 	}
 
+	recordTaskList(ctx, serialNumber, created, true)
+
 	fmt.Printf("   ... %v refreshed task list\n", serialNumber)
 
 	// ... done
@@ -96,10 +101,15 @@ func (c *SetTaskList) Help() {
 	fmt.Println("Usage: uhppote-cli [options] set-task-list <serial number> <file>")
 	fmt.Println()
 	fmt.Println(" Clears any existing task defined on a controller, adds the task defined in the file and then invokes")
-	fmt.Println(" refresh-tasks to activate the new task list.")
+	fmt.Println(" refresh-tasks to activate the new task list. The task list is recorded in the local state store")
+	fmt.Println(" (see get-task-list).")
+	fmt.Println()
+	fmt.Println(" The tasks may also be defined in a schedule file (with a .schedule extension), one task per line e.g.:")
+	fmt.Println()
+	fmt.Println("   door 1 lock door: Mon-Fri 18:00 from 2026-01-01 to 2026-12-31")
 	fmt.Println()
 	fmt.Println("  serial number  (required) controller serial number")
-	fmt.Println("  file           (required) TSV file with list of tasks")
+	fmt.Println("  file           (required) TSV or .schedule file with list of tasks")
 	fmt.Println()
 	fmt.Println("  Options:")
 	fmt.Println()
@@ -110,6 +120,7 @@ func (c *SetTaskList) Help() {
 	fmt.Println("  Examples:")
 	fmt.Println()
 	fmt.Println("    uhppote-cli set-task-list 9876543210 tasks.tsv")
+	fmt.Println("    uhppote-cli set-task-list 9876543210 office-hours.schedule")
 	fmt.Println()
 }
 
@@ -144,8 +155,9 @@ func (c *SetTaskList) getTSVFile() (string, error) {
 	return file, nil
 }
 
-func (c *SetTaskList) load(ctx Context, serialNumber uint32, tasks []types.Task) (int, []error, error) {
+func (c *SetTaskList) load(ctx Context, serialNumber uint32, tasks []types.Task) ([]types.Task, []error, error) {
 	warnings := []error{}
+	added := []types.Task{}
 	created := [][]string{}
 
 	for id, task := range tasks {
@@ -155,10 +167,11 @@ func (c *SetTaskList) load(ctx Context, serialNumber uint32, tasks []types.Task)
 		}
 
 		if ok, err := ctx.uhppote.AddTask(serialNumber, task); err != nil {
-			return len(created), nil, err
+			return added, nil, err
 		} else if !ok {
 			warnings = append(warnings, fmt.Errorf("%v: could not create task definition %v", serialNumber, id+1))
 		} else {
+			added = append(added, task)
 			cards := ""
 			if task.Task == types.EnableMoreCards {
 				cards = fmt.Sprintf("%d", task.Cards)
@@ -181,7 +194,7 @@ func (c *SetTaskList) load(ctx Context, serialNumber uint32, tasks []types.Task)
 		fmt.Printf("   ... created task definition %s\n", v)
 	}
 
-	return len(created), warnings, nil
+	return added, warnings, nil
 }
 
 func (c *SetTaskList) validate(task types.Task) error {
@@ -193,6 +206,14 @@ func (c *SetTaskList) validate(task types.Task) error {
 }
 
 func (c *SetTaskList) parse(file string) ([]types.Task, error) {
+	if isScheduleFile(file) {
+		if s, err := loadSchedule(file); err != nil {
+			return nil, err
+		} else {
+			return s.tasks, nil
+		}
+	}
+
 	type tsvTask struct {
 		Task      types.TaskType `tsv:"Task"`
 		Door      uint8          `tsv:"Door"`
@@ -73,8 +73,13 @@ func (c *SetTimeProfiles) Help() {
 	fmt.Println(" Writes the time profiles defined in a TSV file to a controller. Existing time profiles are not cleared")
 	fmt.Println(" but will be overwritten if redefined in the TSV file.")
 	fmt.Println()
+	fmt.Println(" The time profiles may also be defined in a schedule file (with a .schedule extension), one time profile")
+	fmt.Println(" per line e.g.:")
+	fmt.Println()
+	fmt.Println("   29: Mon-Fri 08:00-12:00,13:00-17:00 from 2026-01-01 to 2026-12-31 then 30")
+	fmt.Println()
 	fmt.Println("  serial number  (required) controller serial number")
-	fmt.Println("  file           (required) TSV file with time profiles")
+	fmt.Println("  file           (required) TSV or .schedule file with time profiles")
 	fmt.Println()
 	fmt.Println("  Options:")
 	fmt.Println()
@@ -85,6 +90,7 @@ func (c *SetTimeProfiles) Help() {
 	fmt.Println("  Examples:")
 	fmt.Println()
 	fmt.Println("    uhppote-cli set-time-profiles 9876543210 9876543210.tsv")
+	fmt.Println("    uhppote-cli set-time-profiles 9876543210 office-hours.schedule")
 	fmt.Println()
 }
 
@@ -247,6 +253,14 @@ func (c *SetTimeProfiles) circular(ctx Context, serialNumber uint32, profile typ
 }
 
 func (c *SetTimeProfiles) parse(file string) ([]types.TimeProfile, error) {
+	if isScheduleFile(file) {
+		if s, err := loadSchedule(file); err != nil {
+			return nil, err
+		} else {
+			return s.profiles, nil
+		}
+	}
+
 	type tsvProfile struct {
 		ID        int        `tsv:"Profile"`
 		From      types.Date `tsv:"From"`
@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Settings holds the uhppote-cli specific settings, i.e. the 'cli.xxx' entries in the
+// uhppoted.conf file (which are ignored by the other uhppoted modules) e.g.
+//
+//	cli.cardholders = /etc/uhppoted/cardholders.tsv
+//
+// Keys are stored with the 'cli.' prefix removed. Relative file paths are resolved
+// relative to the directory containing the uhppoted.conf file.
+type Settings struct {
+	dir    string
+	values map[string]string
+}
+
+// LoadSettings extracts the 'cli.xxx' settings from a uhppoted.conf file.
+func LoadSettings(file string) (Settings, error) {
+	settings := Settings{
+		values: map[string]string{},
+	}
+
+	if file == "" {
+		return settings, nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return settings, err
+	}
+
+	defer f.Close()
+
+	re := regexp.MustCompile(`^\s*cli\.(\S+)\s*=\s*(.*?)\s*$`)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		if match := re.FindStringSubmatch(line); match != nil {
+			settings.values[match[1]] = match[2]
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return settings, err
+	}
+
+	settings.dir = filepath.Dir(file)
+
+	return settings, nil
+}
+
+// WithSettings returns a copy of the Context with the uhppote-cli specific settings.
+func (ctx Context) WithSettings(settings Settings) Context {
+	ctx.settings = settings
+
+	return ctx
+}
+
+// Simulated returns a copy of the settings for the controllers simulated with --simulate. The
+// local state store is relocated to '<file>.state' next to the simulation file, so that the
+// commands run against a simulation never update the state recorded for the real controllers.
+func (s Settings) Simulated(file string) Settings {
+	settings := Settings{
+		dir:    s.dir,
+		values: map[string]string{},
+	}
+
+	for k, v := range s.values {
+		settings.values[k] = v
+	}
+
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+
+	settings.values["state"] = file + ".state"
+
+	return settings
+}
+
+// Returns the setting for the key (or the default value if not defined).
+func (s Settings) get(key string, defval string) string {
+	if v, ok := s.values[key]; ok && v != "" {
+		return v
+	}
+
+	return defval
+}
+
+// Returns the file path for the key, resolved relative to the directory containing
+// the uhppoted.conf file if not an absolute path (or "" if not defined).
+func (s Settings) path(key string) string {
+	if v := s.get(key, ""); v == "" {
+		return ""
+	} else if filepath.IsAbs(v) || s.dir == "" {
+		return v
+	} else {
+		return filepath.Join(s.dir, v)
+	}
+}
+
+// Returns the settings with keys that start with the prefix, with the prefix removed e.g.
+// prefixed("profile.") returns the 'cli.profile.xxx' settings keyed by 'xxx'.
+func (s Settings) prefixed(prefix string) map[string]string {
+	m := map[string]string{}
+	for k, v := range s.values {
+		if after, ok := strings.CutPrefix(k, prefix); ok && after != "" {
+			m[after] = v
+		}
+	}
+
+	return m
+}
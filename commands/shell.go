@@ -0,0 +1,619 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/uhppoted/uhppoted-lib/config"
+	"golang.org/x/term"
+)
+
+var ShellCmd = Shell{}
+
+// Shell is an interactive command shell that keeps the configuration, settings and controller
+// connection loaded between commands. The shell has a 'current controller' which is used as the
+// serial number for commands that require a serial number if one is not supplied.
+type Shell struct {
+	commands   []Command
+	controller uint32
+	cards      map[uint32]bool
+	running    atomic.Bool
+	interrupts atomic.Int32
+}
+
+// maxHistory is the maximum number of lines retained in the shell history.
+const maxHistory = 1000
+
+// Sets the commands available in the shell.
+func (c *Shell) SetCommands(commands []Command) {
+	c.commands = commands
+}
+
+func (c *Shell) Execute(ctx Context) error {
+	// ... invalid command options are reported at the prompt rather than exiting the shell
+	mode := flagErrorHandling
+	flagErrorHandling = flag.ContinueOnError
+	defer func() {
+		flagErrorHandling = mode
+	}()
+
+	c.cards = map[uint32]bool{}
+	for card := range getCardholders(ctx) {
+		c.cards[card] = true
+	}
+
+	// ... Ctrl-C interrupts the current command (e.g. listen) rather than the shell, but a second
+	//     Ctrl-C exits the shell if the command does not stop
+	interrupt := make(chan os.Signal, 1)
+
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	go func() {
+		for range interrupt {
+			if c.interrupted() {
+				fmt.Fprintf(os.Stderr, "\n   ERROR: interrupted\n\n")
+				os.Exit(1)
+			}
+		}
+	}()
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return c.run(ctx, os.Stdin)
+	}
+
+	history := loadShellHistory(ctx)
+	terminal := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, c.prompt(ctx))
+
+	terminal.History = history
+	terminal.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+
+		newLine, newPos, matches := c.complete(ctx, line, pos)
+		if len(matches) > 1 && newLine == line {
+			fmt.Fprintf(terminal, "%v\n", strings.Join(matches, "  "))
+		}
+
+		return newLine, newPos, newLine != line
+	}
+
+	fmt.Println("uhppote-cli shell - type 'help' for a list of commands, 'exit' (or Ctrl-D) to exit")
+	fmt.Println()
+
+	for {
+		line, err := c.readLine(terminal)
+		if errors.Is(err, io.EOF) {
+			fmt.Println()
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if c.exec(ctx, line) {
+			return nil
+		}
+
+		terminal.SetPrompt(c.prompt(ctx))
+	}
+}
+
+// Reads a line with the terminal in raw mode. The terminal is restored to 'cooked' mode while
+// a command is executing so that the command output is displayed normally.
+func (c *Shell) readLine(terminal *term.Terminal) (string, error) {
+	fd := int(os.Stdin.Fd())
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+
+	defer term.Restore(fd, state)
+
+	if width, height, err := term.GetSize(fd); err == nil && width > 0 {
+		terminal.SetSize(width, height)
+	}
+
+	return terminal.ReadLine()
+}
+
+// Executes the commands read from a non-interactive input (e.g. a pipe).
+func (c *Shell) run(ctx Context, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if c.exec(ctx, scanner.Text()) {
+			break
+		}
+	}
+
+	return scanner.Err()
+}
+
+// Executes a shell command line. Returns true if the line is an 'exit' (or 'quit') command.
+func (c *Shell) exec(ctx Context, line string) bool {
+	words, err := splitLine(line)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\n   ERROR: %v\n\n", err)
+		return false
+	} else if len(words) == 0 || strings.HasPrefix(words[0], "#") {
+		return false
+	}
+
+	switch words[0] {
+	case "exit", "quit":
+		return true
+
+	case "help":
+		c.help(words[1:])
+		return false
+
+	case "use":
+		if err := c.use(ctx, words[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "\n   ERROR: %v\n\n", err)
+		}
+		return false
+	}
+
+	cmd := c.lookup(words[0])
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "\n   ERROR: unknown command '%v' (type 'help' for a list of commands)\n\n", words[0])
+		return false
+	} else if cmd == Command(c) {
+		fmt.Fprintf(os.Stderr, "\n   ERROR: already in the shell\n\n")
+		return false
+	} else if cmd.CLI() == "tui" {
+		// ... the dashboard keystroke reader cannot be stopped and would swallow the shell input
+		fmt.Fprintf(os.Stderr, "\n   ERROR: 'tui' cannot be used in the shell\n\n")
+		return false
+	}
+
+	if err := c.execute(ctx, cmd, words); errors.Is(err, ErrDrift) {
+		fmt.Fprintf(os.Stderr, "\n   WARN:  %v\n\n", err)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "\n   ERROR: %v\n\n", err)
+	}
+
+	return false
+}
+
+// Executes a command with the current controller inserted as the serial number (if required).
+func (c *Shell) execute(ctx Context, cmd Command, words []string) error {
+	args := c.args(ctx, cmd, words)
+
+	c.remember(cmd, args)
+
+	// ... the commands retrieve their arguments from the command line flag set
+	if err := flag.CommandLine.Parse(args); err != nil {
+		return err
+	}
+
+	c.interrupts.Store(0)
+	c.running.Store(true)
+	defer c.running.Store(false)
+
+	if err := cmd.Execute(ctx); errors.Is(err, flag.ErrHelp) {
+		cmd.Help()
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Records a Ctrl-C and returns true if it is the second Ctrl-C while the same command is
+// running, i.e. the command is either ignoring Ctrl-C or not stopping.
+func (c *Shell) interrupted() bool {
+	return c.running.Load() && c.interrupts.Add(1) > 1
+}
+
+// Inserts the current controller as the serial number for a command that requires a serial
+// number, unless the first argument is already a controller.
+func (c *Shell) args(ctx Context, cmd Command, words []string) []string {
+	if c.controller == 0 || !strings.HasPrefix(cmd.Usage(), "<serial number>") {
+		return words
+	}
+
+	if len(words) > 1 && c.isController(ctx, words[1]) {
+		return words
+	}
+
+	return slices.Concat(words[:1], []string{fmt.Sprintf("%v", c.controller)}, words[1:])
+}
+
+// Returns true if an argument is the current controller, a configured controller (name or
+// serial number) or a 9 or 10 digit number (the UHPPOTE controller serial number format).
+func (c *Shell) isController(ctx Context, arg string) bool {
+	if arg == fmt.Sprintf("%v", c.controller) || regexp.MustCompile(`^[0-9]{9,10}$`).MatchString(arg) {
+		return true
+	}
+
+	for _, device := range ctx.devices {
+		if arg == fmt.Sprintf("%v", device.DeviceID) || (device.Name != "" && clean(arg) == clean(device.Name)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Records the card numbers used in a command for tab completion.
+func (c *Shell) remember(cmd Command, args []string) {
+	usage, _, _ := strings.Cut(cmd.Usage(), " | ")
+	tokens := regexp.MustCompile(`<[^>]*>|\[[^\]]*\]|\S+`).FindAllString(usage, -1)
+
+	for i, token := range tokens {
+		if strings.HasPrefix(token, "<card number") && i+1 < len(args) {
+			if card, err := strconv.ParseUint(args[i+1], 10, 32); err == nil && card > 0 {
+				c.cards[uint32(card)] = true
+			}
+		}
+	}
+}
+
+// Sets (or displays) the current controller. 'use none' clears the current controller.
+func (c *Shell) use(ctx Context, args []string) error {
+	if len(args) == 0 {
+		if c.controller == 0 {
+			fmt.Println("   (no current controller)")
+		} else {
+			fmt.Printf("   %v\n", c.controller)
+		}
+
+		return nil
+	}
+
+	if args[0] == "none" {
+		c.controller = 0
+		return nil
+	}
+
+	controller, err := parseSerialNumber(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	c.controller = controller
+
+	return nil
+}
+
+func (c *Shell) prompt(ctx Context) string {
+	if c.controller == 0 {
+		return "uhppote> "
+	}
+
+	for _, device := range ctx.devices {
+		if device.DeviceID == c.controller && device.Name != "" {
+			return fmt.Sprintf("uhppote:%v> ", device.Name)
+		}
+	}
+
+	return fmt.Sprintf("uhppote:%v> ", c.controller)
+}
+
+func (c *Shell) lookup(name string) Command {
+	for _, cmd := range c.commands {
+		if cmd.CLI() == name {
+			return cmd
+		}
+	}
+
+	return nil
+}
+
+func (c *Shell) help(args []string) {
+	if len(args) > 0 {
+		if cmd := c.lookup(args[0]); cmd != nil {
+			cmd.Help()
+		} else {
+			fmt.Fprintf(os.Stderr, "\n   ERROR: unknown command '%v'\n\n", args[0])
+		}
+
+		return
+	}
+
+	format := "    %-21s %s\n"
+
+	fmt.Println()
+	fmt.Println("  Commands:")
+	fmt.Println()
+	fmt.Printf(format, "help [command]", "Displays this message (or the help for a command)")
+	fmt.Printf(format, "use [controller|none]", "Sets (or displays) the current controller")
+	fmt.Printf(format, "exit", "Exits the shell")
+	fmt.Println()
+
+	for _, cmd := range c.commands {
+		if cmd != Command(c) {
+			fmt.Printf(format, cmd.CLI(), cmd.Description())
+		}
+	}
+
+	fmt.Println()
+}
+
+// Completes the word at the cursor position. The first word is completed from the command
+// names and the remaining words from the controller names and serial numbers, door names and
+// card numbers. Returns the updated line and cursor position along with the matching candidates.
+func (c *Shell) complete(ctx Context, line string, pos int) (string, int, []string) {
+	prefix := line[:pos]
+	start := strings.LastIndexAny(prefix, " \t") + 1
+	word := prefix[start:]
+	quote := ""
+
+	// ... allow for a quoted door or controller name
+	if i := strings.LastIndexAny(prefix, `'"`); i >= 0 && strings.Count(prefix, prefix[i:i+1])%2 == 1 {
+		start = i
+		quote = prefix[i : i+1]
+		word = prefix[i+1:]
+	}
+
+	candidates := c.arguments(ctx)
+	if strings.TrimSpace(prefix[:start]) == "" {
+		candidates = c.commandNames()
+	}
+
+	matches := []string{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(strings.ToLower(candidate), strings.ToLower(word)) {
+			matches = append(matches, candidate)
+		}
+	}
+
+	var replacement string
+	switch {
+	case len(matches) == 0:
+		return line, pos, matches
+
+	case len(matches) == 1 && (quote != "" || strings.ContainsAny(matches[0], " \t")):
+		if quote == "" {
+			quote = "'"
+		}
+		replacement = quote + matches[0] + quote + " "
+
+	case len(matches) == 1:
+		replacement = matches[0] + " "
+
+	default:
+		common := commonPrefix(matches)
+		if len(common) <= len(word) {
+			return line, pos, matches
+		} else if quote != "" || strings.ContainsAny(common, " \t") {
+			if quote == "" {
+				quote = "'"
+			}
+			replacement = quote + common
+		} else {
+			replacement = common
+		}
+	}
+
+	return prefix[:start] + replacement + line[pos:], start + len(replacement), matches
+}
+
+func (c *Shell) commandNames() []string {
+	names := []string{"help", "use", "exit", "quit"}
+	for _, cmd := range c.commands {
+		names = append(names, cmd.CLI())
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+func (c *Shell) arguments(ctx Context) []string {
+	arguments := []string{}
+
+	for _, device := range ctx.devices {
+		if device.Name != "" {
+			arguments = append(arguments, device.Name)
+		}
+
+		arguments = append(arguments, fmt.Sprintf("%v", device.DeviceID))
+	}
+
+	for _, door := range getConfiguredDoors(ctx) {
+		arguments = append(arguments, door.name)
+	}
+
+	for _, card := range slices.Sorted(maps.Keys(c.cards)) {
+		arguments = append(arguments, fmt.Sprintf("%v", card))
+	}
+
+	slices.Sort(arguments)
+
+	return slices.Compact(arguments)
+}
+
+// Returns the longest (case-insensitive) common prefix of a list of strings, using the case
+// of the first string.
+func commonPrefix(list []string) string {
+	prefix := list[0]
+	for _, s := range list[1:] {
+		n := 0
+		for n < len(prefix) && n < len(s) && strings.EqualFold(prefix[n:n+1], s[n:n+1]) {
+			n++
+		}
+
+		prefix = prefix[:n]
+	}
+
+	return prefix
+}
+
+// Splits a command line into words, allowing for single and double quoted words.
+func splitLine(line string) ([]string, error) {
+	words := []string{}
+	word := strings.Builder{}
+	quote := rune(0)
+	inword := false
+
+	for _, ch := range line {
+		switch {
+		case quote != 0 && ch == quote:
+			quote = 0
+
+		case quote != 0:
+			word.WriteRune(ch)
+
+		case ch == '\'' || ch == '"':
+			quote = ch
+			inword = true
+
+		case ch == ' ' || ch == '\t':
+			if inword {
+				words = append(words, word.String())
+				word.Reset()
+				inword = false
+			}
+
+		default:
+			word.WriteRune(ch)
+			inword = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in '%v'", line)
+	}
+
+	if inword {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+// shellHistory is the shell command history, persisted as the 'shell_history' file in the
+// local state store.
+type shellHistory struct {
+	file    string
+	entries []string
+}
+
+func loadShellHistory(ctx Context) *shellHistory {
+	history := shellHistory{}
+
+	dir, err := stateDir(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "   WARN  shell history not available (%v)\n", err)
+		return &history
+	}
+
+	history.file = filepath.Join(dir, "shell_history")
+
+	if bytes, err := os.ReadFile(history.file); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, "   WARN  error loading shell history (%v)\n", err)
+	} else if err == nil {
+		for line := range strings.Lines(string(bytes)) {
+			if s := strings.TrimRight(line, "\r\n"); s != "" {
+				history.entries = append(history.entries, s)
+			}
+		}
+
+		if len(history.entries) > maxHistory {
+			history.entries = history.entries[len(history.entries)-maxHistory:]
+
+			if err := os.WriteFile(history.file, []byte(strings.Join(history.entries, "\n")+"\n"), 0660); err != nil {
+				fmt.Fprintf(os.Stderr, "   WARN  error trimming shell history (%v)\n", err)
+			}
+		}
+	}
+
+	return &history
+}
+
+// Adds a line to the history and appends it to the history file. Repeated lines are ignored.
+func (h *shellHistory) Add(entry string) {
+	if strings.TrimSpace(entry) == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry) {
+		return
+	}
+
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[1:]
+	}
+
+	if h.file != "" {
+		if err := os.MkdirAll(filepath.Dir(h.file), 0770); err == nil {
+			if f, err := os.OpenFile(h.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0660); err == nil {
+				fmt.Fprintln(f, entry)
+				f.Close()
+			}
+		}
+	}
+}
+
+func (h *shellHistory) Len() int {
+	return len(h.entries)
+}
+
+// Returns a history entry, with 0 as the most recent entry.
+func (h *shellHistory) At(index int) string {
+	return h.entries[len(h.entries)-1-index]
+}
+
+func (c *Shell) CLI() string {
+	return "shell"
+}
+
+func (c *Shell) Description() string {
+	return "Interactive command shell"
+}
+
+func (c *Shell) Usage() string {
+	return ""
+}
+
+func (c *Shell) Help() {
+	fmt.Println("Usage: uhppote-cli [options] shell")
+	fmt.Println()
+	fmt.Println(" Starts an interactive shell that keeps the configuration and controller connection loaded between commands.")
+	fmt.Println(" The shell supports tab completion of command names, controller names, door names and card numbers (from the")
+	fmt.Println(" cardholder registry and the commands in the session) and a command history that is persisted in the local")
+	fmt.Println(" state store.")
+	fmt.Println()
+	fmt.Println(" 'use <controller>' sets the current controller, which is used as the serial number for commands that require")
+	fmt.Println(" a serial number if the first argument is not a controller (a configured controller name or serial number, or")
+	fmt.Println(" a 9 or 10 digit serial number).")
+	fmt.Println()
+	fmt.Println(" Commands are read from stdin without a prompt if stdin is not a terminal. Invalid command options are reported")
+	fmt.Println(" as errors without exiting the shell and 'command -h' displays the help for a command. 'tui' is not available")
+	fmt.Println(" in the shell. Ctrl-C stops a command that runs until interrupted (e.g. listen) without exiting the shell, and")
+	fmt.Println(" a second Ctrl-C while the same command is still running exits the shell.")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --config  File path for the 'conf' file containing the controller configuration")
+	fmt.Printf("              (defaults to %s)\n", config.DefaultConfig)
+	fmt.Println("    --debug   Displays internal information for diagnosing errors")
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println()
+	fmt.Println("    uhppote-cli shell")
+	fmt.Println("    uhppote> use 405419896")
+	fmt.Println("    uhppote:405419896> get-status")
+	fmt.Println("    uhppote:405419896> get-card 10058400")
+	fmt.Println()
+}
+
+// Returns false - configuration is useful but optional.
+func (c *Shell) RequiresConfig() bool {
+	return false
+}
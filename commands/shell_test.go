@@ -0,0 +1,304 @@
+package commands
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+// shellCommand is a command that records the arguments it was invoked with (and returns err).
+type shellCommand struct {
+	cli   string
+	usage string
+	args  [][]string
+	err   error
+}
+
+func (c *shellCommand) Execute(ctx Context) error {
+	c.args = append(c.args, flag.Args())
+	return c.err
+}
+
+func (c *shellCommand) CLI() string          { return c.cli }
+func (c *shellCommand) Description() string  { return c.cli }
+func (c *shellCommand) Usage() string        { return c.usage }
+func (c *shellCommand) Help()                {}
+func (c *shellCommand) RequiresConfig() bool { return false }
+
+// optionsCommand is a command with options that records the option values it was invoked with.
+type optionsCommand struct {
+	shellCommand
+	periods []time.Duration
+	helped  bool
+}
+
+func (c *optionsCommand) Execute(ctx Context) error {
+	flagset := newFlagSet()
+	period := flagset.Duration("for", 0, "Time for which to unlock the door")
+
+	if err := flagset.Parse(flag.Args()[1:]); err != nil {
+		return err
+	}
+
+	c.periods = append(c.periods, *period)
+
+	return nil
+}
+
+func (c *optionsCommand) Help() {
+	c.helped = true
+}
+
+func shellContext(t *testing.T) Context {
+	c := config.Config{
+		Devices: config.DeviceMap{
+			405419896: &config.Device{
+				Name:    "Alpha",
+				Address: types.MustParseControllerAddr("192.168.1.100:60000"),
+				Doors:   []string{"Great Hall", "Kitchen", "", ""},
+			},
+			303986753: &config.Device{
+				Name:    "Beta",
+				Address: types.MustParseControllerAddr("192.168.1.101:60000"),
+				Doors:   []string{"Gate", "", "", ""},
+			},
+		},
+	}
+
+	return NewContext(&stub{}, &c, false).WithSettings(Settings{
+		values: map[string]string{"state": t.TempDir()},
+	})
+}
+
+func TestSplitLine(t *testing.T) {
+	tests := map[string][]string{
+		"":                                       {},
+		"get-status":                             {"get-status"},
+		"  get-card   405419896  10058400 ":      {"get-card", "405419896", "10058400"},
+		"set-door-control 1 'normally open'":     {"set-door-control", "1", "normally open"},
+		`unlock "Great Hall" --for 45m`:          {"unlock", "Great Hall", "--for", "45m"},
+		"explain 10058400 'Great Hall' at 09:00": {"explain", "10058400", "Great Hall", "at", "09:00"},
+		"x ''":                                   {"x", ""},
+	}
+
+	for line, expected := range tests {
+		if words, err := splitLine(line); err != nil {
+			t.Errorf("%q: unexpected error (%v)", line, err)
+		} else if !reflect.DeepEqual(words, expected) {
+			t.Errorf("%q: incorrect words - expected:%q, got:%q", line, expected, words)
+		}
+	}
+
+	if _, err := splitLine("unlock 'Great Hall --for 45m"); err == nil {
+		t.Errorf("expected error for unterminated quote")
+	}
+}
+
+func TestShellCurrentController(t *testing.T) {
+	ctx := shellContext(t)
+	getCard := shellCommand{cli: "get-card", usage: "<serial number> <card number>"}
+	grant := shellCommand{cli: "grant", usage: "<card number|name> <start date> <end date> [profile] <doors> | --file <TSV file>"}
+	tui := shellCommand{cli: "tui"}
+	shell := Shell{}
+
+	shell.SetCommands([]Command{&getCard, &grant, &tui, &shell})
+
+	input := strings.Join([]string{
+		"get-card 405419896 10058400",
+		"use Beta",
+		"get-card 10058401",
+		"get-card Alpha 10058402",
+		"get-card 201020304 10058403",
+		"# comment",
+		"grant 10058404 2026-01-01 2026-12-31 'Great Hall'",
+		"use none",
+		"tui",
+		"get-card 10058405",
+		"exit",
+		"get-card 405419896 10058406",
+	}, "\n")
+
+	shell.cards = map[uint32]bool{}
+	if err := shell.run(ctx, strings.NewReader(input)); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	expected := [][]string{
+		{"get-card", "405419896", "10058400"},
+		{"get-card", "303986753", "10058401"},
+		{"get-card", "Alpha", "10058402"},
+		{"get-card", "201020304", "10058403"},
+		{"get-card", "10058405"},
+	}
+
+	if !reflect.DeepEqual(getCard.args, expected) {
+		t.Errorf("incorrect get-card arguments\n   expected:%q\n   got:     %q", expected, getCard.args)
+	}
+
+	if expected := [][]string{{"grant", "10058404", "2026-01-01", "2026-12-31", "Great Hall"}}; !reflect.DeepEqual(grant.args, expected) {
+		t.Errorf("incorrect grant arguments\n   expected:%q\n   got:     %q", expected, grant.args)
+	}
+
+	if len(tui.args) != 0 {
+		t.Errorf("'tui' executed in the shell %q", tui.args)
+	}
+
+	for _, card := range []uint32{10058400, 10058401, 10058402, 10058403, 10058404} {
+		if !shell.cards[card] {
+			t.Errorf("card %v not recorded for completion", card)
+		}
+	}
+}
+
+func TestShellInvalidOptions(t *testing.T) {
+	flagErrorHandling = flag.ContinueOnError
+	defer func() {
+		flagErrorHandling = flag.ExitOnError
+	}()
+
+	ctx := shellContext(t)
+	unlock := optionsCommand{shellCommand: shellCommand{cli: "unlock"}}
+	shell := Shell{}
+
+	shell.SetCommands([]Command{&unlock, &shell})
+
+	input := strings.Join([]string{
+		"unlock --for 45m",
+		"unlock --bogus",
+		"unlock --for never",
+		"unlock -h",
+		"unlock --for 15m",
+	}, "\n")
+
+	shell.cards = map[uint32]bool{}
+	if err := shell.run(ctx, strings.NewReader(input)); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	if expected := []time.Duration{45 * time.Minute, 15 * time.Minute}; !reflect.DeepEqual(unlock.periods, expected) {
+		t.Errorf("incorrect options\n   expected:%v\n   got:     %v", expected, unlock.periods)
+	}
+
+	if !unlock.helped {
+		t.Errorf("'-h' did not display the command help")
+	}
+}
+
+// interruptCommand is a command that records whether each Ctrl-C while it is running would
+// exit the shell.
+type interruptCommand struct {
+	shellCommand
+	shell  *Shell
+	exited []bool
+}
+
+func (c *interruptCommand) Execute(ctx Context) error {
+	c.exited = append(c.exited, c.shell.interrupted(), c.shell.interrupted())
+	return nil
+}
+
+func TestShellInterrupt(t *testing.T) {
+	ctx := shellContext(t)
+	shell := Shell{}
+	listen := interruptCommand{shellCommand: shellCommand{cli: "listen"}, shell: &shell}
+
+	shell.SetCommands([]Command{&listen, &shell})
+
+	if shell.interrupted() {
+		t.Errorf("Ctrl-C at the prompt exits the shell")
+	}
+
+	shell.cards = map[uint32]bool{}
+	if err := shell.run(ctx, strings.NewReader("listen\nlisten\n")); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	if expected := []bool{false, true, false, true}; !reflect.DeepEqual(listen.exited, expected) {
+		t.Errorf("incorrect Ctrl-C handling - expected:%v, got:%v", expected, listen.exited)
+	}
+
+	if shell.interrupted() || shell.interrupted() {
+		t.Errorf("Ctrl-C after a command exits the shell")
+	}
+}
+
+func TestShellComplete(t *testing.T) {
+	ctx := shellContext(t)
+	shell := Shell{
+		commands: []Command{
+			&shellCommand{cli: "get-card"},
+			&shellCommand{cli: "get-cards"},
+			&shellCommand{cli: "get-status"},
+			&shellCommand{cli: "set-time"},
+		},
+		cards: map[uint32]bool{10058400: true, 10058401: true, 20058400: true},
+	}
+
+	tests := []struct {
+		line     string
+		expected string
+		matches  []string
+	}{
+		{"get-s", "get-status ", []string{"get-status"}},
+		{"get-c", "get-card", []string{"get-card", "get-cards"}},
+		{"get-card", "get-card", []string{"get-card", "get-cards"}},
+		{"set", "set-time ", []string{"set-time"}},
+		{"xyz", "xyz", []string{}},
+		{"get-card al", "get-card Alpha ", []string{"Alpha"}},
+		{"get-card 4", "get-card 405419896 ", []string{"405419896"}},
+		{"get-card 405419896 1005840", "get-card 405419896 1005840", []string{"10058400", "10058401"}},
+		{"get-card 405419896 2", "get-card 405419896 20058400 ", []string{"20058400"}},
+		{"unlock gr", "unlock 'Great Hall' ", []string{"Great Hall"}},
+		{`unlock "gr`, `unlock "Great Hall" `, []string{"Great Hall"}},
+		{"unlock g", "unlock g", []string{"Gate", "Great Hall"}},
+		{"unlock gre", "unlock 'Great Hall' ", []string{"Great Hall"}},
+		{"unlock k", "unlock Kitchen ", []string{"Kitchen"}},
+	}
+
+	for _, test := range tests {
+		line, pos, matches := shell.complete(ctx, test.line, len(test.line))
+		if line != test.expected || pos != len(test.expected) {
+			t.Errorf("%q: incorrect completion - expected:%q, got:%q (%v)", test.line, test.expected, line, pos)
+		}
+
+		if !reflect.DeepEqual(matches, test.matches) {
+			t.Errorf("%q: incorrect matches - expected:%q, got:%q", test.line, test.matches, matches)
+		}
+	}
+
+	// ... completion in the middle of a line
+	if line, pos, _ := shell.complete(ctx, "get-s 405419896", 5); line != "get-status  405419896" || pos != 11 {
+		t.Errorf("incorrect mid-line completion %q (%v)", line, pos)
+	}
+}
+
+func TestShellHistory(t *testing.T) {
+	ctx := shellContext(t)
+
+	history := loadShellHistory(ctx)
+	for _, line := range []string{"use 405419896", "get-status", "get-status", "", "get-card 10058400"} {
+		history.Add(line)
+	}
+
+	if history.Len() != 3 || history.At(0) != "get-card 10058400" || history.At(2) != "use 405419896" {
+		t.Errorf("incorrect history %q", history.entries)
+	}
+
+	reloaded := loadShellHistory(ctx)
+	if !reflect.DeepEqual(reloaded.entries, history.entries) {
+		t.Errorf("history not persisted - expected:%q, got:%q", history.entries, reloaded.entries)
+	}
+
+	for i := range maxHistory + 10 {
+		reloaded.Add(strings.Repeat("x", i%3+1))
+	}
+
+	if reloaded = loadShellHistory(ctx); reloaded.Len() != maxHistory {
+		t.Errorf("history not trimmed - expected:%v entries, got:%v", maxHistory, reloaded.Len())
+	}
+}
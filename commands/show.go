@@ -18,7 +18,7 @@ func (c *Show) Execute(ctx Context) error {
 		return fmt.Errorf("show requires a valid configuration file")
 	}
 
-	cardNumber, err := getUint32(1, "Missing card number", "Invalid card number: %v")
+	cardNumber, err := getCardNumber(ctx, 1, "Missing card number", "Invalid card number: %v")
 	if err != nil {
 		return err
 	}
@@ -44,13 +44,19 @@ func (c *Show) Execute(ctx Context) error {
 		return p < q
 	})
 
+	profiles := getProfileNames(ctx)
+
 	fmt.Println()
+	if name := getCardholders(ctx).annotate(cardNumber); name != "" {
+		fmt.Printf("%v  %v\n\n", cardNumber, name)
+	}
+
 	format := fmt.Sprintf("%%-%ds  %%v  %%v\n", width)
 	formatp := fmt.Sprintf("%%-%ds  %%v  %%v  %%v\n", width)
 	for _, door := range doors {
 		v := permissions[door]
 		if v.Profile >= 2 && v.Profile <= 254 {
-			fmt.Printf(formatp, door, v.From, v.To, v.Profile)
+			fmt.Printf(formatp, door, v.From, v.To, profiles.label(uint8(v.Profile)))
 		} else {
 			fmt.Printf(format, door, v.From, v.To)
 		}
@@ -69,15 +75,15 @@ func (c *Show) Description() string {
 }
 
 func (c *Show) Usage() string {
-	return "<card number>"
+	return "<card number|name>"
 }
 
 func (c *Show) Help() {
-	fmt.Println("Usage: uhppote-cli [options] show <card number>")
+	fmt.Println("Usage: uhppote-cli [options] show <card number|name>")
 	fmt.Println()
 	fmt.Println(" Lists the access permissions for a card")
 	fmt.Println()
-	fmt.Println("  <card number>    (required) card number")
+	fmt.Println("  <card number>    (required) card number or cardholder name from the cardholder registry")
 	fmt.Println()
 	fmt.Println("  Options:")
 	fmt.Println()
@@ -88,6 +94,7 @@ func (c *Show) Help() {
 	fmt.Println("  Examples:")
 	fmt.Println()
 	fmt.Println("    uhppote-cli show 918273645")
+	fmt.Println(`    uhppote-cli show "Eduardo Ramos"`)
 	fmt.Println()
 }
 
@@ -0,0 +1,180 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+var SimulateCmd = Simulate{}
+
+// Simulate drives the physical inputs (card readers, door sensors and pushbuttons) of a
+// controller simulated with --simulate.
+type Simulate struct {
+}
+
+func (c *Simulate) Execute(ctx Context) error {
+	simulator, ok := ctx.uhppote.(*Simulator)
+	if !ok {
+		return fmt.Errorf("'simulate' requires a simulated controller (--simulate <file>)")
+	}
+
+	args := flag.Args()[1:]
+	if len(args) < 1 {
+		return fmt.Errorf("missing action (expected swipe, open, close or button)")
+	}
+
+	action := clean(args[0])
+	args = args[1:]
+
+	switch action {
+	case "swipe":
+		if len(args) < 1 {
+			return fmt.Errorf("missing card number")
+		} else if len(args) < 2 {
+			return fmt.Errorf("missing door")
+		}
+
+		cardNumber, err := resolveCardNumber(ctx, args[0], "invalid card number (%v)")
+		if err != nil {
+			return err
+		}
+
+		door, err := resolveDoor(ctx, args[1])
+		if err != nil {
+			return err
+		}
+
+		direction := uint8(directionIn)
+		if len(args) > 2 {
+			switch clean(args[2]) {
+			case "in":
+				direction = directionIn
+			case "out":
+				direction = directionOut
+			default:
+				return fmt.Errorf("invalid direction (%v) - expected 'in' or 'out'", args[2])
+			}
+		}
+
+		event, err := simulator.swipe(door.controller, door.door, cardNumber, direction)
+		if err != nil {
+			return err
+		}
+
+		c.print(ctx, &event)
+
+	case "open", "close":
+		if len(args) < 1 {
+			return fmt.Errorf("missing door")
+		}
+
+		door, err := resolveDoor(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		event, err := simulator.setDoor(door.controller, door.door, action == "open")
+		if err != nil {
+			return err
+		}
+
+		c.print(ctx, event)
+
+	case "button":
+		if len(args) < 1 {
+			return fmt.Errorf("missing door")
+		}
+
+		door, err := resolveDoor(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		granted, event, err := simulator.pushButton(door.controller, door.door)
+		if err != nil {
+			return err
+		} else if event == nil && granted {
+			fmt.Printf("%v  door %v unlocked\n", door.controller, door.door)
+		} else if event == nil {
+			fmt.Printf("%v  door %v not unlocked\n", door.controller, door.door)
+		}
+
+		c.print(ctx, event)
+
+	default:
+		return fmt.Errorf("invalid action (%v) - expected swipe, open, close or button", args[0])
+	}
+
+	return nil
+}
+
+// Prints the event recorded by the simulated controller (if any), with the reason text and
+// cardholder.
+func (c *Simulate) print(ctx Context, event *types.Event) {
+	if event == nil {
+		return
+	}
+
+	if name := getCardholders(ctx).annotate(event.CardNumber); event.Type == eventSwipe && name != "" {
+		fmt.Printf("%v  (%v)  %v\n", event, eventReason(event.Reason), name)
+	} else {
+		fmt.Printf("%v  (%v)\n", event, eventReason(event.Reason))
+	}
+}
+
+func (c *Simulate) CLI() string {
+	return "simulate"
+}
+
+func (c *Simulate) Description() string {
+	return "Simulates a card swipe, door or pushbutton on a simulated controller"
+}
+
+func (c *Simulate) Usage() string {
+	return "swipe <card> <door> [in|out] | open <door> | close <door> | button <door>"
+}
+
+func (c *Simulate) Help() {
+	fmt.Println("Usage: uhppote-cli --simulate <file> [options] simulate swipe <card> <door> [in|out]")
+	fmt.Println("       uhppote-cli --simulate <file> [options] simulate open <door>")
+	fmt.Println("       uhppote-cli --simulate <file> [options] simulate close <door>")
+	fmt.Println("       uhppote-cli --simulate <file> [options] simulate button <door>")
+	fmt.Println()
+	fmt.Println(" Drives the card readers, door sensors and pushbuttons of a controller simulated with --simulate and")
+	fmt.Println(" displays the event recorded by the simulated controller:")
+	fmt.Println()
+	fmt.Println("   swipe   swipes a card at the door reader. The swipe is checked against the card list, time profiles,")
+	fmt.Println("           door control mode, interlock and anti-passback and unlocks the door if access is granted")
+	fmt.Println("   open    opens the door. Opening a locked door records a 'door forced open' alarm")
+	fmt.Println("   close   closes the door")
+	fmt.Println("   button  presses the door pushbutton, which unlocks the door unless it is 'normally closed' or an")
+	fmt.Println("           interlocked door is open")
+	fmt.Println()
+	fmt.Println(" Door open/close and pushbutton events are only recorded if 'record special events' is enabled.")
+	fmt.Println()
+	fmt.Println("  card  card number or cardholder name from the cardholder registry")
+	fmt.Println("  door  door name from the configuration file or <serial number>:<door> (e.g. 405419896:1)")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --simulate  (required) State file for the simulated controllers")
+	fmt.Println("    --config    File path for the 'conf' file containing the controller configuration")
+	fmt.Printf("                (defaults to %s)\n", config.DefaultConfig)
+	fmt.Println("    --debug     Displays internal information for diagnosing errors")
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println()
+	fmt.Println("    uhppote-cli --simulate simulation.json simulate swipe 10058400 405419896:1")
+	fmt.Println("    uhppote-cli --simulate simulation.json simulate swipe Alice 'Great Hall' out")
+	fmt.Println("    uhppote-cli --simulate simulation.json simulate open 'Great Hall'")
+	fmt.Println("    uhppote-cli --simulate simulation.json simulate button 405419896:2")
+	fmt.Println()
+}
+
+// Returns false - configuration is useful but optional.
+func (c *Simulate) RequiresConfig() bool {
+	return false
+}
@@ -0,0 +1,434 @@
+package commands
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+var SimulateScheduleCmd = SimulateSchedule{
+	week:  "",
+	tasks: "",
+	json:  false,
+}
+
+type SimulateSchedule struct {
+	week  string
+	tasks string
+	json  bool
+}
+
+// doorTimeline is the expected door control mode at the start of the week and the expected
+// mode changes (and other scheduled door events) during the week.
+type doorTimeline struct {
+	door    uint8
+	name    string
+	initial types.ControlState
+	changes []doorChange
+}
+
+// doorChange is a scheduled door event. The mode is ModeUnknown for events that do not
+// change the door control mode (e.g. 'disable pushbutton').
+type doorChange struct {
+	at     time.Time
+	mode   types.ControlState
+	source string
+}
+
+func (c *SimulateSchedule) Execute(ctx Context) error {
+	serialNumber, err := getSerialNumber(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.parseArgs(); err != nil {
+		return err
+	}
+
+	monday, err := parseISOWeek(c.week)
+	if err != nil {
+		return err
+	}
+
+	// ... tasks
+	var tasks []types.Task
+	if c.tasks != "" {
+		if tasks, err = (&SetTaskList{}).parse(c.tasks); err != nil {
+			return err
+		}
+	} else if list, err := getTaskList(ctx, serialNumber); err != nil {
+		return err
+	} else if list == nil {
+		return fmt.Errorf("no recorded task list for %v (use --tasks to specify a task list file)", serialNumber)
+	} else {
+		if !list.Complete {
+			fmt.Fprintf(os.Stderr, "   WARN  %v task list was not set with set-task-list and may include unrecorded tasks\n", serialNumber)
+		}
+
+		tasks = list.Tasks
+	}
+
+	// ... first card
+	firstcards, err := getFirstCards(ctx, serialNumber)
+	if err != nil {
+		return err
+	}
+
+	// ... current door control state
+	names := map[uint8]string{}
+	for _, device := range ctx.devices {
+		if device.DeviceID == serialNumber {
+			for i, name := range device.Doors {
+				names[uint8(i+1)] = name
+			}
+		}
+	}
+
+	current := map[uint8]types.ControlState{}
+	for _, door := range []uint8{1, 2, 3, 4} {
+		if state, err := ctx.uhppote.GetDoorControlState(serialNumber, door); err != nil {
+			return err
+		} else if state != nil {
+			current[door] = state.ControlState
+		}
+	}
+
+	timelines := simulateWeek(monday, tasks, firstcards, current)
+	for i := range timelines {
+		timelines[i].name = names[timelines[i].door]
+	}
+
+	if c.json {
+		return c.export(serialNumber, monday, timelines, os.Stdout)
+	}
+
+	c.print(serialNumber, monday, timelines, os.Stdout)
+
+	return nil
+}
+
+// Evaluates the task list and first card configuration for the week starting on Monday. The
+// door control mode at the start of the week is the mode set by the last scheduled mode change
+// in the preceding week (or the current door control mode if there is none).
+func simulateWeek(monday time.Time, tasks []types.Task, firstcards map[uint8]types.FirstCard, current map[uint8]types.ControlState) []doorTimeline {
+	modes := map[types.TaskType]types.ControlState{
+		types.DoorControlled:     types.ModeControlled,
+		types.DoorNormallyOpen:   types.ModeNormallyOpen,
+		types.DoorNormallyClosed: types.ModeNormallyClosed,
+	}
+
+	timelines := []doorTimeline{}
+	for _, door := range slices.Sorted(maps.Keys(current)) {
+		events := []doorChange{}
+
+		for day := monday.AddDate(0, 0, -7); day.Before(monday.AddDate(0, 0, 7)); day = day.AddDate(0, 0, 1) {
+			date := types.ToDate(day.Year(), day.Month(), day.Day())
+
+			for i, task := range tasks {
+				if task.Door != door || date.Before(task.From) || date.After(task.To) || !task.Weekdays[day.Weekday()] {
+					continue
+				}
+
+				events = append(events, doorChange{
+					at:     atTime(day, task.Start),
+					mode:   modes[task.Task],
+					source: fmt.Sprintf("task %v: %v", i+1, strings.ToLower(fmt.Sprintf("%v", task.Task))),
+				})
+			}
+
+			if firstcard, ok := firstcards[door]; ok && firstcard.Weekdays[day.Weekday()] {
+				events = append(events,
+					doorChange{
+						at:     atTime(day, firstcard.StartTime),
+						mode:   types.ModeFirstCardOnly,
+						source: fmt.Sprintf("first card start (%v after first card)", firstcard.Active),
+					},
+					doorChange{
+						at:     atTime(day, firstcard.EndTime),
+						mode:   firstcard.Inactive,
+						source: "first card end",
+					})
+			}
+		}
+
+		slices.SortStableFunc(events, func(p, q doorChange) int {
+			return p.at.Compare(q.at)
+		})
+
+		timeline := doorTimeline{
+			door:    door,
+			initial: current[door],
+			changes: []doorChange{},
+		}
+
+		for _, e := range events {
+			if e.at.Before(monday) {
+				if e.mode != types.ModeUnknown {
+					timeline.initial = e.mode
+				}
+			} else {
+				timeline.changes = append(timeline.changes, e)
+			}
+		}
+
+		timelines = append(timelines, timeline)
+	}
+
+	return timelines
+}
+
+// Returns the door control mode at a time.
+func (t doorTimeline) modeAt(at time.Time) types.ControlState {
+	mode := t.initial
+	for _, e := range t.changes {
+		if e.at.After(at) {
+			break
+		} else if e.mode != types.ModeUnknown {
+			mode = e.mode
+		}
+	}
+
+	return mode
+}
+
+func (c *SimulateSchedule) print(serialNumber uint32, monday time.Time, timelines []doorTimeline, w io.Writer) {
+	symbols := map[types.ControlState]string{
+		types.ModeUnknown:        "?",
+		types.ModeControlled:     "C",
+		types.ModeNormallyOpen:   "O",
+		types.ModeNormallyClosed: "X",
+		types.ModeFirstCardOnly:  "F",
+	}
+
+	year, week := monday.ISOWeek()
+	fmt.Fprintf(w, "%v  week %04d-W%02d (%v to %v)\n", serialNumber, year, week, monday.Format("2006-01-02"), monday.AddDate(0, 0, 6).Format("2006-01-02"))
+
+	for _, t := range timelines {
+		fmt.Fprintln(w)
+		if t.name != "" {
+			fmt.Fprintf(w, "  door %v (%v)  %v\n", t.door, t.name, t.initial)
+		} else {
+			fmt.Fprintf(w, "  door %v  %v\n", t.door, t.initial)
+		}
+
+		table := [][]string{}
+		for _, e := range t.changes {
+			mode := ""
+			if e.mode != types.ModeUnknown {
+				mode = fmt.Sprintf("%v", e.mode)
+			}
+
+			table = append(table, []string{e.at.Format("Mon 15:04"), mode, e.source})
+		}
+
+		for _, row := range format(table) {
+			fmt.Fprintf(w, "    %v\n", strings.TrimRight(row, " "))
+		}
+
+		fmt.Fprintln(w)
+
+		header := []string{}
+		for hour := range 24 {
+			header = append(header, fmt.Sprintf("%02d", hour))
+		}
+
+		fmt.Fprintf(w, "         %v\n", strings.Join(header, " "))
+		for day := range 7 {
+			date := monday.AddDate(0, 0, day)
+			cells := []string{}
+			for hour := range 24 {
+				cells = append(cells, fmt.Sprintf("%-2v", symbols[t.modeAt(date.Add(time.Duration(hour)*time.Hour))]))
+			}
+
+			fmt.Fprintf(w, "    %v  %v\n", date.Format("Mon"), strings.TrimRight(strings.Join(cells, " "), " "))
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "  C: controlled  O: normally open  X: normally closed  F: first card (mode at the start of each hour)")
+	fmt.Fprintln(w)
+}
+
+func (c *SimulateSchedule) export(serialNumber uint32, monday time.Time, timelines []doorTimeline, w io.Writer) error {
+	type change struct {
+		At     string             `json:"at"`
+		Mode   types.ControlState `json:"mode,omitempty"`
+		Source string             `json:"source"`
+	}
+
+	type door struct {
+		Door    uint8              `json:"door"`
+		Name    string             `json:"name,omitempty"`
+		Initial types.ControlState `json:"initial,omitempty"`
+		Changes []change           `json:"changes"`
+	}
+
+	year, week := monday.ISOWeek()
+	report := struct {
+		Controller uint32 `json:"controller"`
+		Week       string `json:"week"`
+		From       string `json:"from"`
+		To         string `json:"to"`
+		Doors      []door `json:"doors"`
+	}{
+		Controller: serialNumber,
+		Week:       fmt.Sprintf("%04d-W%02d", year, week),
+		From:       monday.Format("2006-01-02"),
+		To:         monday.AddDate(0, 0, 6).Format("2006-01-02"),
+		Doors:      []door{},
+	}
+
+	for _, t := range timelines {
+		d := door{
+			Door:    t.door,
+			Name:    t.name,
+			Initial: t.initial,
+			Changes: []change{},
+		}
+
+		for _, e := range t.changes {
+			d.Changes = append(d.Changes, change{
+				At:     e.at.Format("2006-01-02 15:04"),
+				Mode:   e.mode,
+				Source: e.source,
+			})
+		}
+
+		report.Doors = append(report.Doors, d)
+	}
+
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s\n", b)
+
+	return err
+}
+
+// Returns the time on a day for an HH:mm time.
+func atTime(day time.Time, hhmm types.HHmm) time.Time {
+	var hours, minutes int
+
+	fmt.Sscanf(fmt.Sprintf("%v", hhmm), "%d:%d", &hours, &minutes)
+
+	return day.Add(time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute)
+}
+
+// Parses an ISO 8601 week (e.g. 2026-W43) and returns the Monday of the week. Returns the
+// Monday of the current week if the week is "".
+func parseISOWeek(s string) (time.Time, error) {
+	now := time.Now()
+	year, week := now.ISOWeek()
+
+	if s != "" {
+		match := regexp.MustCompile(`^([0-9]{4})-?[wW]([0-9]{1,2})$`).FindStringSubmatch(s)
+		if match == nil {
+			return time.Time{}, fmt.Errorf("invalid week '%v' (expected e.g. 2026-W43)", s)
+		}
+
+		year, _ = strconv.Atoi(match[1])
+		week, _ = strconv.Atoi(match[2])
+	}
+
+	// ... week 1 is the week with January 4th
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.Local)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)).AddDate(0, 0, 7*(week-1))
+
+	if y, w := monday.ISOWeek(); y != year || w != week {
+		return time.Time{}, fmt.Errorf("invalid week '%v'", s)
+	}
+
+	return monday, nil
+}
+
+func (c *SimulateSchedule) parseArgs() error {
+	flagset := newFlagSet()
+	week := flagset.String("week", "", "ISO week e.g. 2026-W43")
+	tasks := flagset.String("tasks", "", "TSV or .schedule file with the task list")
+	asJSON := flagset.Bool("json", false, "Displays the timeline as JSON")
+
+	if args := flag.Args(); len(args) > 2 {
+		if err := flagset.Parse(args[2:]); err != nil {
+			return err
+		}
+	}
+
+	if *tasks != "" {
+		if stat, err := os.Stat(*tasks); err != nil && os.IsNotExist(err) {
+			return fmt.Errorf("file '%s' does not exist", *tasks)
+		} else if err != nil {
+			return err
+		} else if !stat.Mode().IsRegular() {
+			return fmt.Errorf("file '%s' is not a real file", *tasks)
+		}
+	}
+
+	c.week = *week
+	c.tasks = *tasks
+	c.json = *asJSON
+
+	return nil
+}
+
+func (c *SimulateSchedule) CLI() string {
+	return "simulate-schedule"
+}
+
+func (c *SimulateSchedule) Description() string {
+	return "Displays the expected door control modes for a controller for a week"
+}
+
+func (c *SimulateSchedule) Usage() string {
+	return "<serial number> [--week <week>] [--tasks <file>] [--json]"
+}
+
+func (c *SimulateSchedule) Help() {
+	fmt.Println("Usage: uhppote-cli [options] simulate-schedule <serial number> [--week <week>] [--tasks <file>] [--json]")
+	fmt.Println()
+	fmt.Println(" Evaluates the task list and first card configuration for a controller and displays the expected door control")
+	fmt.Println(" mode changes for each door for a week, along with an hourly grid of the door control modes.")
+	fmt.Println()
+	fmt.Println(" The task list defaults to the task list recorded in the local state store (see get-task-list) and the first")
+	fmt.Println(" card configuration is the configuration recorded by set-firstcard. The door control mode at the start of the")
+	fmt.Println(" week is the mode set by the last scheduled mode change in the preceding week or, if there is none, the current")
+	fmt.Println(" door control mode on the controller. Card swipes are not simulated i.e. a door in first card mode remains in")
+	fmt.Println(" first card mode until the first card end time.")
+	fmt.Println()
+	fmt.Println("  serial number  (required) controller serial number")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --config  File path for the 'conf' file containing the controller configuration")
+	fmt.Printf("              (defaults to %s)\n", config.DefaultConfig)
+	fmt.Println("    --debug   Displays internal information for diagnosing errors")
+	fmt.Println()
+	fmt.Println("  Command options:")
+	fmt.Println()
+	fmt.Println("    --week   ISO week (e.g. 2026-W43). Defaults to the current week")
+	fmt.Println("    --tasks  TSV or .schedule file with the task list (in the format used by set-task-list)")
+	fmt.Println("    --json   Displays the timeline as JSON")
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println()
+	fmt.Println("    uhppote-cli simulate-schedule 9876543210")
+	fmt.Println("    uhppote-cli simulate-schedule 9876543210 --week 2026-W43 --tasks office-hours.schedule --json")
+	fmt.Println()
+}
+
+// Returns false - configuration is useful but optional.
+func (c *SimulateSchedule) RequiresConfig() bool {
+	return false
+}
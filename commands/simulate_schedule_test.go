@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+func TestParseISOWeek(t *testing.T) {
+	tests := map[string]string{
+		"2026-W43": "2026-10-19",
+		"2026W01":  "2025-12-29",
+		"2020-W53": "2020-12-28",
+	}
+
+	for week, expected := range tests {
+		if monday, err := parseISOWeek(week); err != nil {
+			t.Errorf("%v: unexpected error (%v)", week, err)
+		} else if monday.Format("2006-01-02") != expected {
+			t.Errorf("%v: incorrect Monday - expected:%v, got:%v", week, expected, monday.Format("2006-01-02"))
+		}
+	}
+
+	for _, week := range []string{"2026-W54", "2026-43", "W43"} {
+		if _, err := parseISOWeek(week); err == nil {
+			t.Errorf("%v: expected error", week)
+		}
+	}
+}
+
+func TestSimulateWeek(t *testing.T) {
+	monday, _ := parseISOWeek("2026-W43")
+
+	tasks := []types.Task{
+		testTask(types.DoorNormallyOpen, 1, "08:00"),
+		testTask(types.DoorNormallyClosed, 1, "18:00"),
+		testTask(types.DisablePushButton, 1, "18:30"),
+		testTask(types.DoorNormallyOpen, 2, "09:00"),
+	}
+
+	firstcards := map[uint8]types.FirstCard{
+		2: {
+			StartTime: types.MustParseHHmm("07:00"),
+			EndTime:   types.MustParseHHmm("08:30"),
+			Active:    types.ModeNormallyOpen,
+			Inactive:  types.ModeControlled,
+			Weekdays:  types.Weekdays{time.Saturday: true},
+		},
+	}
+
+	current := map[uint8]types.ControlState{
+		1: types.ModeControlled,
+		2: types.ModeControlled,
+	}
+
+	timelines := simulateWeek(monday, tasks, firstcards, current)
+
+	var b bytes.Buffer
+	(&SimulateSchedule{}).print(405419896, monday, timelines[:1], &b)
+
+	expected := `405419896  week 2026-W43 (2026-10-19 to 2026-10-25)
+
+  door 1  normally closed
+    Mon 08:00  normally open    task 1: unlock door
+    Mon 18:00  normally closed  task 2: lock door
+    Mon 18:30                   task 3: disable push button
+    Tue 08:00  normally open    task 1: unlock door
+    Tue 18:00  normally closed  task 2: lock door
+    Tue 18:30                   task 3: disable push button
+    Wed 08:00  normally open    task 1: unlock door
+    Wed 18:00  normally closed  task 2: lock door
+    Wed 18:30                   task 3: disable push button
+    Thu 08:00  normally open    task 1: unlock door
+    Thu 18:00  normally closed  task 2: lock door
+    Thu 18:30                   task 3: disable push button
+    Fri 08:00  normally open    task 1: unlock door
+    Fri 18:00  normally closed  task 2: lock door
+    Fri 18:30                   task 3: disable push button
+
+         00 01 02 03 04 05 06 07 08 09 10 11 12 13 14 15 16 17 18 19 20 21 22 23
+    Mon  X  X  X  X  X  X  X  X  O  O  O  O  O  O  O  O  O  O  X  X  X  X  X  X
+    Tue  X  X  X  X  X  X  X  X  O  O  O  O  O  O  O  O  O  O  X  X  X  X  X  X
+    Wed  X  X  X  X  X  X  X  X  O  O  O  O  O  O  O  O  O  O  X  X  X  X  X  X
+    Thu  X  X  X  X  X  X  X  X  O  O  O  O  O  O  O  O  O  O  X  X  X  X  X  X
+    Fri  X  X  X  X  X  X  X  X  O  O  O  O  O  O  O  O  O  O  X  X  X  X  X  X
+    Sat  X  X  X  X  X  X  X  X  X  X  X  X  X  X  X  X  X  X  X  X  X  X  X  X
+    Sun  X  X  X  X  X  X  X  X  X  X  X  X  X  X  X  X  X  X  X  X  X  X  X  X
+
+  C: controlled  O: normally open  X: normally closed  F: first card (mode at the start of each hour)
+
+`
+
+	if b.String() != expected {
+		t.Errorf("incorrect timeline\n   expected:\n%v\n   got:\n%v", expected, b.String())
+	}
+
+	// ... door 2: first card on Saturday, initial mode from the preceding Saturday first card end
+	door2 := timelines[1]
+	if door2.initial != types.ModeControlled {
+		t.Errorf("door 2: incorrect initial mode - expected:%v, got:%v", types.ModeControlled, door2.initial)
+	}
+
+	friday := monday.AddDate(0, 0, 4)
+	if mode := door2.modeAt(friday.Add(10 * time.Hour)); mode != types.ModeNormallyOpen {
+		t.Errorf("door 2: incorrect Friday 10:00 mode - expected:%v, got:%v", types.ModeNormallyOpen, mode)
+	}
+
+	saturday := monday.AddDate(0, 0, 5)
+	if mode := door2.modeAt(saturday.Add(7 * time.Hour)); mode != types.ModeFirstCardOnly {
+		t.Errorf("door 2: incorrect Saturday 07:00 mode - expected:%v, got:%v", types.ModeFirstCardOnly, mode)
+	}
+
+	if mode := door2.modeAt(saturday.Add(9 * time.Hour)); mode != types.ModeControlled {
+		t.Errorf("door 2: incorrect Saturday 09:00 mode - expected:%v, got:%v", types.ModeControlled, mode)
+	}
+}
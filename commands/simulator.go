@@ -0,0 +1,1215 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppote-core/uhppote"
+)
+
+// Simulator is an in-process implementation of uhppote.IUHPPOTE that emulates a set of
+// UHPPOTE controllers, for rehearsing commands and for integration tests without any
+// hardware. The simulated controllers are saved to a JSON state file after every change
+// and reloaded if the file is changed by another process, so a simulation carries over
+// between commands (e.g. 'listen' in one terminal and 'simulate swipe' in another). Changes
+// are made while holding an exclusive '<file>.lock' lock file so that concurrent changes from
+// different processes are not lost.
+type Simulator struct {
+	file        string
+	devices     map[uint32]uhppote.Device
+	controllers map[uint32]*simController
+	info        os.FileInfo
+	sync.Mutex
+}
+
+// simController is the state of a simulated controller.
+type simController struct {
+	id            uint32
+	Address       netip.Addr                  `json:"address"`
+	Netmask       netip.Addr                  `json:"netmask"`
+	Gateway       netip.Addr                  `json:"gateway"`
+	MAC           string                      `json:"MAC"`
+	Version       types.Version               `json:"version"`
+	Released      types.Date                  `json:"released"`
+	Listener      netip.AddrPort              `json:"listener"`
+	Interval      uint8                       `json:"interval"`
+	Offset        time.Duration               `json:"time-offset"`
+	Doors         map[uint8]*simDoor          `json:"doors"`
+	Cards         []types.Card                `json:"cards"`
+	Profiles      map[uint8]types.TimeProfile `json:"time-profiles"`
+	Tasks         []types.Task                `json:"tasks"`
+	PendingTasks  []types.Task                `json:"pending-tasks"`
+	Events        []types.Event               `json:"events"`
+	EventBuffer   int                         `json:"event-buffer"`
+	EventIndex    uint32                      `json:"event-index"`
+	SpecialEvents bool                        `json:"record-special-events"`
+	PCControl     bool                        `json:"pc-control"`
+	Interlock     types.Interlock             `json:"interlock"`
+	AntiPassback  types.AntiPassback          `json:"antipassback"`
+	Passages      map[uint32]map[uint8]uint8  `json:"passages"`
+	Keypads       map[uint8]bool              `json:"keypads"`
+}
+
+// simDoor is the state of a door on a simulated controller.
+type simDoor struct {
+	Mode      types.ControlState `json:"mode"`
+	Delay     uint8              `json:"delay"`
+	Open      bool               `json:"open"`
+	Unlocked  time.Time          `json:"unlocked-until,omitzero"`
+	Passcodes []uint32           `json:"passcodes,omitempty"`
+	FirstCard *types.FirstCard   `json:"first-card,omitempty"`
+}
+
+// simState is the JSON state file.
+type simState struct {
+	Controllers map[uint32]*simController `json:"controllers"`
+}
+
+const (
+	simController1  = 405419896
+	simEventBuffer  = 100000
+	simDefaultDelay = 3
+	simLockTimeout  = 5 * time.Second
+	simLockStale    = 30 * time.Second
+)
+
+// NewSimulator loads a simulation from the state file, creating the file if it does not
+// exist. A simulated controller is added for each configured controller that is not in the
+// state file (or a single controller 405419896 for an empty simulation).
+func NewSimulator(file string, devices []uhppote.Device) (*Simulator, error) {
+	s := Simulator{
+		file:        file,
+		devices:     map[uint32]uhppote.Device{},
+		controllers: map[uint32]*simController{},
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+
+	defer unlock()
+
+	if err := s.load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	N := len(s.controllers)
+	for _, d := range devices {
+		s.devices[d.DeviceID] = d
+
+		if _, ok := s.controllers[d.DeviceID]; !ok {
+			s.controllers[d.DeviceID] = newSimController(d.DeviceID, d.Address.Addr())
+		}
+	}
+
+	if len(s.controllers) == 0 {
+		s.controllers[simController1] = newSimController(simController1, netip.Addr{})
+	}
+
+	if len(s.controllers) != N {
+		if err := s.save(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &s, nil
+}
+
+func newSimController(id uint32, address netip.Addr) *simController {
+	if !address.Is4() {
+		address = netip.AddrFrom4([4]byte{192, 168, 1, 100})
+	}
+
+	c := simController{
+		Address:  address,
+		Netmask:  netip.AddrFrom4([4]byte{255, 255, 255, 0}),
+		Gateway:  netip.AddrFrom4([4]byte{0, 0, 0, 0}),
+		MAC:      fmt.Sprintf("00:12:%02x:%02x:%02x:%02x", byte(id>>24), byte(id>>16), byte(id>>8), byte(id)),
+		Version:  0x0892,
+		Released: types.ToDate(2018, time.November, 5),
+	}
+
+	c.normalise(id)
+
+	return &c
+}
+
+// Initialises the unset fields of a controller loaded from a state file.
+func (c *simController) normalise(id uint32) {
+	c.id = id
+
+	if c.Doors == nil {
+		c.Doors = map[uint8]*simDoor{}
+	}
+
+	for _, door := range []uint8{1, 2, 3, 4} {
+		if c.Doors[door] == nil {
+			c.Doors[door] = &simDoor{Mode: types.Controlled, Delay: simDefaultDelay}
+		}
+	}
+
+	if c.Cards == nil {
+		c.Cards = []types.Card{}
+	}
+
+	if c.Profiles == nil {
+		c.Profiles = map[uint8]types.TimeProfile{}
+	}
+
+	if c.Tasks == nil {
+		c.Tasks = []types.Task{}
+	}
+
+	if c.PendingTasks == nil {
+		c.PendingTasks = []types.Task{}
+	}
+
+	if c.Events == nil {
+		c.Events = []types.Event{}
+	}
+
+	if c.EventBuffer <= 0 {
+		c.EventBuffer = simEventBuffer
+	}
+
+	if c.Passages == nil {
+		c.Passages = map[uint32]map[uint8]uint8{}
+	}
+
+	if c.Keypads == nil {
+		c.Keypads = map[uint8]bool{1: false, 2: false, 3: false, 4: false}
+	}
+}
+
+func (s *Simulator) load() error {
+	b, err := os.ReadFile(s.file)
+	if err != nil {
+		return err
+	}
+
+	state := simState{}
+	if err := json.Unmarshal(b, &state); err != nil {
+		return fmt.Errorf("%v: %v", s.file, err)
+	}
+
+	s.controllers = map[uint32]*simController{}
+	for id, c := range state.Controllers {
+		if c != nil {
+			c.normalise(id)
+			s.controllers[id] = c
+		}
+	}
+
+	if info, err := os.Stat(s.file); err == nil {
+		s.info = info
+	}
+
+	return nil
+}
+
+func (s *Simulator) save() error {
+	if err := writeJSONFile(s.file, simState{Controllers: s.controllers}); err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(s.file); err == nil {
+		s.info = info
+	}
+
+	return nil
+}
+
+// Reloads the state file if it has been replaced by another process (the state file is
+// always replaced rather than rewritten).
+func (s *Simulator) refresh() error {
+	if info, err := os.Stat(s.file); err == nil && (s.info == nil || !os.SameFile(info, s.info) || !info.ModTime().Equal(s.info.ModTime())) {
+		return s.load()
+	}
+
+	return nil
+}
+
+// Takes the exclusive state file lock, waiting for up to simLockTimeout for another process to
+// release it. A lock file older than simLockStale is assumed to have been left by a killed
+// process and is removed. Returns the function that releases the lock.
+func (s *Simulator) lock() (func(), error) {
+	file := s.file + ".lock"
+	timeout := time.Now().Add(simLockTimeout)
+
+	if err := os.MkdirAll(filepath.Dir(file), 0770); err != nil {
+		return nil, err
+	}
+
+	for {
+		f, err := os.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0660)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(file) }, nil
+		} else if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(file); err == nil && time.Since(info.ModTime()) > simLockStale {
+			os.Remove(file)
+		} else if time.Now().After(timeout) {
+			return nil, fmt.Errorf("simulation locked by another process (remove %v if the process has exited)", file)
+		} else {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+// Invokes f with the current state of a simulated controller.
+func (s *Simulator) view(controller uint32, f func(c *simController) error) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return err
+	} else if c, ok := s.controllers[controller]; !ok {
+		return fmt.Errorf("no response from controller %v (not a simulated controller)", controller)
+	} else {
+		return f(c)
+	}
+}
+
+// Invokes f with the current state of a simulated controller and saves the updated state. The
+// state file is locked from the refresh through to the save.
+func (s *Simulator) update(controller uint32, f func(c *simController) error) error {
+	s.Lock()
+	defer s.Unlock()
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	if err := s.refresh(); err != nil {
+		return err
+	} else if c, ok := s.controllers[controller]; !ok {
+		return fmt.Errorf("no response from controller %v (not a simulated controller)", controller)
+	} else if err := f(c); err != nil {
+		return err
+	} else {
+		return s.save()
+	}
+}
+
+func (s *Simulator) GetDevices() ([]types.Device, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+
+	devices := []types.Device{}
+	for _, id := range slices.Sorted(maps.Keys(s.controllers)) {
+		devices = append(devices, s.device(s.controllers[id]))
+	}
+
+	return devices, nil
+}
+
+func (s *Simulator) GetDevice(controller uint32) (*types.Device, error) {
+	var device types.Device
+
+	err := s.view(controller, func(c *simController) error {
+		device = s.device(c)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &device, nil
+}
+
+func (s *Simulator) device(c *simController) types.Device {
+	mac, _ := net.ParseMAC(c.MAC)
+
+	return types.Device{
+		Name:         s.devices[c.id].Name,
+		SerialNumber: types.SerialNumber(c.id),
+		IpAddress:    net.IP(c.Address.AsSlice()),
+		SubnetMask:   net.IP(c.Netmask.AsSlice()),
+		Gateway:      net.IP(c.Gateway.AsSlice()),
+		MacAddress:   types.MacAddress(mac),
+		Version:      c.Version,
+		Date:         c.Released,
+		Address:      netip.AddrPortFrom(c.Address, types.CONTROLLER_PORT),
+		TimeZone:     time.Local,
+	}
+}
+
+func (s *Simulator) SetAddress(controller uint32, address, mask, gateway net.IP) (*types.Result, error) {
+	err := s.update(controller, func(c *simController) error {
+		ip, ok := netip.AddrFromSlice(address.To4())
+		if !ok {
+			return fmt.Errorf("invalid IP address (%v)", address)
+		}
+
+		c.Address = ip
+		c.Netmask, _ = netip.AddrFromSlice(mask.To4())
+		c.Gateway, _ = netip.AddrFromSlice(gateway.To4())
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Result{SerialNumber: types.SerialNumber(controller), Succeeded: true}, nil
+}
+
+func (s *Simulator) GetListener(controller uint32) (netip.AddrPort, uint8, error) {
+	var address netip.AddrPort
+	var interval uint8
+
+	err := s.view(controller, func(c *simController) error {
+		address = c.Listener
+		interval = c.Interval
+		return nil
+	})
+
+	return address, interval, err
+}
+
+func (s *Simulator) SetListener(controller uint32, address netip.AddrPort, interval uint8) (bool, error) {
+	if !address.Addr().Is4() {
+		return false, fmt.Errorf("invalid listener address (%v) - expected IPv4 address:port", address)
+	} else if address.Port() == 0 && !address.Addr().IsUnspecified() {
+		return false, fmt.Errorf("invalid listener address (%v) - port 0 is only valid for 0.0.0.0:0", address)
+	}
+
+	err := s.update(controller, func(c *simController) error {
+		c.Listener = address
+		c.Interval = interval
+		return nil
+	})
+
+	return err == nil, err
+}
+
+func (s *Simulator) GetTime(controller uint32) (*types.Time, error) {
+	var t types.Time
+
+	err := s.view(controller, func(c *simController) error {
+		t = types.Time{SerialNumber: types.SerialNumber(controller), DateTime: types.DateTime(c.now())}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (s *Simulator) SetTime(controller uint32, datetime time.Time) (*types.Time, error) {
+	var t types.Time
+
+	err := s.update(controller, func(c *simController) error {
+		c.Offset = time.Until(datetime).Round(time.Second)
+		t = types.Time{SerialNumber: types.SerialNumber(controller), DateTime: types.DateTime(c.now())}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (s *Simulator) GetDoorControlState(controller uint32, door byte) (*types.DoorControlState, error) {
+	var state types.DoorControlState
+
+	err := s.view(controller, func(c *simController) error {
+		if d, err := c.door(door); err != nil {
+			return err
+		} else {
+			state = types.DoorControlState{SerialNumber: types.SerialNumber(controller), Door: door, ControlState: d.Mode, Delay: d.Delay}
+			return nil
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+func (s *Simulator) SetDoorControlState(controller uint32, door uint8, state types.ControlState, delay uint8) (*types.DoorControlState, error) {
+	if state < types.NormallyOpen || state > types.Controlled {
+		return nil, fmt.Errorf("invalid door control state (%v)", state)
+	}
+
+	err := s.update(controller, func(c *simController) error {
+		if d, err := c.door(door); err != nil {
+			return err
+		} else {
+			d.Mode = state
+			d.Delay = delay
+			return nil
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.DoorControlState{SerialNumber: types.SerialNumber(controller), Door: door, ControlState: state, Delay: delay}, nil
+}
+
+func (s *Simulator) SetDoorPasscodes(controller uint32, door uint8, passcodes ...uint32) (bool, error) {
+	err := s.update(controller, func(c *simController) error {
+		d, err := c.door(door)
+		if err != nil {
+			return err
+		}
+
+		d.Passcodes = []uint32{0, 0, 0, 0}
+		for i, code := range passcodes[:min(len(passcodes), 4)] {
+			if code <= 999999 {
+				d.Passcodes[i] = code
+			}
+		}
+
+		return nil
+	})
+
+	return err == nil, err
+}
+
+func (s *Simulator) GetStatus(controller uint32) (*types.Status, error) {
+	var status types.Status
+
+	err := s.view(controller, func(c *simController) error {
+		status = c.status()
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+func (s *Simulator) GetCards(controller uint32) (uint32, error) {
+	var N uint32
+
+	err := s.view(controller, func(c *simController) error {
+		N = uint32(len(c.Cards))
+		return nil
+	})
+
+	return N, err
+}
+
+func (s *Simulator) GetCardByIndex(controller, index uint32) (*types.Card, error) {
+	var card *types.Card
+
+	err := s.view(controller, func(c *simController) error {
+		if index > 0 && index <= uint32(len(c.Cards)) {
+			card = copyCard(c.Cards[index-1])
+		}
+
+		return nil
+	})
+
+	return card, err
+}
+
+func (s *Simulator) GetCardByID(controller, cardNumber uint32) (*types.Card, error) {
+	var card *types.Card
+
+	err := s.view(controller, func(c *simController) error {
+		if ix := c.card(cardNumber); ix >= 0 {
+			card = copyCard(c.Cards[ix])
+		}
+
+		return nil
+	})
+
+	return card, err
+}
+
+func (s *Simulator) PutCard(controller uint32, card types.Card, formats ...types.CardFormat) (bool, error) {
+	switch {
+	case card.CardNumber == 0 || card.CardNumber == 0xffffffff || card.CardNumber == 0x00ffffff:
+		return false, uhppote.ErrInvalidCard
+
+	case slices.Contains(formats, types.Wiegand26) && !slices.Contains(formats, types.WiegandAny) && !isWiegand26(card.CardNumber):
+		return false, fmt.Errorf("invalid card number (%v)", card.CardNumber)
+
+	case card.PIN > 999999:
+		return false, fmt.Errorf("invalid PIN (%v)", card.PIN)
+
+	case card.From.IsZero() || card.To.IsZero():
+		return false, fmt.Errorf("invalid start or end date (%v to %v)", card.From, card.To)
+	}
+
+	err := s.update(controller, func(c *simController) error {
+		record := *copyCard(card)
+		if ix := c.card(card.CardNumber); ix >= 0 {
+			c.Cards[ix] = record
+		} else {
+			c.Cards = append(c.Cards, record)
+		}
+
+		return nil
+	})
+
+	return err == nil, err
+}
+
+func (s *Simulator) DeleteCard(controller uint32, cardNumber uint32) (bool, error) {
+	deleted := false
+
+	err := s.update(controller, func(c *simController) error {
+		if ix := c.card(cardNumber); ix >= 0 {
+			c.Cards = slices.Delete(c.Cards, ix, ix+1)
+			deleted = true
+		}
+
+		return nil
+	})
+
+	return deleted, err
+}
+
+func (s *Simulator) DeleteCards(controller uint32) (bool, error) {
+	err := s.update(controller, func(c *simController) error {
+		c.Cards = []types.Card{}
+		return nil
+	})
+
+	return err == nil, err
+}
+
+func (s *Simulator) GetTimeProfile(controller uint32, profileID uint8) (*types.TimeProfile, error) {
+	var profile *types.TimeProfile
+
+	err := s.view(controller, func(c *simController) error {
+		if p, ok := c.Profiles[profileID]; ok {
+			profile = &p
+		}
+
+		return nil
+	})
+
+	return profile, err
+}
+
+func (s *Simulator) SetTimeProfile(controller uint32, profile types.TimeProfile) (bool, error) {
+	if profile.ID < 2 || profile.ID > 254 {
+		return false, fmt.Errorf("invalid time profile ID (%v)", profile.ID)
+	} else if profile.LinkedProfileID == 1 || profile.LinkedProfileID == 255 {
+		return false, fmt.Errorf("invalid linked time profile ID (%v)", profile.LinkedProfileID)
+	}
+
+	err := s.update(controller, func(c *simController) error {
+		c.Profiles[profile.ID] = profile
+		return nil
+	})
+
+	return err == nil, err
+}
+
+func (s *Simulator) ClearTimeProfiles(controller uint32) (bool, error) {
+	err := s.update(controller, func(c *simController) error {
+		c.Profiles = map[uint8]types.TimeProfile{}
+		return nil
+	})
+
+	return err == nil, err
+}
+
+// Clears the pending task list. The active task list is replaced by the pending task list
+// on 'refresh-task-list'.
+func (s *Simulator) ClearTaskList(controller uint32) (bool, error) {
+	err := s.update(controller, func(c *simController) error {
+		c.PendingTasks = []types.Task{}
+		return nil
+	})
+
+	return err == nil, err
+}
+
+func (s *Simulator) AddTask(controller uint32, task types.Task) (bool, error) {
+	err := s.update(controller, func(c *simController) error {
+		c.PendingTasks = append(c.PendingTasks, task)
+		return nil
+	})
+
+	return err == nil, err
+}
+
+func (s *Simulator) RefreshTaskList(controller uint32) (bool, error) {
+	err := s.update(controller, func(c *simController) error {
+		c.Tasks = slices.Clone(c.PendingTasks)
+		return nil
+	})
+
+	return err == nil, err
+}
+
+func (s *Simulator) RecordSpecialEvents(controller uint32, enable bool) (bool, error) {
+	err := s.update(controller, func(c *simController) error {
+		c.SpecialEvents = enable
+		return nil
+	})
+
+	return err == nil, err
+}
+
+// Retrieves an event from the event buffer. Index 0 retrieves the first (oldest) event and
+// index 0xffffffff retrieves the last event. Returns an error if the event has been
+// overwritten and nil if the index is after the last event.
+func (s *Simulator) GetEvent(controller, index uint32) (*types.Event, error) {
+	var event *types.Event
+
+	err := s.view(controller, func(c *simController) error {
+		if len(c.Events) == 0 {
+			return nil
+		}
+
+		first := c.Events[0].Index
+		last := c.Events[len(c.Events)-1].Index
+
+		switch {
+		case index == 0:
+			event = &c.Events[0]
+
+		case index == 0xffffffff:
+			event = &c.Events[len(c.Events)-1]
+
+		case index < first:
+			return fmt.Errorf("event at index %v has been overwritten", index)
+
+		case index <= last:
+			event = &c.Events[index-first]
+		}
+
+		return nil
+	})
+
+	if err != nil || event == nil {
+		return nil, err
+	}
+
+	e := *event
+
+	return &e, nil
+}
+
+func (s *Simulator) GetEventIndex(controller uint32) (*types.EventIndex, error) {
+	var index types.EventIndex
+
+	err := s.view(controller, func(c *simController) error {
+		index = types.EventIndex{SerialNumber: types.SerialNumber(controller), Index: c.EventIndex}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &index, nil
+}
+
+func (s *Simulator) SetEventIndex(controller, index uint32) (*types.EventIndexResult, error) {
+	var result types.EventIndexResult
+
+	err := s.update(controller, func(c *simController) error {
+		result = types.EventIndexResult{SerialNumber: types.SerialNumber(controller), Index: index, Changed: c.EventIndex != index}
+		c.EventIndex = index
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Sends the status (with the new event) to the listener for each event recorded by a
+// simulated controller, until the q channel is signalled or closed. The state file is
+// polled for events recorded by other processes.
+func (s *Simulator) Listen(listener uhppote.Listener, q chan os.Signal) error {
+	last := map[uint32]uint32{}
+	poll := func() []types.Status {
+		s.Lock()
+		defer s.Unlock()
+
+		if err := s.refresh(); err != nil {
+			listener.OnError(err)
+		}
+
+		list := []types.Status{}
+		for _, id := range slices.Sorted(maps.Keys(s.controllers)) {
+			c := s.controllers[id]
+			for _, e := range c.eventsAfter(last[id]) {
+				status := c.status()
+				status.Event = types.StatusEvent{
+					Index:      e.Index,
+					Type:       e.Type,
+					Granted:    e.Granted,
+					Door:       e.Door,
+					Direction:  e.Direction,
+					CardNumber: e.CardNumber,
+					Timestamp:  e.Timestamp,
+					Reason:     e.Reason,
+				}
+
+				list = append(list, status)
+			}
+
+			if N := len(c.Events); N > 0 {
+				last[id] = c.Events[N-1].Index
+			}
+		}
+
+		return list
+	}
+
+	poll()
+	listener.OnConnected()
+
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q:
+			return nil
+
+		case <-ticker.C:
+			for _, status := range poll() {
+				listener.OnEvent(&status)
+			}
+		}
+	}
+}
+
+func (s *Simulator) OpenDoor(controller uint32, door uint8) (*types.Result, error) {
+	err := s.update(controller, func(c *simController) error {
+		if _, err := c.door(door); err != nil {
+			return err
+		}
+
+		c.unlock(door)
+		c.record(types.Event{Type: eventDoor, Granted: true, Door: door, Direction: 1, Reason: reasonRemoteOpen})
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Result{SerialNumber: types.SerialNumber(controller), Succeeded: true}, nil
+}
+
+func (s *Simulator) SetPCControl(controller uint32, enable bool) (bool, error) {
+	err := s.update(controller, func(c *simController) error {
+		c.PCControl = enable
+		return nil
+	})
+
+	return err == nil, err
+}
+
+func (s *Simulator) SetInterlock(controller uint32, interlock types.Interlock) (bool, error) {
+	if interlock.String() == "" {
+		return false, fmt.Errorf("invalid interlock (%v)", uint8(interlock))
+	}
+
+	err := s.update(controller, func(c *simController) error {
+		c.Interlock = interlock
+		return nil
+	})
+
+	return err == nil, err
+}
+
+func (s *Simulator) ActivateKeypads(controller uint32, keypads map[uint8]bool) (bool, error) {
+	err := s.update(controller, func(c *simController) error {
+		for _, reader := range []uint8{1, 2, 3, 4} {
+			c.Keypads[reader] = keypads[reader]
+		}
+
+		return nil
+	})
+
+	return err == nil, err
+}
+
+func (s *Simulator) GetAntiPassback(controller uint32) (types.AntiPassback, error) {
+	var antipassback types.AntiPassback
+
+	err := s.view(controller, func(c *simController) error {
+		antipassback = c.AntiPassback
+		return nil
+	})
+
+	return antipassback, err
+}
+
+// Sets the anti-passback mode, clearing the recorded card passages.
+func (s *Simulator) SetAntiPassback(controller uint32, antipassback types.AntiPassback) (bool, error) {
+	if antipassback.String() == "" {
+		return false, fmt.Errorf("invalid anti-passback (%v)", uint8(antipassback))
+	}
+
+	err := s.update(controller, func(c *simController) error {
+		c.AntiPassback = antipassback
+		c.Passages = map[uint32]map[uint8]uint8{}
+		return nil
+	})
+
+	return err == nil, err
+}
+
+func (s *Simulator) SetFirstCard(controller uint32, door uint8, firstcard types.FirstCard) (bool, error) {
+	err := s.update(controller, func(c *simController) error {
+		if d, err := c.door(door); err != nil {
+			return err
+		} else {
+			d.FirstCard = &firstcard
+			return nil
+		}
+	})
+
+	return err == nil, err
+}
+
+// Resets the controller configuration (door control, passcodes, first card, interlock,
+// anti-passback, keypads, listener and event options) to the manufacturer defaults. The
+// cards, time profiles, tasks, events and IP address are retained.
+func (s *Simulator) RestoreDefaultParameters(controller uint32) (bool, error) {
+	err := s.update(controller, func(c *simController) error {
+		for _, door := range []uint8{1, 2, 3, 4} {
+			c.Doors[door] = &simDoor{Mode: types.Controlled, Delay: simDefaultDelay, Open: c.Doors[door].Open}
+		}
+
+		c.Listener = netip.AddrPort{}
+		c.Interval = 0
+		c.SpecialEvents = false
+		c.PCControl = false
+		c.Interlock = types.NoInterlock
+		c.AntiPassback = types.Disabled
+		c.Passages = map[uint32]map[uint8]uint8{}
+		c.Keypads = map[uint8]bool{1: false, 2: false, 3: false, 4: false}
+
+		return nil
+	})
+
+	return err == nil, err
+}
+
+func (s *Simulator) DeviceList() map[uint32]uhppote.Device {
+	return maps.Clone(s.devices)
+}
+
+func (s *Simulator) ListenAddrList() []netip.AddrPort {
+	return []netip.AddrPort{}
+}
+
+// Simulates a card swipe at a door reader. The swipe is evaluated in the same way as
+// 'explain' (card, door permission, time profile, door control mode and interlock) and
+// then checked against the anti-passback passages. A granted swipe unlocks the door.
+func (s *Simulator) swipe(controller uint32, door uint8, cardNumber uint32, direction uint8) (types.Event, error) {
+	var event types.Event
+
+	err := s.update(controller, func(c *simController) error {
+		d, err := c.door(door)
+		if err != nil {
+			return err
+		}
+
+		a := access{
+			door:         door,
+			at:           c.now(),
+			profiles:     c.Profiles,
+			mode:         d.Mode,
+			source:       "current",
+			firstcard:    d.FirstCard,
+			antipassback: &c.AntiPassback,
+			interlock:    &c.Interlock,
+			open:         c.open(),
+		}
+
+		if ix := c.card(cardNumber); ix >= 0 {
+			a.card = copyCard(c.Cards[ix])
+		}
+
+		granted := true
+		reason := uint8(reasonSwipe)
+		zone, side, restricted := antipassbackSide(c.AntiPassback, door)
+
+		if step, ok := verdict(explain(a, profileNames{})); !ok {
+			granted = false
+			reason = step.reason
+		} else if restricted && c.Passages[cardNumber][zone] == side {
+			granted = false
+			reason = reasonAntiPassback
+		}
+
+		if granted {
+			c.unlock(door)
+
+			if restricted {
+				if c.Passages[cardNumber] == nil {
+					c.Passages[cardNumber] = map[uint8]uint8{}
+				}
+
+				c.Passages[cardNumber][zone] = side
+			}
+		}
+
+		event = c.record(types.Event{
+			Type:       eventSwipe,
+			Granted:    granted,
+			Door:       door,
+			Direction:  direction,
+			CardNumber: cardNumber,
+			Reason:     reason,
+		})
+
+		return nil
+	})
+
+	return event, err
+}
+
+// Simulates a door being opened or closed. Opening a locked door records a 'forced open'
+// alarm event. The door open and closed events are only recorded if 'record special events'
+// is enabled. Returns nil if no event was recorded.
+func (s *Simulator) setDoor(controller uint32, door uint8, open bool) (*types.Event, error) {
+	var event *types.Event
+
+	err := s.update(controller, func(c *simController) error {
+		d, err := c.door(door)
+		if err != nil {
+			return err
+		} else if d.Open == open {
+			return nil
+		}
+
+		d.Open = open
+
+		var e types.Event
+		switch {
+		case open && !c.unlocked(door):
+			e = c.record(types.Event{Type: eventAlarm, Door: door, Direction: 1, Reason: reasonForcedOpen})
+		case open && c.SpecialEvents:
+			e = c.record(types.Event{Type: eventDoor, Granted: true, Door: door, Direction: 1, Reason: reasonDoorOpened})
+		case !open && c.SpecialEvents:
+			e = c.record(types.Event{Type: eventDoor, Granted: true, Door: door, Direction: 1, Reason: reasonDoorClosed})
+		default:
+			return nil
+		}
+
+		event = &e
+
+		return nil
+	})
+
+	return event, err
+}
+
+// Simulates pressing the door pushbutton, which unlocks the door unless the door is
+// 'normally closed' or an interlocked door is open. The pushbutton event is only recorded
+// if 'record special events' is enabled.
+func (s *Simulator) pushButton(controller uint32, door uint8) (bool, *types.Event, error) {
+	var granted bool
+	var event *types.Event
+
+	err := s.update(controller, func(c *simController) error {
+		d, err := c.door(door)
+		if err != nil {
+			return err
+		}
+
+		reason := uint8(reasonPushButton)
+		open := c.open()
+
+		switch {
+		case d.Mode == types.NormallyClosed:
+			reason = reasonPushButtonLocked
+
+		case slices.ContainsFunc(interlocked(c.Interlock, door), func(d uint8) bool { return open[d] }):
+			reason = reasonPushButtonInterlock
+
+		default:
+			granted = true
+			c.unlock(door)
+		}
+
+		if c.SpecialEvents {
+			e := c.record(types.Event{Type: eventDoor, Granted: granted, Door: door, Direction: 1, Reason: reason})
+			event = &e
+		}
+
+		return nil
+	})
+
+	return granted, event, err
+}
+
+// Returns the controller time.
+func (c *simController) now() time.Time {
+	return time.Now().Add(c.Offset).Truncate(time.Second)
+}
+
+func (c *simController) door(door uint8) (*simDoor, error) {
+	if d, ok := c.Doors[door]; ok && door >= 1 && door <= 4 {
+		return d, nil
+	}
+
+	return nil, fmt.Errorf("invalid door (%v)", door)
+}
+
+// Returns the index of a card in the card list or -1 if the card is not in the list.
+func (c *simController) card(cardNumber uint32) int {
+	return slices.IndexFunc(c.Cards, func(card types.Card) bool {
+		return card.CardNumber == cardNumber
+	})
+}
+
+// Returns the door sensor states.
+func (c *simController) open() map[uint8]bool {
+	open := map[uint8]bool{}
+	for door, d := range c.Doors {
+		open[door] = d.Open
+	}
+
+	return open
+}
+
+// Returns true if the door lock relay is energised.
+func (c *simController) unlocked(door uint8) bool {
+	switch d := c.Doors[door]; d.Mode {
+	case types.NormallyOpen:
+		return true
+	case types.NormallyClosed:
+		return false
+	default:
+		return c.now().Before(d.Unlocked)
+	}
+}
+
+// Unlocks a door for the door delay.
+func (c *simController) unlock(door uint8) {
+	d := c.Doors[door]
+	d.Unlocked = c.now().Add(time.Duration(d.Delay) * time.Second)
+}
+
+// Appends an event to the event buffer, discarding the oldest events once the buffer is full.
+func (c *simController) record(e types.Event) types.Event {
+	e.SerialNumber = types.SerialNumber(c.id)
+	e.Index = 1
+	e.Timestamp = types.DateTime(c.now())
+
+	if N := len(c.Events); N > 0 {
+		e.Index = c.Events[N-1].Index + 1
+	}
+
+	c.Events = append(c.Events, e)
+	if N := len(c.Events); N > c.EventBuffer {
+		c.Events = slices.Delete(c.Events, 0, N-c.EventBuffer)
+	}
+
+	return e
+}
+
+// Returns the events with an index after the index, without scanning the event buffer (the
+// events are recorded in index order).
+func (c *simController) eventsAfter(index uint32) []types.Event {
+	ix := sort.Search(len(c.Events), func(i int) bool {
+		return c.Events[i].Index > index
+	})
+
+	return c.Events[ix:]
+}
+
+func (c *simController) status() types.Status {
+	status := types.Status{
+		SerialNumber:   types.SerialNumber(c.id),
+		DoorState:      map[uint8]bool{},
+		DoorButton:     map[uint8]bool{1: false, 2: false, 3: false, 4: false},
+		SystemDateTime: types.DateTime(c.now()),
+	}
+
+	for _, door := range []uint8{1, 2, 3, 4} {
+		status.DoorState[door] = c.Doors[door].Open
+		if c.unlocked(door) {
+			status.RelayState |= 1 << (door - 1)
+		}
+	}
+
+	if N := len(c.Events); N > 0 {
+		e := c.Events[N-1]
+		status.Event = types.StatusEvent{
+			Index:      e.Index,
+			Type:       e.Type,
+			Granted:    e.Granted,
+			Door:       e.Door,
+			Direction:  e.Direction,
+			CardNumber: e.CardNumber,
+			Timestamp:  e.Timestamp,
+			Reason:     e.Reason,
+		}
+	}
+
+	return status
+}
+
+// Returns the anti-passback zone and side for a door reader. A card may not pass the same
+// side of a zone twice in succession. Returns false if the reader is not restricted.
+func antipassbackSide(antipassback types.AntiPassback, door uint8) (uint8, uint8, bool) {
+	sides := map[types.AntiPassback]map[uint8][2]uint8{
+		types.Readers12_34: {1: {1, 1}, 2: {1, 2}, 3: {2, 1}, 4: {2, 2}},
+		types.Readers13_24: {1: {1, 1}, 3: {1, 1}, 2: {1, 2}, 4: {1, 2}},
+		types.Readers1_23:  {1: {1, 1}, 2: {1, 2}, 3: {1, 2}},
+		types.Readers1_234: {1: {1, 1}, 2: {1, 2}, 3: {1, 2}, 4: {1, 2}},
+	}
+
+	if v, ok := sides[antipassback][door]; ok {
+		return v[0], v[1], true
+	}
+
+	return 0, 0, false
+}
+
+func copyCard(card types.Card) *types.Card {
+	c := card
+	c.Doors = map[uint8]uint8{}
+
+	for _, door := range []uint8{1, 2, 3, 4} {
+		c.Doors[door] = card.Doors[door]
+	}
+
+	return &c
+}
@@ -0,0 +1,409 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppote-core/uhppote"
+)
+
+func simulation(t *testing.T) (Context, *Simulator) {
+	devices := []uhppote.Device{
+		uhppote.NewDevice("Alpha", 405419896, types.MustParseControllerAddr("192.168.1.100:60000"), "udp", []string{"Great Hall", "Kitchen", "", ""}, time.Local),
+		uhppote.NewDevice("Beta", 303986753, types.MustParseControllerAddr("192.168.1.101:60000"), "udp", []string{"Gate", "", "", ""}, time.Local),
+	}
+
+	s, err := NewSimulator(filepath.Join(t.TempDir(), "simulation.json"), devices)
+	if err != nil {
+		t.Fatalf("error creating simulator (%v)", err)
+	}
+
+	ctx := shellContext(t)
+	ctx.uhppote = s
+
+	return ctx, s
+}
+
+// Executes a command with the command line arguments.
+func simulate(t *testing.T, ctx Context, cmd Command, args ...string) {
+	if err := flag.CommandLine.Parse(append([]string{cmd.CLI()}, args...)); err != nil {
+		t.Fatalf("%v: %v", cmd.CLI(), err)
+	} else if err := cmd.Execute(ctx); err != nil {
+		t.Fatalf("%v %v: unexpected error (%v)", cmd.CLI(), args, err)
+	}
+}
+
+func putCard(t *testing.T, s *Simulator, controller, card uint32, doors map[uint8]uint8) {
+	record := types.Card{
+		CardNumber: card,
+		From:       types.MustParseDate("2026-01-01"),
+		To:         types.MustParseDate("2026-12-31"),
+		Doors:      doors,
+	}
+
+	if _, err := s.PutCard(controller, record); err != nil {
+		t.Fatalf("error storing card %v (%v)", card, err)
+	}
+}
+
+func TestSimulatorCards(t *testing.T) {
+	ctx, s := simulation(t)
+
+	simulate(t, ctx, &PutCardCmd, "405419896", "10058400", "2026-01-01", "2026-12-31", "1,2")
+	simulate(t, ctx, &PutCardCmd, "405419896", "10058401", "2026-01-01", "2026-12-31", "1")
+	simulate(t, ctx, &DeleteCardCmd, "405419896", "10058400")
+
+	if N, err := s.GetCards(405419896); err != nil || N != 1 {
+		t.Errorf("incorrect number of cards - expected:%v, got:%v (%v)", 1, N, err)
+	}
+
+	if card, err := s.GetCardByID(405419896, 10058401); err != nil || card == nil {
+		t.Fatalf("card 10058401 not stored (%v)", err)
+	} else if expected := map[uint8]uint8{1: 1, 2: 0, 3: 0, 4: 0}; !reflect.DeepEqual(card.Doors, expected) {
+		t.Errorf("incorrect door permissions - expected:%v, got:%v", expected, card.Doors)
+	}
+
+	if card, err := s.GetCardByID(405419896, 10058400); err != nil || card != nil {
+		t.Errorf("card 10058400 not deleted (%v %v)", card, err)
+	}
+
+	simulate(t, ctx, &DeleteCardsCmd, "405419896")
+
+	if N, _ := s.GetCards(405419896); N != 0 {
+		t.Errorf("cards not deleted - expected:%v, got:%v", 0, N)
+	}
+
+	if _, err := s.PutCard(405419896, types.Card{CardNumber: 0xffffffff}); err == nil {
+		t.Errorf("expected error storing invalid card number")
+	}
+}
+
+func TestSimulatorSetAddress(t *testing.T) {
+	ctx, s := simulation(t)
+
+	simulate(t, ctx, &SetAddressCmd, "405419896", "192.168.1.125", "255.255.0.0", "192.168.1.1")
+
+	device, err := s.GetDevice(405419896)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if device.IpAddress.String() != "192.168.1.125" || device.SubnetMask.String() != "255.255.0.0" || device.Gateway.String() != "192.168.1.1" {
+		t.Errorf("incorrect address - got:%v %v %v", device.IpAddress, device.SubnetMask, device.Gateway)
+	}
+
+	if _, err := s.GetDevice(201020304); err == nil {
+		t.Errorf("expected error for unknown controller")
+	}
+}
+
+func TestSimulatorRestoreDefaultParameters(t *testing.T) {
+	ctx, s := simulation(t)
+
+	putCard(t, s, 405419896, 10058400, map[uint8]uint8{1: 1})
+	simulate(t, ctx, &SetDoorControlCmd, "405419896", "2", "normally open")
+	simulate(t, ctx, &SetInterlockCmd, "405419896", "1&2")
+	simulate(t, ctx, &SetAntiPassbackCmd, "405419896", "1:(2,3)")
+	simulate(t, ctx, &RecordSpecialEventsCmd, "405419896", "true")
+	simulate(t, ctx, &RestoreDefaultParametersCmd, "405419896")
+
+	if state, _ := s.GetDoorControlState(405419896, 2); state.ControlState != types.Controlled || state.Delay != 3 {
+		t.Errorf("door control not restored - got:%v %v", state.ControlState, state.Delay)
+	}
+
+	if antipassback, _ := s.GetAntiPassback(405419896); antipassback != types.Disabled {
+		t.Errorf("anti-passback not restored - got:%v", antipassback)
+	}
+
+	s.view(405419896, func(c *simController) error {
+		if c.Interlock != types.NoInterlock || c.SpecialEvents {
+			t.Errorf("interlock and special events not restored - got:%v %v", c.Interlock, c.SpecialEvents)
+		}
+
+		return nil
+	})
+
+	if N, _ := s.GetCards(405419896); N != 1 {
+		t.Errorf("cards not retained - expected:%v, got:%v", 1, N)
+	}
+}
+
+func TestSimulatorEventBuffer(t *testing.T) {
+	_, s := simulation(t)
+
+	s.update(405419896, func(c *simController) error {
+		c.EventBuffer = 5
+		return nil
+	})
+
+	for range 8 {
+		if _, err := s.OpenDoor(405419896, 1); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+
+	if event, err := s.GetEvent(405419896, 0); err != nil || event == nil || event.Index != 4 {
+		t.Errorf("incorrect first event - expected:%v, got:%v (%v)", 4, event, err)
+	}
+
+	if event, err := s.GetEvent(405419896, 0xffffffff); err != nil || event == nil || event.Index != 8 {
+		t.Errorf("incorrect last event - expected:%v, got:%v (%v)", 8, event, err)
+	}
+
+	if _, err := s.GetEvent(405419896, 3); err == nil {
+		t.Errorf("expected 'overwritten' error for event 3")
+	}
+
+	if event, err := s.GetEvent(405419896, 9); err != nil || event != nil {
+		t.Errorf("expected no event at index 9 - got:%v (%v)", event, err)
+	}
+
+	s.view(405419896, func(c *simController) error {
+		for _, test := range []struct{ after, first, N uint32 }{{0, 4, 5}, {3, 4, 5}, {6, 7, 2}, {8, 0, 0}} {
+			if events := c.eventsAfter(test.after); uint32(len(events)) != test.N || (test.N > 0 && events[0].Index != test.first) {
+				t.Errorf("incorrect events after %v - expected:%v events from %v, got:%v", test.after, test.N, test.first, events)
+			}
+		}
+
+		return nil
+	})
+}
+
+func TestSimulatorConcurrentUpdates(t *testing.T) {
+	_, s := simulation(t)
+
+	other, err := NewSimulator(s.file, nil)
+	if err != nil {
+		t.Fatalf("error loading simulator (%v)", err)
+	}
+
+	var wg sync.WaitGroup
+	for i, sim := range []*Simulator{s, other} {
+		wg.Go(func() {
+			for card := range uint32(25) {
+				putCard(t, sim, 405419896, 10058400+100*uint32(i)+card, map[uint8]uint8{1: 1})
+			}
+		})
+	}
+
+	wg.Wait()
+
+	if N, err := s.GetCards(405419896); err != nil || N != 50 {
+		t.Errorf("concurrent updates lost - expected:%v cards, got:%v (%v)", 50, N, err)
+	}
+
+	if _, err := os.Stat(s.file + ".lock"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("lock file not removed (%v)", err)
+	}
+
+	// ... stale lock left by a killed process
+	if err := os.WriteFile(s.file+".lock", []byte{}, 0660); err != nil {
+		t.Fatalf("%v", err)
+	} else if err := os.Chtimes(s.file+".lock", time.Now(), time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if _, err := s.OpenDoor(405419896, 1); err != nil {
+		t.Errorf("stale lock not removed (%v)", err)
+	}
+}
+
+func TestSimulatorAntiPassback(t *testing.T) {
+	ctx, s := simulation(t)
+
+	putCard(t, s, 405419896, 10058400, map[uint8]uint8{1: 1, 2: 1, 3: 1, 4: 1})
+	simulate(t, ctx, &SetAntiPassbackCmd, "405419896", "(1:2);(3:4)")
+
+	tests := []struct {
+		door    uint8
+		granted bool
+		reason  uint8
+	}{
+		{1, true, reasonSwipe},
+		{1, false, reasonAntiPassback},
+		{3, true, reasonSwipe},
+		{2, true, reasonSwipe},
+		{2, false, reasonAntiPassback},
+		{1, true, reasonSwipe},
+	}
+
+	for _, test := range tests {
+		event, err := s.swipe(405419896, test.door, 10058400, 1)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		if event.Granted != test.granted || event.Reason != test.reason {
+			t.Errorf("door %v: incorrect swipe - expected:%v %v, got:%v %v", test.door, test.granted, test.reason, event.Granted, event.Reason)
+		}
+	}
+}
+
+func TestSimulatorInterlock(t *testing.T) {
+	ctx, s := simulation(t)
+
+	putCard(t, s, 405419896, 10058400, map[uint8]uint8{1: 1, 2: 1})
+	simulate(t, ctx, &SetInterlockCmd, "405419896", "1&2")
+
+	if event, err := s.setDoor(405419896, 2, true); err != nil || event == nil || event.Reason != reasonForcedOpen {
+		t.Errorf("expected 'forced open' alarm - got:%v (%v)", event, err)
+	}
+
+	if event, _ := s.swipe(405419896, 1, 10058400, 1); event.Granted || event.Reason != reasonInterlock {
+		t.Errorf("incorrect swipe - expected:%v %v, got:%v %v", false, reasonInterlock, event.Granted, event.Reason)
+	}
+
+	if granted, _, _ := s.pushButton(405419896, 1); granted {
+		t.Errorf("pushbutton unlocked interlocked door")
+	}
+
+	s.setDoor(405419896, 2, false)
+
+	if event, _ := s.swipe(405419896, 1, 10058400, 1); !event.Granted {
+		t.Errorf("swipe denied after interlocked door closed (reason %v)", event.Reason)
+	}
+
+	if status, _ := s.GetStatus(405419896); status.RelayState&0x01 == 0 {
+		t.Errorf("door 1 not unlocked - relays:%02x", status.RelayState)
+	}
+}
+
+func TestSimulatorPersistence(t *testing.T) {
+	ctx, s := simulation(t)
+
+	putCard(t, s, 405419896, 10058400, map[uint8]uint8{1: 1})
+	simulate(t, ctx, &SetTimeProfileCmd, "405419896", "29", "2026-01-01:2026-12-31", "Mon,Tue", "08:30-17:00")
+	simulate(t, ctx, &SetDoorDelayCmd, "405419896", "1", "7")
+	s.swipe(405419896, 1, 10058400, 1)
+
+	restored, err := NewSimulator(s.file, nil)
+	if err != nil {
+		t.Fatalf("error reloading simulator (%v)", err)
+	}
+
+	if card, _ := restored.GetCardByID(405419896, 10058400); card == nil || card.From != types.MustParseDate("2026-01-01") {
+		t.Errorf("card not restored - got:%v", card)
+	}
+
+	if profile, _ := restored.GetTimeProfile(405419896, 29); profile == nil || !profile.Weekdays[time.Monday] || profile.Weekdays[time.Wednesday] {
+		t.Errorf("time profile not restored - got:%v", profile)
+	}
+
+	if state, _ := restored.GetDoorControlState(405419896, 1); state.Delay != 7 {
+		t.Errorf("door delay not restored - expected:%v, got:%v", 7, state.Delay)
+	}
+
+	if event, _ := restored.GetEvent(405419896, 0xffffffff); event == nil || event.CardNumber != 10058400 || !event.Granted {
+		t.Errorf("event not restored - got:%v", event)
+	}
+
+	// ... changes made by another process
+	restored.DeleteCards(405419896)
+
+	if N, _ := s.GetCards(405419896); N != 0 {
+		t.Errorf("simulator not refreshed from state file - expected:%v cards, got:%v", 0, N)
+	}
+}
+
+type simListener struct {
+	connected chan struct{}
+	events    chan types.Status
+}
+
+func (l *simListener) OnConnected() {
+	close(l.connected)
+}
+
+func (l *simListener) OnEvent(status *types.Status) {
+	l.events <- *status
+}
+
+func (l *simListener) OnError(err error) bool {
+	return true
+}
+
+func TestSimulatorListen(t *testing.T) {
+	_, s := simulation(t)
+
+	putCard(t, s, 405419896, 10058400, map[uint8]uint8{1: 1})
+	s.swipe(405419896, 1, 10058400, 1)
+
+	listener := simListener{
+		connected: make(chan struct{}),
+		events:    make(chan types.Status, 8),
+	}
+
+	q := make(chan os.Signal)
+	done := make(chan error)
+
+	go func() {
+		done <- s.Listen(&listener, q)
+	}()
+
+	<-listener.connected
+
+	s.swipe(405419896, 1, 10058400, 2)
+	s.swipe(303986753, 1, 10058400, 1)
+
+	expected := map[uint32]types.StatusEvent{
+		405419896: {Index: 2, Granted: true, Door: 1, Direction: 2, CardNumber: 10058400},
+		303986753: {Index: 1, Granted: false, Door: 1, Direction: 1, CardNumber: 10058400},
+	}
+
+	events := map[uint32]types.StatusEvent{}
+	for len(events) < len(expected) {
+		select {
+		case status := <-listener.events:
+			e := status.Event
+			events[uint32(status.SerialNumber)] = types.StatusEvent{Index: e.Index, Granted: e.Granted, Door: e.Door, Direction: e.Direction, CardNumber: e.CardNumber}
+
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timeout waiting for events (received %v)", events)
+		}
+	}
+
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("incorrect events\n   expected:%+v\n   got:     %+v", expected, events)
+	}
+
+	close(q)
+
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error (%v)", err)
+	}
+}
+
+func TestSimulatorStateStore(t *testing.T) {
+	real := t.TempDir()
+	file := filepath.Join(t.TempDir(), "simulation.json")
+	settings := Settings{
+		values: map[string]string{"state": real},
+	}
+
+	ctx := shellContext(t).WithSettings(settings.Simulated(file))
+
+	if err := saveState(ctx, "interlocks.json", map[uint32]uint8{405419896: 4}); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	if entries, err := os.ReadDir(real); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if len(entries) != 0 {
+		t.Errorf("simulated command updated the real state store (%v)", entries)
+	}
+
+	if _, err := os.Stat(filepath.Join(file+".state", "interlocks.json")); err != nil {
+		t.Errorf("state not saved to the simulation state store (%v)", err)
+	}
+
+	if dir, _ := stateDir(Context{settings: settings}); dir != real {
+		t.Errorf("real state store changed - expected:%v, got:%v", real, dir)
+	}
+}
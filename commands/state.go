@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// The local state store holds the information that cannot be retrieved from the controllers
+// (e.g. the task lists) as JSON files. The store is located in the directory defined by the
+// 'cli.state' setting, defaulting to 'uhppote-cli' in the user configuration directory.
+func stateDir(ctx Context) (string, error) {
+	if dir := ctx.settings.path("state"); dir != "" {
+		return dir, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "uhppote-cli"), nil
+}
+
+// Loads a JSON state file from the state store. Returns false if the state file does not exist.
+func loadState(ctx Context, name string, v any) (bool, error) {
+	dir, err := stateDir(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return false, fmt.Errorf("%v: %v", name, err)
+	}
+
+	return true, nil
+}
+
+// Writes a JSON state file to the state store.
+func saveState(ctx Context, name string, v any) error {
+	dir, err := stateDir(ctx)
+	if err != nil {
+		return err
+	}
+
+	return writeJSONFile(filepath.Join(dir, name), v)
+}
+
+// Writes a JSON file. The file is written to a temporary file and then renamed so that an
+// interrupted write never leaves a partial file.
+func writeJSONFile(file string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0770); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
+
+// Removes a state file from the state store. Removing a state file that does not exist is not
+// an error.
+func deleteState(ctx Context, name string) error {
+	dir, err := stateDir(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(filepath.Join(dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// Returns the names of the JSON state files in a state store folder.
+func listState(ctx Context, folder string) ([]string, error) {
+	dir, err := stateDir(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, folder))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && filepath.Ext(entry.Name()) == ".json" {
+			names = append(names, filepath.Join(folder, entry.Name()))
+		}
+	}
+
+	return names, nil
+}
@@ -0,0 +1,312 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+var SyncTimeProfilesCmd = SyncTimeProfiles{
+	file:   "",
+	delete: false,
+	dryrun: false,
+}
+
+type SyncTimeProfiles struct {
+	file   string
+	delete bool
+	dryrun bool
+}
+
+// timeProfilesDiff is the set of changes required to synchronise the time profiles on a
+// controller. The added and updated profiles are ordered so that linked profiles are always
+// set before the profiles that link to them.
+type timeProfilesDiff struct {
+	unchanged []uint8
+	updated   []uint8
+	added     []uint8
+	deleted   []uint8
+	ordered   []types.TimeProfile
+}
+
+func (c *SyncTimeProfiles) Execute(ctx Context) error {
+	if ctx.config == nil {
+		return errors.New("sync-time-profiles requires a valid configuration file")
+	}
+
+	if err := c.parseArgs(); err != nil {
+		return err
+	}
+
+	if c.file == "" {
+		return fmt.Errorf("missing TSV file with time profiles")
+	}
+
+	profiles, err := (&SetTimeProfiles{}).parse(c.file)
+	if err != nil {
+		return err
+	} else if len(profiles) == 0 {
+		return fmt.Errorf("file '%s' does not contain any valid time profiles", c.file)
+	}
+
+	for _, p := range profiles {
+		if p.ID < 2 || p.ID > 254 {
+			return fmt.Errorf("profile %v: invalid time profile ID (valid range is from 2 to 254)", p.ID)
+		} else if err := (&SetTimeProfiles{}).validate(p); err != nil {
+			return fmt.Errorf("profile %v: %v", p.ID, err)
+		}
+	}
+
+	errs := []error{}
+	for _, device := range ctx.devices {
+		if err := c.sync(ctx, device.DeviceID, profiles); err != nil {
+			fmt.Printf("   ... %v  ERROR  %v\n", device.DeviceID, err)
+			errs = append(errs, fmt.Errorf("%v: %v", device.DeviceID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *SyncTimeProfiles) sync(ctx Context, deviceID uint32, profiles []types.TimeProfile) error {
+	current, err := getTimeProfiles(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+
+	diff, err := c.diff(current, profiles)
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	if !c.dryrun {
+		// ... no way to delete a single time profile, so clear all and reload the list
+		if len(diff.deleted) > 0 {
+			if ok, err := ctx.uhppote.ClearTimeProfiles(deviceID); err != nil {
+				return err
+			} else if !ok {
+				return fmt.Errorf("failed to clear time profiles")
+			}
+		}
+
+		errs = c.load(ctx, deviceID, diff.ordered)
+	}
+
+	report := func(action string, ids []uint8) {
+		for _, id := range ids {
+			fmt.Printf("   ... %v  %-7v time profile %v\n", deviceID, action, id)
+		}
+	}
+
+	report("added", diff.added)
+	report("updated", diff.updated)
+	report("deleted", diff.deleted)
+
+	for _, err := range errs {
+		fmt.Printf("   ... %v  ERROR   %v\n", deviceID, err)
+	}
+
+	dryrun := ""
+	if c.dryrun {
+		dryrun = " (dry run)"
+	}
+
+	fmt.Printf("   ... %v  unchanged:%v  updated:%v  added:%v  deleted:%v%v\n",
+		deviceID,
+		len(diff.unchanged),
+		len(diff.updated),
+		len(diff.added),
+		len(diff.deleted),
+		dryrun)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%v of %v time profiles not set", len(errs), len(diff.ordered))
+	}
+
+	return nil
+}
+
+// Sets the time profiles (in linked profile order) on a controller. A failure does not stop the
+// load because the controller time profiles may have been cleared, so the remaining profiles are
+// still set - except for the profiles that link to a profile that was not set, which would
+// otherwise leave a link to an undefined time profile. Returns an error for each profile that
+// was not set.
+func (c *SyncTimeProfiles) load(ctx Context, deviceID uint32, profiles []types.TimeProfile) []error {
+	errs := []error{}
+	failed := map[uint8]bool{}
+
+	for _, profile := range profiles {
+		if linked := profile.LinkedProfileID; linked != 0 && failed[linked] {
+			errs = append(errs, fmt.Errorf("time profile %v not set (linked time profile %v not set)", profile.ID, linked))
+			failed[profile.ID] = true
+		} else if ok, err := ctx.uhppote.SetTimeProfile(deviceID, profile); err != nil {
+			errs = append(errs, fmt.Errorf("time profile %v not set (%v)", profile.ID, err))
+			failed[profile.ID] = true
+		} else if !ok {
+			errs = append(errs, fmt.Errorf("could not set time profile %v", profile.ID))
+			failed[profile.ID] = true
+		}
+	}
+
+	return errs
+}
+
+// Compares the desired time profiles with the time profiles on a controller and returns the
+// changes required to synchronise the controller. Unlisted profiles are retained unless --delete
+// is specified, in which case the time profiles are cleared and all the desired profiles are
+// reloaded (the controller does not support deleting an individual time profile).
+//
+// Returns an error if the synchronised time profiles would include a link to an undefined
+// profile or a circular reference.
+func (c *SyncTimeProfiles) diff(current map[uint8]types.TimeProfile, profiles []types.TimeProfile) (timeProfilesDiff, error) {
+	diff := timeProfilesDiff{
+		unchanged: []uint8{},
+		updated:   []uint8{},
+		added:     []uint8{},
+		deleted:   []uint8{},
+		ordered:   []types.TimeProfile{},
+	}
+
+	desired := map[uint8]types.TimeProfile{}
+	for _, p := range profiles {
+		if q, ok := desired[p.ID]; ok && !sameTimeProfile(p, q) {
+			return diff, fmt.Errorf("profile %v has more than one definition", p.ID)
+		}
+
+		desired[p.ID] = p
+	}
+
+	// ... final state must be consistent
+	final := maps.Clone(desired)
+	if !c.delete {
+		for id, p := range current {
+			if _, ok := final[id]; !ok {
+				final[id] = p
+			}
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(final)) {
+		if _, err := linkedChain(id, final); err != nil {
+			return diff, fmt.Errorf("profile %v: %v", id, err)
+		}
+	}
+
+	ordered, err := orderTimeProfiles(slices.Collect(maps.Values(desired)))
+	if err != nil {
+		return diff, err
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(desired)) {
+		if p, ok := current[id]; !ok {
+			diff.added = append(diff.added, id)
+		} else if !sameTimeProfile(p, desired[id]) {
+			diff.updated = append(diff.updated, id)
+		} else {
+			diff.unchanged = append(diff.unchanged, id)
+		}
+	}
+
+	if c.delete {
+		for _, id := range slices.Sorted(maps.Keys(current)) {
+			if _, ok := desired[id]; !ok {
+				diff.deleted = append(diff.deleted, id)
+			}
+		}
+	}
+
+	for _, p := range ordered {
+		if len(diff.deleted) > 0 || !slices.Contains(diff.unchanged, p.ID) {
+			diff.ordered = append(diff.ordered, p)
+		}
+	}
+
+	return diff, nil
+}
+
+func (c *SyncTimeProfiles) parseArgs() error {
+	flagset := newFlagSet()
+	remove := flagset.Bool("delete", false, "Deletes time profiles that are not in the TSV file")
+	dryrun := flagset.Bool("dry-run", false, "Reports the changes without updating the controllers")
+	file := ""
+	args := flag.Args()[1:]
+
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+
+	if len(flagset.Args()) > 0 {
+		file = flagset.Arg(0)
+		if stat, err := os.Stat(file); err != nil && os.IsNotExist(err) {
+			return fmt.Errorf("file '%s' does not exist", file)
+		} else if err != nil {
+			return err
+		} else if stat.Mode().IsDir() {
+			return fmt.Errorf("file '%s' is a directory", file)
+		} else if !stat.Mode().IsRegular() {
+			return fmt.Errorf("file '%s' is not a real file", file)
+		}
+	}
+
+	c.file = file
+	c.delete = *remove
+	c.dryrun = *dryrun
+
+	return nil
+}
+
+func (c *SyncTimeProfiles) CLI() string {
+	return "sync-time-profiles"
+}
+
+func (c *SyncTimeProfiles) Description() string {
+	return "Synchronises the time profiles on all configured controllers with a TSV file"
+}
+
+func (c *SyncTimeProfiles) Usage() string {
+	return "[--delete] [--dry-run] <file>"
+}
+
+func (c *SyncTimeProfiles) Help() {
+	fmt.Println("Usage: uhppote-cli [options] sync-time-profiles [--delete] [--dry-run] <file>")
+	fmt.Println()
+	fmt.Println(" Compares the time profiles defined in a TSV file (in the same format as for set-time-profiles) with the time")
+	fmt.Println(" profiles on each of the controllers in the configuration file and sets only the time profiles that have")
+	fmt.Println(" changed. Time profiles are set in linked profile order i.e. a linked profile is always set before the")
+	fmt.Println(" profiles that link to it. A controller is not updated if the result would include a link to an undefined")
+	fmt.Println(" time profile or a circular reference.")
+	fmt.Println()
+	fmt.Println("  file       (required) TSV file with time profiles")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --config  File path for the 'conf' file containing the controller configuration")
+	fmt.Printf("              (defaults to %s)\n", config.DefaultConfig)
+	fmt.Println("    --debug   Displays internal information for diagnosing errors")
+	fmt.Println()
+	fmt.Println("    --delete  Deletes time profiles that are not defined in the TSV file. The controller does not support")
+	fmt.Println("              deleting an individual time profile so the time profiles are cleared and reloaded from the")
+	fmt.Println("              TSV file if any time profile needs to be deleted. Cards lose access to the doors for")
+	fmt.Println("              which they have a time profile permission while the time profiles are being reloaded.")
+	fmt.Println("              A time profile that cannot be set is reported and the remaining time profiles are")
+	fmt.Println("              still loaded (other than profiles that link to it)")
+	fmt.Println("    --dry-run Reports the changes without updating the controllers")
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println()
+	fmt.Println("    uhppote-cli sync-time-profiles --dry-run time-profiles.tsv")
+	fmt.Println("    uhppote-cli sync-time-profiles --delete time-profiles.tsv")
+	fmt.Println()
+}
+
+// Returns true - configuration is not optional for this command to return valid information.
+func (c *SyncTimeProfiles) RequiresConfig() bool {
+	return true
+}
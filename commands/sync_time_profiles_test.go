@@ -0,0 +1,155 @@
+package commands
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+func testProfile(id, linked uint8, start, end string) types.TimeProfile {
+	return types.TimeProfile{
+		ID:              id,
+		LinkedProfileID: linked,
+		From:            types.MustParseDate("2026-01-01"),
+		To:              types.MustParseDate("2026-12-31"),
+		Weekdays: types.Weekdays{
+			time.Monday:    true,
+			time.Tuesday:   true,
+			time.Wednesday: true,
+			time.Thursday:  true,
+			time.Friday:    true,
+		},
+		Segments: types.Segments{
+			1: types.Segment{Start: types.MustParseHHmm(start), End: types.MustParseHHmm(end)},
+		},
+	}
+}
+
+func TestSyncTimeProfilesDiff(t *testing.T) {
+	current := map[uint8]types.TimeProfile{
+		3:  testProfile(3, 0, "08:00", "12:00"),
+		29: testProfile(29, 0, "08:00", "17:00"),
+		30: testProfile(30, 0, "09:00", "16:00"),
+	}
+
+	profiles := []types.TimeProfile{
+		testProfile(29, 0, "08:00", "17:00"),
+		testProfile(3, 31, "08:00", "12:00"),
+		testProfile(31, 0, "13:00", "17:00"),
+	}
+
+	sync := SyncTimeProfiles{}
+
+	diff, err := sync.diff(current, profiles)
+	if err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	expected := timeProfilesDiff{
+		unchanged: []uint8{29},
+		updated:   []uint8{3},
+		added:     []uint8{31},
+		deleted:   []uint8{},
+		ordered: []types.TimeProfile{
+			testProfile(31, 0, "13:00", "17:00"),
+			testProfile(3, 31, "08:00", "12:00"),
+		},
+	}
+
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("incorrect diff\n   expected:%v\n   got:     %v", expected, diff)
+	}
+
+	sync.delete = true
+	if diff, err := sync.diff(current, profiles); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if !reflect.DeepEqual(diff.deleted, []uint8{30}) {
+		t.Errorf("incorrect deleted profiles - expected:%v, got:%v", []uint8{30}, diff.deleted)
+	} else if len(diff.ordered) != 3 {
+		t.Errorf("expected all profiles to be reloaded, got %v", diff.ordered)
+	}
+}
+
+func TestSyncTimeProfilesWithInvalidLinks(t *testing.T) {
+	current := map[uint8]types.TimeProfile{
+		30: testProfile(30, 3, "09:00", "16:00"),
+	}
+
+	tests := [][]types.TimeProfile{
+		[]types.TimeProfile{testProfile(3, 31, "08:00", "12:00")},
+		[]types.TimeProfile{testProfile(3, 30, "08:00", "12:00")},
+	}
+
+	sync := SyncTimeProfiles{}
+	for _, profiles := range tests {
+		if _, err := sync.diff(current, profiles); err == nil {
+			t.Errorf("expected error for invalid links %v", profiles)
+		}
+	}
+}
+
+type syncStub struct {
+	stub
+	set []uint8
+}
+
+func (s *syncStub) SetTimeProfile(controller uint32, profile types.TimeProfile) (bool, error) {
+	if profile.ID == 31 {
+		return false, fmt.Errorf("timeout")
+	}
+
+	s.set = append(s.set, profile.ID)
+
+	return true, nil
+}
+
+func TestSyncTimeProfilesLoadWithFailure(t *testing.T) {
+	s := syncStub{}
+	ctx := shellContext(t)
+	ctx.uhppote = &s
+
+	profiles := []types.TimeProfile{
+		testProfile(29, 0, "08:00", "17:00"),
+		testProfile(31, 0, "13:00", "17:00"),
+		testProfile(3, 31, "08:00", "12:00"),
+		testProfile(4, 3, "08:00", "12:00"),
+		testProfile(30, 29, "09:00", "16:00"),
+	}
+
+	errs := (&SyncTimeProfiles{}).load(ctx, 405419896, profiles)
+
+	if expected := []uint8{29, 30}; !reflect.DeepEqual(s.set, expected) {
+		t.Errorf("incorrect time profiles set - expected:%v, got:%v", expected, s.set)
+	}
+
+	if len(errs) != 3 {
+		t.Errorf("incorrect number of errors - expected:%v, got:%v (%v)", 3, len(errs), errs)
+	}
+}
+
+func TestSameTimeProfileComparesSegmentsByNumber(t *testing.T) {
+	p := testProfile(29, 0, "08:00", "12:00")
+	q := testProfile(29, 0, "08:00", "12:00")
+
+	q.Segments = types.Segments{
+		1: types.Segment{},
+		2: p.Segments[1],
+	}
+
+	if sameTimeProfile(p, q) {
+		t.Errorf("expected time profiles with different segment numbers to differ\n   %v\n   %v", p, q)
+	}
+
+	q.Segments = types.Segments{
+		1: p.Segments[1],
+		2: types.Segment{},
+		3: types.Segment{},
+	}
+
+	if !sameTimeProfile(p, q) {
+		t.Errorf("expected time profiles with the same segments to be identical\n   %v\n   %v", p, q)
+	}
+}
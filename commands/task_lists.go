@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+// taskList is the last known task list for a controller. The controller API does not support
+// retrieving the task list, so the tasks sent by set-task-list, add-task and import-holidays are
+// recorded in the local state store. A task list is incomplete if tasks were added without a
+// recorded set-task-list or clear-task-list i.e. the controller may have other unknown tasks.
+type taskList struct {
+	Controller uint32       `json:"controller"`
+	Updated    time.Time    `json:"updated"`
+	Complete   bool         `json:"complete"`
+	Tasks      []types.Task `json:"tasks"`
+}
+
+func taskListFile(controller uint32) string {
+	return filepath.Join("tasks", fmt.Sprintf("%v.json", controller))
+}
+
+// Retrieves the recorded task list for a controller (or nil if there is no recorded task list).
+func getTaskList(ctx Context, controller uint32) (*taskList, error) {
+	var list taskList
+
+	if ok, err := loadState(ctx, taskListFile(controller), &list); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, nil
+	}
+
+	return &list, nil
+}
+
+// Records the task list sent to a controller after the task list has been cleared. The task
+// list is recorded as incomplete if the tasks may not all have been set and activated.
+func recordTaskList(ctx Context, controller uint32, tasks []types.Task, complete bool) {
+	list := taskList{
+		Controller: controller,
+		Updated:    time.Now(),
+		Complete:   complete,
+		Tasks:      slices.Clone(tasks),
+	}
+
+	if err := saveState(ctx, taskListFile(controller), list); err != nil {
+		fmt.Fprintf(os.Stderr, "   WARN  error recording task list for %v (%v)\n", controller, err)
+	}
+}
+
+// Appends tasks added to a controller to the recorded task list.
+func recordTasks(ctx Context, controller uint32, tasks []types.Task) {
+	list := taskList{
+		Controller: controller,
+		Complete:   false,
+		Tasks:      []types.Task{},
+	}
+
+	if v, err := getTaskList(ctx, controller); err != nil {
+		fmt.Fprintf(os.Stderr, "   WARN  error retrieving recorded task list for %v (%v)\n", controller, err)
+	} else if v != nil {
+		list = *v
+	}
+
+	list.Updated = time.Now()
+	list.Tasks = append(list.Tasks, tasks...)
+
+	if err := saveState(ctx, taskListFile(controller), list); err != nil {
+		fmt.Fprintf(os.Stderr, "   WARN  error recording task list for %v (%v)\n", controller, err)
+	}
+}
@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+func testTask(task types.TaskType, door uint8, start string) types.Task {
+	return types.Task{
+		Task: task,
+		Door: door,
+		From: types.MustParseDate("2026-01-01"),
+		To:   types.MustParseDate("2026-12-31"),
+		Weekdays: types.Weekdays{
+			time.Monday:    true,
+			time.Tuesday:   true,
+			time.Wednesday: true,
+			time.Thursday:  true,
+			time.Friday:    true,
+			time.Saturday:  false,
+			time.Sunday:    false,
+		},
+		Start: types.MustParseHHmm(start),
+	}
+}
+
+func TestRecordTaskList(t *testing.T) {
+	ctx := Context{
+		settings: Settings{
+			values: map[string]string{"state": t.TempDir()},
+		},
+	}
+
+	if list, err := getTaskList(ctx, 405419896); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if list != nil {
+		t.Fatalf("expected no recorded task list, got %v", list)
+	}
+
+	recordTasks(ctx, 405419896, []types.Task{testTask(types.DoorNormallyOpen, 1, "08:00")})
+
+	if list, err := getTaskList(ctx, 405419896); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if list == nil || list.Complete || len(list.Tasks) != 1 {
+		t.Fatalf("incorrect task list %v", list)
+	}
+
+	recordTaskList(ctx, 405419896, []types.Task{testTask(types.DoorNormallyOpen, 1, "08:00")}, true)
+	recordTasks(ctx, 405419896, []types.Task{testTask(types.DoorNormallyClosed, 1, "18:00")})
+
+	expected := []string{
+		"door 1 unlock door: Mon-Fri 08:00 from 2026-01-01 to 2026-12-31",
+		"door 1 lock door: Mon-Fri 18:00 from 2026-01-01 to 2026-12-31",
+	}
+
+	list, err := getTaskList(ctx, 405419896)
+	if err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if list == nil {
+		t.Fatalf("expected recorded task list, got nil")
+	} else if !list.Complete {
+		t.Errorf("expected complete task list")
+	}
+
+	tasks := []string{}
+	for _, task := range list.Tasks {
+		tasks = append(tasks, formatTask(task))
+	}
+
+	if !reflect.DeepEqual(tasks, expected) {
+		t.Errorf("incorrect recorded tasks\n   expected:%q\n   got:     %q", expected, tasks)
+	}
+
+	if list, err := getTaskList(ctx, 303986753); err != nil || list != nil {
+		t.Errorf("expected no recorded task list for 303986753, got %v (%v)", list, err)
+	}
+}
+
+func TestDiffTaskList(t *testing.T) {
+	recorded := []types.Task{
+		testTask(types.DoorNormallyOpen, 1, "08:00"),
+		testTask(types.DoorNormallyClosed, 1, "18:00"),
+		testTask(types.DoorNormallyClosed, 1, "18:00"),
+	}
+
+	tasks := []types.Task{
+		testTask(types.DoorNormallyClosed, 1, "18:00"),
+		testTask(types.DoorNormallyOpen, 1, "08:30"),
+	}
+
+	added, removed := (&DiffTaskList{}).diff(recorded, tasks)
+
+	if expected := []string{"door 1 unlock door: Mon-Fri 08:30 from 2026-01-01 to 2026-12-31"}; !reflect.DeepEqual(added, expected) {
+		t.Errorf("incorrect added tasks\n   expected:%q\n   got:     %q", expected, added)
+	}
+
+	expected := []string{
+		"door 1 unlock door: Mon-Fri 08:00 from 2026-01-01 to 2026-12-31",
+		"door 1 lock door: Mon-Fri 18:00 from 2026-01-01 to 2026-12-31",
+	}
+
+	if !reflect.DeepEqual(removed, expected) {
+		t.Errorf("incorrect removed tasks\n   expected:%q\n   got:     %q", expected, removed)
+	}
+}
+
+type taskListStub struct {
+	stub
+	refresh error
+}
+
+func (s *taskListStub) ClearTaskList(controller uint32) (bool, error) {
+	return true, nil
+}
+
+func (s *taskListStub) AddTask(controller uint32, task types.Task) (bool, error) {
+	return true, nil
+}
+
+func (s *taskListStub) RefreshTaskList(controller uint32) (bool, error) {
+	return s.refresh == nil, s.refresh
+}
+
+func TestSetTaskListRecordsIncompleteListOnFailure(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tasks.schedule")
+	schedule := "door 1 unlock door: Mon-Fri 08:00 from 2026-01-01 to 2026-12-31\n"
+
+	if err := os.WriteFile(file, []byte(schedule), 0660); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	for _, test := range []struct {
+		refresh  error
+		complete bool
+	}{
+		{fmt.Errorf("timeout"), false},
+		{nil, true},
+	} {
+		ctx := shellContext(t)
+		ctx.uhppote = &taskListStub{refresh: test.refresh}
+
+		flag.CommandLine.Parse([]string{"set-task-list", "405419896", file})
+		if err := (&SetTaskList{}).Execute(ctx); (err == nil) != (test.refresh == nil) {
+			t.Errorf("refresh %v: unexpected result (%v)", test.refresh, err)
+		}
+
+		if list, err := getTaskList(ctx, 405419896); err != nil || list == nil {
+			t.Errorf("refresh %v: expected recorded task list (%v)", test.refresh, err)
+		} else if list.Complete != test.complete || len(list.Tasks) != 1 {
+			t.Errorf("refresh %v: incorrect recorded task list - expected complete:%v, got:%v %v", test.refresh, test.complete, list.Complete, list.Tasks)
+		}
+	}
+}
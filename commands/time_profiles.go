@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+// Retrieves all the time profiles defined on a controller, keyed by profile ID.
+func getTimeProfiles(ctx Context, deviceID uint32) (map[uint8]types.TimeProfile, error) {
+	profiles := map[uint8]types.TimeProfile{}
+
+	for id := 2; id <= 254; id++ {
+		if profile, err := ctx.uhppote.GetTimeProfile(deviceID, uint8(id)); err != nil {
+			return nil, err
+		} else if profile != nil {
+			profiles[uint8(id)] = *profile
+		}
+	}
+
+	return profiles, nil
+}
+
+// Returns true if the two time profiles are functionally identical. The segments are compared
+// by segment number (the time profile string representation omits empty segments).
+func sameTimeProfile(p, q types.TimeProfile) bool {
+	for _, i := range []uint8{1, 2, 3} {
+		if p.Segments[i] != q.Segments[i] {
+			return false
+		}
+	}
+
+	return p.ID == q.ID &&
+		p.From.Equals(q.From) &&
+		p.To.Equals(q.To) &&
+		maps.Equal(p.Weekdays, q.Weekdays) &&
+		p.LinkedProfileID == q.LinkedProfileID
+}
+
+// Orders the time profiles so that a linked profile always precedes the profiles that link to
+// it, i.e. loading the profiles in order never leaves a link to a profile that has not yet been
+// loaded. Links to profiles that are not in the list are ignored. Returns an error if the
+// links form a cycle.
+func orderTimeProfiles(profiles []types.TimeProfile) ([]types.TimeProfile, error) {
+	index := map[uint8]types.TimeProfile{}
+	for _, p := range profiles {
+		index[p.ID] = p
+	}
+
+	ordered := []types.TimeProfile{}
+	done := map[uint8]bool{}
+
+	var visit func(id uint8, chain []uint8) error
+	visit = func(id uint8, chain []uint8) error {
+		if done[id] {
+			return nil
+		}
+
+		if slices.Contains(chain, id) {
+			return fmt.Errorf("time profiles %v form a circular reference", append(chain, id))
+		}
+
+		profile := index[id]
+		if linked := profile.LinkedProfileID; linked != 0 {
+			if _, ok := index[linked]; ok {
+				if err := visit(linked, append(chain, id)); err != nil {
+					return err
+				}
+			}
+		}
+
+		done[id] = true
+		ordered = append(ordered, profile)
+
+		return nil
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(index)) {
+		if err := visit(id, []uint8{}); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+// Follows the linked profile chain from a time profile, returning the chain and an error if
+// the chain links to an undefined profile or is circular.
+func linkedChain(id uint8, profiles map[uint8]types.TimeProfile) ([]uint8, error) {
+	chain := []uint8{id}
+
+	for p, ok := profiles[id]; ok && p.LinkedProfileID != 0; p, ok = profiles[p.LinkedProfileID] {
+		linked := p.LinkedProfileID
+
+		if slices.Contains(chain, linked) {
+			return append(chain, linked), fmt.Errorf("circular reference %v", append(chain, linked))
+		}
+
+		chain = append(chain, linked)
+		if _, ok := profiles[linked]; !ok {
+			return chain, fmt.Errorf("linked time profile %v is not defined", linked)
+		}
+	}
+
+	return chain, nil
+}
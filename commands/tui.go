@@ -0,0 +1,800 @@
+package commands
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+var TUICmd = TUI{
+	interval: 5 * time.Second,
+}
+
+// TUI is a full screen terminal dashboard that displays the live status of the configured
+// controllers, updated by polling 'get-status' and from the events received by 'listen'.
+type TUI struct {
+	interval time.Duration
+}
+
+// dashboard is the state displayed by the TUI. All access is synchronized on the embedded
+// mutex because the controller status is updated concurrently by the poller and listener.
+type dashboard struct {
+	controllers []*dashboardController
+	cardholders cardholders
+	profiles    profileNames
+	selected    int
+	view        string
+	title       string
+	detail      []string
+	offset      int
+	message     string
+	listening   bool
+	listenErr   error
+	changed     chan struct{}
+	sync.Mutex
+}
+
+type dashboardController struct {
+	id      uint32
+	name    string
+	doors   [4]string
+	status  *types.Status
+	err     error
+	updated time.Time
+	modes   map[uint8]types.DoorControlState
+	swipe   types.StatusEvent
+}
+
+const (
+	viewStatus   = "status"
+	viewCards    = "cards"
+	viewProfiles = "profiles"
+)
+
+func (c *TUI) Execute(ctx Context) error {
+	flagset := newFlagSet()
+	interval := flagset.Duration("interval", c.interval, "Interval at which to poll the controller status")
+
+	if err := flagset.Parse(flag.Args()[1:]); err != nil {
+		return err
+	}
+
+	controllers, err := overrideControllers(ctx, flagset.Args())
+	if err != nil {
+		return err
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return fmt.Errorf("'tui' requires a terminal")
+	}
+
+	d := newDashboard(ctx, controllers)
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+
+	defer term.Restore(fd, state)
+
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+
+	done := make(chan struct{})
+	q := make(chan os.Signal)
+	wg := sync.WaitGroup{}
+
+	defer wg.Wait()
+	defer close(q)
+	defer close(done)
+
+	wg.Go(func() {
+		if err := ctx.uhppote.Listen(&tuiListener{d}, q); err != nil {
+			d.Lock()
+			d.listenErr = err
+			d.Unlock()
+			d.redraw()
+		}
+	})
+
+	for _, controller := range d.controllers {
+		go d.loadModes(ctx, controller.id)
+	}
+
+	go func() {
+		ticker := time.NewTicker(*interval)
+		defer ticker.Stop()
+
+		for {
+			d.poll(ctx)
+
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	keys := make(chan string, 16)
+	go func() {
+		buffer := make([]byte, 64)
+		for {
+			N, err := os.Stdin.Read(buffer)
+			if err != nil {
+				close(keys)
+				return
+			}
+
+			for _, key := range parseKeys(buffer[:N]) {
+				keys <- key
+			}
+		}
+	}()
+
+	clock := time.NewTicker(1 * time.Second)
+	defer clock.Stop()
+
+	for {
+		width, height, err := term.GetSize(fd)
+		if err != nil || width <= 0 || height <= 0 {
+			width, height = 80, 24
+		}
+
+		d.draw(os.Stdout, time.Now(), width, height)
+
+		select {
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+
+			quit, action := d.onKey(ctx, key, height)
+			if quit {
+				return nil
+			} else if action != nil {
+				go func() {
+					action()
+					d.redraw()
+				}()
+			}
+
+		case <-d.changed:
+		case <-clock.C:
+		}
+	}
+}
+
+func newDashboard(ctx Context, controllers []uint32) *dashboard {
+	d := dashboard{
+		cardholders: getCardholders(ctx),
+		profiles:    getProfileNames(ctx),
+		view:        viewStatus,
+		changed:     make(chan struct{}, 1),
+	}
+
+	for _, id := range slices.Sorted(slices.Values(controllers)) {
+		controller := dashboardController{
+			id:    id,
+			modes: map[uint8]types.DoorControlState{},
+		}
+
+		for _, device := range ctx.devices {
+			if device.DeviceID == id {
+				controller.name = device.Name
+				for i, door := range device.Doors {
+					if i < len(controller.doors) {
+						controller.doors[i] = door
+					}
+				}
+			}
+		}
+
+		d.controllers = append(d.controllers, &controller)
+	}
+
+	return &d
+}
+
+// Requests a redraw of the screen, without blocking if a redraw is already pending.
+func (d *dashboard) redraw() {
+	select {
+	case d.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Retrieves the status of all the controllers concurrently.
+func (d *dashboard) poll(ctx Context) {
+	wg := sync.WaitGroup{}
+
+	for _, controller := range d.controllers {
+		wg.Go(func() {
+			status, err := ctx.uhppote.GetStatus(controller.id)
+			if err == nil && status == nil {
+				err = fmt.Errorf("no response")
+			}
+
+			d.update(controller.id, status, err, time.Now())
+		})
+	}
+
+	wg.Wait()
+	d.redraw()
+}
+
+// Updates the controller status from a 'get-status' response or a received event. The last
+// swipe is retained until a later swipe is reported.
+func (d *dashboard) update(id uint32, status *types.Status, err error, now time.Time) {
+	d.Lock()
+	defer d.Unlock()
+
+	for _, controller := range d.controllers {
+		if controller.id == id {
+			if err != nil {
+				controller.err = err
+				return
+			}
+
+			controller.status = status
+			controller.err = nil
+			controller.updated = now
+
+			if e := status.Event; e.Type == eventSwipe && e.Index >= controller.swipe.Index {
+				controller.swipe = e
+			}
+		}
+	}
+}
+
+// Retrieves the door control mode and delay for each door of a controller.
+func (d *dashboard) loadModes(ctx Context, id uint32) {
+	for _, door := range []uint8{1, 2, 3, 4} {
+		if state, err := ctx.uhppote.GetDoorControlState(id, door); err == nil && state != nil {
+			d.setMode(id, *state)
+		}
+	}
+
+	d.redraw()
+}
+
+func (d *dashboard) setMode(id uint32, state types.DoorControlState) {
+	d.Lock()
+	defer d.Unlock()
+
+	for _, controller := range d.controllers {
+		if controller.id == id {
+			controller.modes[state.Door] = state
+		}
+	}
+}
+
+func (d *dashboard) setMessage(format string, args ...any) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.message = fmt.Sprintf(format, args...)
+}
+
+// Returns the controller and door for the selected row.
+func (d *dashboard) current() (*dashboardController, uint8) {
+	if len(d.controllers) == 0 {
+		return nil, 0
+	}
+
+	return d.controllers[d.selected/4], uint8(d.selected%4 + 1)
+}
+
+// Handles a key press. Returns true if the key exits the TUI and an (optional) action to be
+// executed in the background, so that a slow or offline controller does not block the display.
+func (d *dashboard) onKey(ctx Context, key string, height int) (bool, func()) {
+	d.Lock()
+	defer d.Unlock()
+
+	if key == "q" || key == "ctrl-c" {
+		return true, nil
+	}
+
+	if d.view != viewStatus {
+		page := max(height-4, 1)
+
+		switch key {
+		case "esc", "b", "backspace":
+			d.view = viewStatus
+			d.detail = nil
+			d.offset = 0
+		case "up", "k":
+			d.offset = max(d.offset-1, 0)
+		case "down", "j":
+			d.offset = max(min(d.offset+1, len(d.detail)-page), 0)
+		case "pgup":
+			d.offset = max(d.offset-page, 0)
+		case "pgdn", " ":
+			d.offset = max(min(d.offset+page, len(d.detail)-page), 0)
+		}
+
+		return false, nil
+	}
+
+	controller, door := d.current()
+	if controller == nil {
+		return false, nil
+	}
+
+	switch key {
+	case "up", "k":
+		d.selected = max(d.selected-1, 0)
+
+	case "down", "j":
+		d.selected = min(d.selected+1, 4*len(d.controllers)-1)
+
+	case "r":
+		d.message = "refreshing..."
+		return false, func() {
+			d.poll(ctx)
+			d.loadModes(ctx, controller.id)
+			d.setMessage("")
+		}
+
+	case "o":
+		label := doorLabel(controller, door)
+		d.message = fmt.Sprintf("opening %v...", label)
+		return false, func() {
+			if result, err := ctx.uhppote.OpenDoor(controller.id, door); err != nil {
+				d.setMessage("error opening %v (%v)", label, err)
+			} else if result == nil || !result.Succeeded {
+				d.setMessage("failed to open %v", label)
+			} else {
+				d.setMessage("opened %v", label)
+			}
+		}
+
+	case "m":
+		label := doorLabel(controller, door)
+		current, ok := controller.modes[door]
+		d.message = fmt.Sprintf("updating %v...", label)
+		return false, func() {
+			if !ok {
+				if state, err := ctx.uhppote.GetDoorControlState(controller.id, door); err != nil || state == nil {
+					d.setMessage("error retrieving door control mode for %v (%v)", label, err)
+					return
+				} else {
+					current = *state
+				}
+			}
+
+			mode := nextMode(current.ControlState)
+			if state, err := ctx.uhppote.SetDoorControlState(controller.id, door, mode, current.Delay); err != nil {
+				d.setMessage("error setting %v to '%v' (%v)", label, mode, err)
+			} else if state == nil || state.ControlState != mode {
+				d.setMessage("failed to set %v to '%v'", label, mode)
+			} else {
+				d.setMode(controller.id, *state)
+				d.setMessage("set %v to '%v'", label, mode)
+			}
+		}
+
+	case "c":
+		d.view = viewCards
+		d.title = fmt.Sprintf("CARDS %v", controllerLabel(controller))
+		d.detail = []string{"loading..."}
+		d.offset = 0
+		return false, func() {
+			cards, err := getCards(ctx, controller.id)
+			d.showCards(cards, err)
+		}
+
+	case "p":
+		d.view = viewProfiles
+		d.title = fmt.Sprintf("TIME PROFILES %v", controllerLabel(controller))
+		d.detail = []string{"loading..."}
+		d.offset = 0
+		return false, func() {
+			profiles := []types.TimeProfile{}
+			for id := 2; id <= 254; id++ {
+				if profile, err := ctx.uhppote.GetTimeProfile(controller.id, uint8(id)); err != nil {
+					d.showProfiles(profiles, err)
+					return
+				} else if profile != nil {
+					profiles = append(profiles, *profile)
+				}
+			}
+
+			d.showProfiles(profiles, nil)
+		}
+	}
+
+	return false, nil
+}
+
+func (d *dashboard) showCards(cards []types.Card, err error) {
+	var b bytes.Buffer
+
+	c := GetCards{
+		cardholders: d.cardholders,
+		profiles:    d.profiles,
+	}
+
+	c.print(cards, &b)
+
+	lines := []string{}
+	if s := strings.TrimSpace(b.String()); s != "" {
+		lines = strings.Split(s, "\n")
+	}
+
+	if err != nil {
+		lines = append(lines, fmt.Sprintf("ERROR: %v", err))
+	} else if len(lines) == 0 {
+		lines = append(lines, "no cards")
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	if d.view == viewCards {
+		d.detail = lines
+	}
+}
+
+func (d *dashboard) showProfiles(profiles []types.TimeProfile, err error) {
+	table := [][]string{}
+	for _, p := range profiles {
+		linked := "-"
+		if p.LinkedProfileID != 0 {
+			linked = d.profiles.label(p.LinkedProfileID)
+		}
+
+		table = append(table, []string{
+			fmt.Sprintf("%v", p.ID),
+			fmt.Sprintf("%v", p.From),
+			fmt.Sprintf("%v", p.To),
+			fmt.Sprintf("%v", p.Weekdays),
+			fmt.Sprintf("%v", p.Segments),
+			linked,
+			d.profiles.name(p.ID),
+		})
+	}
+
+	lines := []string{}
+	for _, row := range format(table) {
+		lines = append(lines, strings.TrimSpace(row))
+	}
+
+	if err != nil {
+		lines = append(lines, fmt.Sprintf("ERROR: %v", err))
+	} else if len(lines) == 0 {
+		lines = append(lines, "no time profiles")
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	if d.view == viewProfiles {
+		d.detail = lines
+	}
+}
+
+// Renders the current view as a list of lines, truncated to the screen width and height.
+func (d *dashboard) render(now time.Time, width, height int) []string {
+	d.Lock()
+	defer d.Unlock()
+
+	lines := []string{}
+	keys := ""
+
+	switch d.view {
+	case viewStatus:
+		listening := "listening"
+		if d.listenErr != nil {
+			listening = fmt.Sprintf("not listening (%v)", d.listenErr)
+		} else if !d.listening {
+			listening = "not listening"
+		}
+
+		body := d.renderStatus(now)
+
+		// ... scroll to keep the selected door on the screen
+		page := max(height-4, 1)
+		if i := slices.IndexFunc(body, func(line string) bool { return strings.HasPrefix(line, "  >") }); i >= page {
+			body = append(body[:1], body[i-page+2:]...)
+		}
+
+		lines = append(lines, fmt.Sprintf("uhppote-cli  %v  %v", now.Format("2006-01-02 15:04:05"), listening), "")
+		lines = append(lines, body...)
+		keys = "up/down select  o open  m door mode  c cards  p time profiles  r refresh  q quit"
+
+	default:
+		page := max(height-4, 1)
+		end := min(d.offset+page, len(d.detail))
+
+		lines = append(lines, d.title, "")
+		lines = append(lines, d.detail[min(d.offset, end):end]...)
+		keys = "up/down/pgup/pgdn scroll  esc back  q quit"
+	}
+
+	// ... pad to place the message and keys at the bottom of the screen
+	for len(lines) < height-2 {
+		lines = append(lines, "")
+	}
+
+	lines = append(lines[:max(height-2, 0)], d.message, keys)
+
+	for i, line := range lines {
+		if len(line) > width {
+			lines[i] = line[:width]
+		}
+	}
+
+	return lines
+}
+
+func (d *dashboard) renderStatus(now time.Time) []string {
+	table := [][]string{
+		{"", "DOOR", "NAME", "MODE", "STATE", "BUTTON", "LOCK", "LAST SWIPE"},
+	}
+
+	for i, controller := range d.controllers {
+		for door := uint8(1); door <= 4; door++ {
+			marker := " "
+			if d.selected == 4*i+int(door)-1 {
+				marker = ">"
+			}
+
+			table = append(table, d.renderDoor(controller, door, marker))
+		}
+	}
+
+	rows := format(table)
+	lines := []string{"  " + strings.TrimRight(rows[0], " ")}
+
+	for i, controller := range d.controllers {
+		lines = append(lines, "", d.renderController(controller, now))
+		for _, row := range rows[1+4*i : 5+4*i] {
+			lines = append(lines, "  "+strings.TrimRight(row, " "))
+		}
+	}
+
+	if len(d.controllers) == 0 {
+		lines = append(lines, "", "no controllers")
+	}
+
+	return lines
+}
+
+func (d *dashboard) renderController(controller *dashboardController, now time.Time) string {
+	label := controllerLabel(controller)
+
+	switch {
+	case controller.status == nil && controller.err != nil:
+		return fmt.Sprintf("%v  ERROR: %v", label, controller.err)
+
+	case controller.status == nil:
+		return fmt.Sprintf("%v  ...", label)
+	}
+
+	status := controller.status
+	line := fmt.Sprintf("%v  %v  error:%v  inputs:%02X", label, status.SystemDateTime, status.SystemError, status.InputState)
+
+	if controller.err != nil {
+		line += fmt.Sprintf("  ERROR: %v (last update %v ago)", controller.err, now.Sub(controller.updated).Truncate(time.Second))
+	}
+
+	return line
+}
+
+func (d *dashboard) renderDoor(controller *dashboardController, door uint8, marker string) []string {
+	name := controller.doors[door-1]
+	if name == "" {
+		name = "-"
+	}
+
+	mode := "-"
+	if state, ok := controller.modes[door]; ok {
+		mode = fmt.Sprintf("%v", state.ControlState)
+	}
+
+	if controller.status == nil {
+		return []string{marker, fmt.Sprintf("%v", door), name, mode, "-", "-", "-", "-"}
+	}
+
+	status := controller.status
+
+	state := "closed"
+	if status.DoorState[door] {
+		state = "open"
+	}
+
+	button := "released"
+	if status.DoorButton[door] {
+		button = "pressed"
+	}
+
+	lock := "locked"
+	if status.RelayState&(1<<(door-1)) != 0 {
+		lock = "unlocked"
+	}
+
+	swipe := "-"
+	if e := controller.swipe; e.Index > 0 && e.Door == door {
+		granted := "denied"
+		if e.Granted {
+			granted = "granted"
+		}
+
+		swipe = fmt.Sprintf("%v %v %v", e.Timestamp, e.CardNumber, granted)
+		if name := d.cardholders.annotate(e.CardNumber); name != "" {
+			swipe = fmt.Sprintf("%v %v (%v) %v", e.Timestamp, e.CardNumber, name, granted)
+		}
+	}
+
+	return []string{marker, fmt.Sprintf("%v", door), name, mode, state, button, lock, swipe}
+}
+
+func (d *dashboard) draw(w io.Writer, now time.Time, width, height int) {
+	lines := d.render(now, width, height)
+
+	fmt.Fprintf(w, "\x1b[H\x1b[2J%v", strings.Join(lines, "\r\n"))
+}
+
+func controllerLabel(controller *dashboardController) string {
+	if controller.name != "" {
+		return fmt.Sprintf("%v (%v)", controller.id, controller.name)
+	}
+
+	return fmt.Sprintf("%v", controller.id)
+}
+
+func doorLabel(controller *dashboardController, door uint8) string {
+	if name := controller.doors[door-1]; name != "" {
+		return fmt.Sprintf("%v door %v (%v)", controller.id, door, name)
+	}
+
+	return fmt.Sprintf("%v door %v", controller.id, door)
+}
+
+// Returns the next door control mode in the cycle controlled -> normally open -> normally closed.
+func nextMode(mode types.ControlState) types.ControlState {
+	switch mode {
+	case types.Controlled:
+		return types.NormallyOpen
+	case types.NormallyOpen:
+		return types.NormallyClosed
+	default:
+		return types.Controlled
+	}
+}
+
+// Translates the bytes read from a terminal in raw mode into key names. Escape sequences for the
+// cursor keys are translated to 'up', 'down', 'pgup' and 'pgdn'.
+func parseKeys(b []byte) []string {
+	sequences := []struct {
+		seq string
+		key string
+	}{
+		{"\x1b[A", "up"},
+		{"\x1bOA", "up"},
+		{"\x1b[B", "down"},
+		{"\x1bOB", "down"},
+		{"\x1b[5~", "pgup"},
+		{"\x1b[6~", "pgdn"},
+		{"\x1b[C", "right"},
+		{"\x1b[D", "left"},
+	}
+
+	keys := []string{}
+
+loop:
+	for s := string(b); len(s) > 0; {
+		for _, v := range sequences {
+			if strings.HasPrefix(s, v.seq) {
+				keys = append(keys, v.key)
+				s = s[len(v.seq):]
+				continue loop
+			}
+		}
+
+		switch s[0] {
+		case 0x03:
+			keys = append(keys, "ctrl-c")
+		case 0x1b:
+			keys = append(keys, "esc")
+		case 0x7f, 0x08:
+			keys = append(keys, "backspace")
+		case '\r', '\n':
+			keys = append(keys, "enter")
+		default:
+			keys = append(keys, string(s[0]))
+		}
+
+		s = s[1:]
+	}
+
+	return keys
+}
+
+type tuiListener struct {
+	dashboard *dashboard
+}
+
+func (l *tuiListener) OnConnected() {
+	l.dashboard.Lock()
+	l.dashboard.listening = true
+	l.dashboard.Unlock()
+	l.dashboard.redraw()
+}
+
+func (l *tuiListener) OnEvent(status *types.Status) {
+	l.dashboard.update(uint32(status.SerialNumber), status, nil, time.Now())
+	l.dashboard.redraw()
+}
+
+func (l *tuiListener) OnError(err error) bool {
+	l.dashboard.setMessage("listen: %v", err)
+	l.dashboard.redraw()
+	return true
+}
+
+func (c *TUI) CLI() string {
+	return "tui"
+}
+
+func (c *TUI) Description() string {
+	return "Displays a live dashboard of the controller and door status"
+}
+
+func (c *TUI) Usage() string {
+	return "[--interval <duration>] [serial number...]"
+}
+
+func (c *TUI) Help() {
+	fmt.Println("Usage: uhppote-cli [options] tui [--interval <duration>] [serial number...]")
+	fmt.Println()
+	fmt.Println(" Displays a full screen dashboard with the live status of the controllers i.e. the door control mode,")
+	fmt.Println(" door open/closed state, door button, lock (relay) state, controller inputs and the last card swipe")
+	fmt.Println(" for each door. The status is updated by polling the controllers and from the events received on the")
+	fmt.Println(" listen address.")
+	fmt.Println()
+	fmt.Println(" Keys:")
+	fmt.Println()
+	fmt.Println("    up/down  selects a door")
+	fmt.Println("    o        opens the selected door")
+	fmt.Println("    m        changes the door control mode (controlled -> normally open -> normally closed)")
+	fmt.Println("    c        displays the cards stored on the selected controller")
+	fmt.Println("    p        displays the time profiles stored on the selected controller")
+	fmt.Println("    r        refreshes the controller status")
+	fmt.Println("    esc      returns to the dashboard from the cards or time profiles")
+	fmt.Println("    q        exits the dashboard")
+	fmt.Println()
+	fmt.Println("  serial number  controller serial number (or name). Defaults to all the controllers in the configuration file")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --config    File path for the 'conf' file containing the controller configuration")
+	fmt.Printf("                (defaults to %s)\n", config.DefaultConfig)
+	fmt.Println("    --debug     Displays internal information for diagnosing errors")
+	fmt.Println()
+	fmt.Println("    --interval  Interval at which to poll the controller status (defaults to 5s)")
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println()
+	fmt.Println("    uhppote-cli tui")
+	fmt.Println("    uhppote-cli tui --interval 2s 405419896 303986753")
+	fmt.Println()
+}
+
+// Returns false - configuration is useful but optional.
+func (c *TUI) RequiresConfig() bool {
+	return false
+}
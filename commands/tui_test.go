@@ -0,0 +1,163 @@
+package commands
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+// tuiStub adds the controller status and card list to the shared door control stub.
+type tuiStub struct {
+	doorControlStub
+}
+
+func (s *tuiStub) GetStatus(controller uint32) (*types.Status, error) {
+	return &types.Status{
+		SerialNumber:   types.SerialNumber(controller),
+		DoorState:      map[uint8]bool{1: true, 2: false, 3: false, 4: false},
+		DoorButton:     map[uint8]bool{1: false, 2: true, 3: false, 4: false},
+		SystemDateTime: types.DateTime(time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)),
+		RelayState:     0x01,
+		InputState:     0x00,
+		Event: types.StatusEvent{
+			Index:      57,
+			Type:       eventSwipe,
+			Granted:    true,
+			Door:       1,
+			CardNumber: 10058400,
+			Timestamp:  types.DateTime(time.Date(2026, 10, 19, 11, 59, 50, 0, time.Local)),
+		},
+	}, nil
+}
+
+func (s *tuiStub) GetCards(controller uint32) (uint32, error) {
+	return 2, nil
+}
+
+func (s *tuiStub) GetCardByIndex(controller, index uint32) (*types.Card, error) {
+	return &types.Card{
+		CardNumber: 10058399 + index,
+		From:       types.MustParseDate("2026-01-01"),
+		To:         types.MustParseDate("2026-12-31"),
+		Doors:      map[uint8]uint8{1: 1, 2: 0, 3: 0, 4: 0},
+	}, nil
+}
+
+func TestParseKeys(t *testing.T) {
+	tests := map[string][]string{
+		"q":              {"q"},
+		"\x1b[A\x1b[B":   {"up", "down"},
+		"\x1bOA":         {"up"},
+		"\x1b[5~\x1b[6~": {"pgup", "pgdn"},
+		"\x1b":           {"esc"},
+		"om\x03":         {"o", "m", "ctrl-c"},
+		"\x7f\r":         {"backspace", "enter"},
+	}
+
+	for input, expected := range tests {
+		if keys := parseKeys([]byte(input)); !reflect.DeepEqual(keys, expected) {
+			t.Errorf("%q: incorrect keys - expected:%q, got:%q", input, expected, keys)
+		}
+	}
+}
+
+func TestDashboardStatus(t *testing.T) {
+	s := tuiStub{
+		doorControlStub{
+			doors: map[doorID]types.DoorControlState{
+				{405419896, 1}: {SerialNumber: 405419896, Door: 1, ControlState: types.Controlled, Delay: 5},
+			},
+		},
+	}
+
+	ctx := shellContext(t)
+	ctx.uhppote = &s
+
+	d := newDashboard(ctx, []uint32{405419896, 303986753})
+
+	d.poll(ctx)
+	d.loadModes(ctx, 405419896)
+
+	lines := d.render(time.Date(2026, 10, 19, 12, 0, 5, 0, time.Local), 120, 24)
+
+	if len(lines) != 24 {
+		t.Errorf("incorrect number of lines - expected:%v, got:%v", 24, len(lines))
+	}
+
+	expected := []string{
+		"303986753 (Beta)  2026-10-19 12:00:00  error:0  inputs:00",
+		"405419896 (Alpha)  2026-10-19 12:00:00  error:0  inputs:00",
+		"1     Great Hall  controlled  open    released  unlocked  2026-10-19 11:59:50 10058400 granted",
+		"2     Kitchen     -           closed  pressed   locked    -",
+	}
+
+	for _, line := range expected {
+		if !slices.ContainsFunc(lines, func(l string) bool { return strings.TrimSpace(l) == line }) {
+			t.Errorf("missing line %q\n%v", line, strings.Join(lines, "\n"))
+		}
+	}
+
+	if !strings.HasPrefix(lines[5], "  >") {
+		t.Errorf("first door not selected %q", lines[5])
+	}
+}
+
+func TestDashboardKeys(t *testing.T) {
+	s := tuiStub{
+		doorControlStub{
+			doors: map[doorID]types.DoorControlState{
+				{405419896, 2}: {SerialNumber: 405419896, Door: 2, ControlState: types.Controlled, Delay: 7},
+			},
+		},
+	}
+
+	ctx := shellContext(t)
+	ctx.uhppote = &s
+
+	d := newDashboard(ctx, []uint32{405419896})
+
+	for _, key := range []string{"down", "down", "up"} {
+		d.onKey(ctx, key, 24)
+	}
+
+	// ... open door
+	if _, action := d.onKey(ctx, "o", 24); action == nil {
+		t.Fatalf("expected 'open' action")
+	} else {
+		action()
+	}
+
+	if expected := []doorID{{405419896, 2}}; !reflect.DeepEqual(s.opened, expected) {
+		t.Errorf("incorrect door opened - expected:%v, got:%v", expected, s.opened)
+	}
+
+	// ... cycle door control mode
+	for _, mode := range []types.ControlState{types.NormallyOpen, types.NormallyClosed, types.Controlled} {
+		_, action := d.onKey(ctx, "m", 24)
+		action()
+
+		if state := s.state(405419896, 2); state.ControlState != mode || state.Delay != 7 {
+			t.Errorf("incorrect door control state - expected:%v (7s), got:%v (%vs)", mode, state.ControlState, state.Delay)
+		}
+	}
+
+	// ... drill into cards
+	_, action := d.onKey(ctx, "c", 24)
+	action()
+
+	if d.view != viewCards || len(d.detail) != 2 || !strings.HasPrefix(d.detail[1], "10058401 2026-01-01 2026-12-31 Y N N N") {
+		t.Errorf("incorrect cards view %q %q", d.view, d.detail)
+	}
+
+	if quit, _ := d.onKey(ctx, "esc", 24); quit || d.view != viewStatus {
+		t.Errorf("'esc' did not return to the status view")
+	}
+
+	if quit, _ := d.onKey(ctx, "q", 24); !quit {
+		t.Errorf("'q' did not quit")
+	}
+}
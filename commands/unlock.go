@@ -0,0 +1,299 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+	"github.com/uhppoted/uhppoted-lib/config"
+)
+
+var UnlockCmd = Unlock{
+	retries:  12,
+	interval: 5 * time.Second,
+}
+
+// Unlock sets a door to 'normally open' for a period and then restores the previous door
+// control mode and delay. The previous mode and delay are recorded as a pending revert in the
+// local state store so that 'unlock --restore' can restore the door if the unlocking process
+// is killed before the door is restored.
+type Unlock struct {
+	door     string
+	period   time.Duration
+	restore  bool
+	all      bool
+	retries  int
+	interval time.Duration
+}
+
+func (c *Unlock) Execute(ctx Context) error {
+	if err := c.parseArgs(); err != nil {
+		return err
+	}
+
+	if c.restore {
+		return c.restorePending(ctx, time.Now())
+	}
+
+	door, err := resolveDoor(ctx, c.door)
+	if err != nil {
+		return err
+	}
+
+	// ... restore any doors left unlocked by an earlier (killed) process
+	if reverts, err := getPendingReverts(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "   WARN  error retrieving pending door reverts (%v)\n", err)
+	} else {
+		now := time.Now()
+		for _, p := range reverts {
+			if p.Until.Before(now) && (p.Controller != door.controller || p.Door != door.door) {
+				if err := c.revert(ctx, p.Controller, p.Door, p.Until); err != nil {
+					fmt.Fprintf(os.Stderr, "   WARN  error restoring %v (%v)\n", p, err)
+				}
+			}
+		}
+	}
+
+	revert, err := c.unlock(ctx, door, time.Now().Add(c.period))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("   %v  unlocked until %v (restores '%v', delay %vs)\n", revert, revert.Until.Format("2006-01-02 15:04:05"), revert.Mode, revert.Delay)
+
+	q := make(chan os.Signal, 1)
+
+	signal.Notify(q, os.Interrupt)
+	defer signal.Stop(q)
+
+	timer := time.NewTimer(time.Until(revert.Until))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-q:
+		fmt.Println()
+	}
+
+	return c.restoreWithRetry(ctx, revert, q)
+}
+
+// Restores the door control state, retrying on failure until either the retries are exhausted
+// or the process is interrupted (in which case the pending revert is left for 'unlock --restore').
+func (c *Unlock) restoreWithRetry(ctx Context, revert pendingRevert, interrupt <-chan os.Signal) error {
+	for attempt := 1; ; attempt++ {
+		err := c.revert(ctx, revert.Controller, revert.Door, revert.Until)
+		if err == nil {
+			return nil
+		} else if attempt > c.retries {
+			return fmt.Errorf("error restoring %v (%v) - use 'unlock --restore' to restore the door", revert, err)
+		}
+
+		fmt.Fprintf(os.Stderr, "   WARN  error restoring %v (%v) - retrying in %v\n", revert, err, c.interval)
+
+		select {
+		case <-time.After(c.interval):
+		case <-interrupt:
+			fmt.Println()
+			return fmt.Errorf("error restoring %v (%v) - use 'unlock --restore' to restore the door", revert, err)
+		}
+	}
+}
+
+// Records the door control state as a pending revert and sets the door to 'normally open'. If
+// the door already has a pending revert (i.e. it is already temporarily unlocked), the original
+// door control state is retained and the unlock period is extended.
+func (c *Unlock) unlock(ctx Context, door configuredDoor, until time.Time) (pendingRevert, error) {
+	pending, err := getPendingRevert(ctx, door.controller, door.door)
+	if err != nil {
+		return pendingRevert{}, err
+	}
+
+	revert := pendingRevert{
+		Controller: door.controller,
+		Door:       door.door,
+		Name:       door.name,
+		Until:      until,
+	}
+
+	if pending != nil {
+		revert.Mode = pending.Mode
+		revert.Delay = pending.Delay
+		if pending.Until.After(until) {
+			revert.Until = pending.Until
+		}
+	} else if state, err := ctx.uhppote.GetDoorControlState(door.controller, door.door); err != nil {
+		return pendingRevert{}, err
+	} else if state == nil {
+		return pendingRevert{}, fmt.Errorf("no response to get-door-control for %v door %v", door.controller, door.door)
+	} else {
+		revert.Mode = state.ControlState
+		revert.Delay = state.Delay
+	}
+
+	if err := savePendingRevert(ctx, revert); err != nil {
+		return pendingRevert{}, fmt.Errorf("error recording pending revert (%v)", err)
+	}
+
+	if state, err := ctx.uhppote.SetDoorControlState(door.controller, door.door, types.NormallyOpen, revert.Delay); err != nil || state == nil || state.ControlState != types.NormallyOpen {
+		if pending == nil {
+			if err := deletePendingRevert(ctx, door.controller, door.door); err != nil {
+				fmt.Fprintf(os.Stderr, "   WARN  error removing pending revert for %v (%v)\n", revert, err)
+			}
+		}
+
+		if err != nil {
+			return pendingRevert{}, err
+		}
+
+		return pendingRevert{}, fmt.Errorf("failed to set %v to 'normally open'", revert)
+	}
+
+	return revert, nil
+}
+
+// Restores the door control mode and delay recorded in the pending revert for a door and
+// removes the pending revert. The door is not restored if the pending revert has been
+// extended past 'until' by a later 'unlock' (or has already been restored).
+func (c *Unlock) revert(ctx Context, controller uint32, door uint8, until time.Time) error {
+	revert, err := getPendingRevert(ctx, controller, door)
+	if err != nil {
+		return err
+	} else if revert == nil {
+		fmt.Printf("   %v door %v  already restored\n", controller, door)
+		return nil
+	} else if revert.Until.After(until) {
+		fmt.Printf("   %v  unlock extended until %v\n", revert, revert.Until.Format("2006-01-02 15:04:05"))
+		return nil
+	}
+
+	state, err := ctx.uhppote.SetDoorControlState(controller, door, revert.Mode, revert.Delay)
+	if err != nil {
+		return err
+	} else if state == nil || state.ControlState != revert.Mode {
+		return fmt.Errorf("failed to restore '%v'", revert.Mode)
+	}
+
+	if err := deletePendingRevert(ctx, controller, door); err != nil {
+		fmt.Fprintf(os.Stderr, "   WARN  error removing pending revert for %v (%v)\n", revert, err)
+	}
+
+	fmt.Printf("   %v  restored '%v' (delay %vs)\n", revert, revert.Mode, revert.Delay)
+
+	return nil
+}
+
+// Restores the doors with a pending revert that has expired (or all the doors with a pending
+// revert if --all is specified).
+func (c *Unlock) restorePending(ctx Context, now time.Time) error {
+	reverts, err := getPendingReverts(ctx)
+	if err != nil {
+		return err
+	}
+
+	errors := []string{}
+	for _, p := range reverts {
+		if !c.all && p.Until.After(now) {
+			fmt.Printf("   %v  unlocked until %v\n", p, p.Until.Format("2006-01-02 15:04:05"))
+			continue
+		}
+
+		if err := c.revert(ctx, p.Controller, p.Door, p.Until); err != nil {
+			errors = append(errors, fmt.Sprintf("%v: %v", p, err))
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("error restoring doors (%v)", strings.Join(errors, "; "))
+	}
+
+	return nil
+}
+
+func (c *Unlock) parseArgs() error {
+	flagset := newFlagSet()
+	period := flagset.Duration("for", 0, "Time for which to unlock the door e.g. 45m")
+	restore := flagset.Bool("restore", false, "Restores the doors with an expired pending revert")
+	all := flagset.Bool("all", false, "Restores all doors with a pending revert")
+
+	c.door = ""
+
+	args := flag.Args()[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		c.door = args[0]
+		args = args[1:]
+	}
+
+	if err := flagset.Parse(args); err != nil {
+		return err
+	}
+
+	if c.door == "" && flagset.NArg() > 0 {
+		c.door = flagset.Arg(0)
+	}
+
+	c.period = *period
+	c.restore = *restore
+	c.all = *all
+
+	if c.restore {
+		return nil
+	} else if c.door == "" {
+		return fmt.Errorf("missing door")
+	} else if c.period <= 0 {
+		return fmt.Errorf("missing or invalid --for duration (e.g. --for 45m)")
+	}
+
+	return nil
+}
+
+func (c *Unlock) CLI() string {
+	return "unlock"
+}
+
+func (c *Unlock) Description() string {
+	return "Temporarily unlocks a door"
+}
+
+func (c *Unlock) Usage() string {
+	return "<door> --for <duration> | --restore [--all]"
+}
+
+func (c *Unlock) Help() {
+	fmt.Println("Usage: uhppote-cli [options] unlock <door> --for <duration>")
+	fmt.Println("       uhppote-cli [options] unlock --restore [--all]")
+	fmt.Println()
+	fmt.Println(" Sets a door to 'normally open' for a period and then restores the previous door control mode and delay when")
+	fmt.Println(" the period expires (or on Ctrl-C). The previous mode and delay are recorded as a pending revert in the local")
+	fmt.Println(" state store, so that a door left unlocked by an interrupted 'unlock' can be restored with 'unlock --restore'.")
+	fmt.Println(" Unlocking a door that is already temporarily unlocked extends the unlock period. A second Ctrl-C while the")
+	fmt.Println(" door is being restored stops retrying and leaves the pending revert for 'unlock --restore'.")
+	fmt.Println()
+	fmt.Println("  door  door name (from the configuration file) or <controller>:<door>")
+	fmt.Println()
+	fmt.Println("  Options:")
+	fmt.Println()
+	fmt.Println("    --config   File path for the 'conf' file containing the controller configuration")
+	fmt.Printf("               (defaults to %s)\n", config.DefaultConfig)
+	fmt.Println("    --debug    Displays internal information for diagnosing errors")
+	fmt.Println()
+	fmt.Println("    --for      Time for which to unlock the door e.g. 45m")
+	fmt.Println("    --restore  Restores the doors with an expired pending revert")
+	fmt.Println("    --all      (with --restore) restores all doors with a pending revert")
+	fmt.Println()
+	fmt.Println("  Examples:")
+	fmt.Println()
+	fmt.Println("    uhppote-cli unlock 'Loading Bay' --for 45m")
+	fmt.Println("    uhppote-cli unlock 405419896:2 --for 2h")
+	fmt.Println("    uhppote-cli unlock --restore")
+	fmt.Println()
+}
+
+// Returns false - configuration is useful but optional.
+func (c *Unlock) RequiresConfig() bool {
+	return false
+}
@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uhppoted/uhppote-core/types"
+)
+
+func TestUnlock(t *testing.T) {
+	s := doorControlStub{
+		doors: map[doorID]types.DoorControlState{
+			{405419896, 2}: {SerialNumber: 405419896, Door: 2, ControlState: types.Controlled, Delay: 7},
+		},
+	}
+
+	ctx := Context{
+		uhppote: &s,
+		settings: Settings{
+			values: map[string]string{"state": t.TempDir()},
+		},
+	}
+
+	door := configuredDoor{controller: 405419896, door: 2, name: "Loading Bay"}
+	until := time.Date(2026, 10, 19, 12, 45, 0, 0, time.Local)
+	c := Unlock{}
+
+	revert, err := c.unlock(ctx, door, until)
+	if err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	if s.state(405419896, 2).ControlState != types.NormallyOpen || s.state(405419896, 2).Delay != 7 {
+		t.Errorf("door not unlocked - expected:%v/%v, got:%v/%v", types.NormallyOpen, 7, s.state(405419896, 2).ControlState, s.state(405419896, 2).Delay)
+	}
+
+	// ... pending revert should survive a restart
+	if p, err := getPendingRevert(ctx, 405419896, 2); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if p == nil {
+		t.Fatalf("missing pending revert for %v", revert)
+	} else if p.Mode != types.Controlled || p.Delay != 7 || !p.Until.Equal(until) {
+		t.Errorf("incorrect pending revert %+v", p)
+	}
+
+	// ... unlocking again should retain the original mode
+	if revert, err := c.unlock(ctx, door, until.Add(15*time.Minute)); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if revert.Mode != types.Controlled || !revert.Until.Equal(until.Add(15*time.Minute)) {
+		t.Errorf("incorrect extended revert %+v", revert)
+	}
+
+	// ... extended unlock should not be restored by the original unlock
+	if err := c.revert(ctx, 405419896, 2, until); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if s.state(405419896, 2).ControlState != types.NormallyOpen {
+		t.Errorf("extended unlock restored")
+	}
+
+	if err := c.revert(ctx, 405419896, 2, until.Add(15*time.Minute)); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if s.state(405419896, 2).ControlState != types.Controlled || s.state(405419896, 2).Delay != 7 {
+		t.Errorf("door not restored - expected:%v/%v, got:%v/%v", types.Controlled, 7, s.state(405419896, 2).ControlState, s.state(405419896, 2).Delay)
+	}
+
+	if p, err := getPendingRevert(ctx, 405419896, 2); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if p != nil {
+		t.Errorf("pending revert not removed %+v", p)
+	}
+}
+
+func TestUnlockFailed(t *testing.T) {
+	s := doorControlStub{
+		doors: map[doorID]types.DoorControlState{
+			{405419896, 1}: {SerialNumber: 405419896, Door: 1, ControlState: types.NormallyClosed, Delay: 5},
+		},
+		offline: []uint32{405419896},
+	}
+
+	ctx := Context{
+		uhppote: &s,
+		settings: Settings{
+			values: map[string]string{"state": t.TempDir()},
+		},
+	}
+
+	c := Unlock{}
+	if _, err := c.unlock(ctx, configuredDoor{controller: 405419896, door: 1}, time.Now().Add(time.Hour)); err == nil {
+		t.Errorf("expected error")
+	}
+
+	if reverts, err := getPendingReverts(ctx); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if len(reverts) != 0 {
+		t.Errorf("expected no pending reverts, got %v", reverts)
+	}
+}
+
+func TestUnlockRestoreInterrupted(t *testing.T) {
+	s := doorControlStub{
+		doors: map[doorID]types.DoorControlState{
+			{405419896, 1}: {SerialNumber: 405419896, Door: 1, ControlState: types.Controlled, Delay: 5},
+		},
+	}
+
+	ctx := Context{
+		uhppote: &s,
+		settings: Settings{
+			values: map[string]string{"state": t.TempDir()},
+		},
+	}
+
+	c := Unlock{retries: 12, interval: time.Hour}
+	revert, err := c.unlock(ctx, configuredDoor{controller: 405419896, door: 1}, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	s.setOffline(405419896)
+
+	interrupt := make(chan os.Signal, 1)
+	interrupt <- os.Interrupt
+
+	errors := make(chan error, 1)
+	go func() {
+		errors <- c.restoreWithRetry(ctx, revert, interrupt)
+	}()
+
+	select {
+	case err := <-errors:
+		if err == nil || !strings.Contains(err.Error(), "unlock --restore") {
+			t.Errorf("expected 'unlock --restore' error, got %v", err)
+		}
+
+	case <-time.After(5 * time.Second):
+		t.Fatalf("retry loop not interrupted")
+	}
+
+	if p, err := getPendingRevert(ctx, 405419896, 1); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if p == nil {
+		t.Errorf("pending revert removed")
+	}
+}
+
+func TestUnlockRestorePending(t *testing.T) {
+	s := doorControlStub{
+		doors: map[doorID]types.DoorControlState{
+			{405419896, 1}: {SerialNumber: 405419896, Door: 1, ControlState: types.NormallyOpen, Delay: 5},
+			{405419896, 2}: {SerialNumber: 405419896, Door: 2, ControlState: types.NormallyOpen, Delay: 7},
+		},
+	}
+
+	ctx := Context{
+		uhppote: &s,
+		settings: Settings{
+			values: map[string]string{"state": t.TempDir()},
+		},
+	}
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	reverts := []pendingRevert{
+		{Controller: 405419896, Door: 1, Mode: types.Controlled, Delay: 5, Until: now.Add(-time.Minute)},
+		{Controller: 405419896, Door: 2, Mode: types.NormallyClosed, Delay: 7, Until: now.Add(time.Minute)},
+	}
+
+	for _, p := range reverts {
+		if err := savePendingRevert(ctx, p); err != nil {
+			t.Fatalf("unexpected error (%v)", err)
+		}
+	}
+
+	c := Unlock{}
+	if err := c.restorePending(ctx, now); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+
+	if s.state(405419896, 1).ControlState != types.Controlled {
+		t.Errorf("expired revert not restored - expected:%v, got:%v", types.Controlled, s.state(405419896, 1).ControlState)
+	}
+
+	if s.state(405419896, 2).ControlState != types.NormallyOpen {
+		t.Errorf("pending revert restored before expiry")
+	}
+
+	c.all = true
+	if err := c.restorePending(ctx, now); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if s.state(405419896, 2).ControlState != types.NormallyClosed {
+		t.Errorf("pending revert not restored - expected:%v, got:%v", types.NormallyClosed, s.state(405419896, 2).ControlState)
+	}
+
+	if reverts, err := getPendingReverts(ctx); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	} else if len(reverts) != 0 {
+		t.Errorf("expected no pending reverts, got %v", reverts)
+	}
+}
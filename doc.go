@@ -30,25 +30,46 @@ Supported commands:
   - put-card
   - delete-card
   - delete-all
+  - expiring-cards
+  - purge-expired
   - get-time-profile
   - set-time-profile
   - get-time-profiles
   - set-time-profiles
+  - sync-time-profiles
+  - check-time-profiles
   - clear-time-profiles
   - clear-task-list
   - add-task
   - refresh-task-list
   - set-task-list
+  - get-task-list
+  - diff-task-list
+  - import-holidays
+  - simulate-schedule
   - get-events
   - get-event
   - get-event-index
   - set-event-index
+  - explain
   - open
+  - unlock
+  - lockdown
+  - evacuate
+  - restore-doors
   - listen
+  - muster
+  - health-check
+  - tui
+  - shell
+  - run
+  - serve
+  - simulate
   - grant
   - revoke
   - load-acl
   - get-acl
   - compare-acl
+  - lint-acl
 */
 package cli
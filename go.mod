@@ -5,6 +5,7 @@ go 1.26
 require (
 	github.com/uhppoted/uhppote-core v0.9.1-0.20260413153340-6648eb33ce77
 	github.com/uhppoted/uhppoted-lib v0.9.1-0.20260413153439-864cae69b6e0
+	golang.org/x/term v0.42.0
 )
 
 require golang.org/x/sys v0.43.0 // indirect